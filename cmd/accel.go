@@ -0,0 +1,44 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// runWithoutAccel 以 GODEBUG=cpu.all=off 重新运行自身，返回子进程的退出码。
+// 运行时只在启动时读取该设置，无法在进程内关闭；BLAKE3 的 SIMD 实现由 BLAKE3_PUREGO 关闭
+func runWithoutAccel() int {
+	exe, err := os.Executable()
+	if err != nil {
+		fmt.Println(err)
+		return exitError
+	}
+	godebug := "cpu.all=off"
+	if v := os.Getenv("GODEBUG"); v != "" {
+		godebug = v + "," + godebug
+	}
+	c := exec.Command(exe, os.Args[1:]...)
+	c.Env = append(os.Environ(), "GODEBUG="+godebug, "BLAKE3_PUREGO=1")
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	err = c.Run()
+	if ee := (*exec.ExitError)(nil); errors.As(err, &ee) {
+		return ee.ExitCode()
+	}
+	if err != nil {
+		fmt.Println(err)
+		return exitError
+	}
+	return exitOK
+}
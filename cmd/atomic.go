@@ -0,0 +1,53 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package cmd
+
+import (
+	"os"
+)
+
+// partialSuffix 写入过程中的临时文件后缀
+const partialSuffix = ".partial"
+
+// atomicFile 先写入同目录下的 .partial 临时文件，全部写完后再重命名为目标文件，
+// 中途崩溃只会留下 .partial 文件，不会出现截断的目标文件
+type atomicFile struct {
+	*os.File
+	path string
+}
+
+// createAtomic 创建目标文件对应的临时文件
+func createAtomic(path string) (*atomicFile, error) {
+	f, err := os.OpenFile(path+partialSuffix, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &atomicFile{File: f, path: path}, nil
+}
+
+// Commit 落盘并将临时文件重命名为目标文件
+func (f *atomicFile) Commit() error {
+	if err := f.Sync(); err != nil {
+		f.Abort()
+		return err
+	}
+	if err := f.File.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), f.path)
+}
+
+// Abort 放弃写入并删除临时文件
+func (f *atomicFile) Abort() {
+	f.File.Close()
+	os.Remove(f.Name())
+}
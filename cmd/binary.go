@@ -0,0 +1,210 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package cmd
+
+import (
+	"bufio"
+	"duplicate-cleaner/duplicate"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// 二进制清单格式:
+//
+//	魔数 "DCLB" 版本(1字节) 生成程序(v2 起)
+//	根路径数 {路径 文件系统} 是否不完整(1字节)
+//	每组: 文件数(>0) 大小 Hash(原始字节) 策略 {与上一路径的公共前缀长度 剩余部分 类别 所有者}
+//	结束: 文件数 0
+//
+// 整数均为 uvarint，字符串为 uvarint 长度加 UTF-8 内容。
+// 同组及相邻组的路径大多有很长的公共前缀，只记录差异部分可大幅缩小清单
+const (
+	binaryMagic   = "DCLB"
+	binaryVersion = 2
+	binaryOldest  = 1 // v1 没有生成程序
+)
+
+// binaryWriter 二进制清单的写入器，记录上一条路径以压缩前缀
+type binaryWriter struct {
+	w    *bufio.Writer
+	last string
+	buf  [binary.MaxVarintLen64]byte
+}
+
+func (b *binaryWriter) uint(v uint64) {
+	n := binary.PutUvarint(b.buf[:], v)
+	b.w.Write(b.buf[:n])
+}
+
+func (b *binaryWriter) string(s string) {
+	b.uint(uint64(len(s)))
+	b.w.WriteString(s)
+}
+
+func (b *binaryWriter) bool(v bool) {
+	if v {
+		b.w.WriteByte(1)
+	} else {
+		b.w.WriteByte(0)
+	}
+}
+
+// path 写入与上一条路径的公共前缀长度和剩余部分
+func (b *binaryWriter) path(p string) {
+	n := 0
+	for n < len(p) && n < len(b.last) && p[n] == b.last[n] {
+		n += 1
+	}
+	b.uint(uint64(n))
+	b.string(p[n:])
+	b.last = p
+}
+
+// writeBinary 写出二进制格式，每写完一组就刷新一次
+func writeBinary(w io.Writer, meta *listMeta, l duplicate.GroupList) error {
+	b := &binaryWriter{w: bufio.NewWriter(w)}
+	b.w.WriteString(binaryMagic)
+	b.w.WriteByte(binaryVersion)
+	b.string(meta.Generator)
+	b.uint(uint64(len(meta.Roots)))
+	for _, r := range meta.Roots {
+		b.string(r.Path)
+		b.string(r.FS)
+	}
+	b.bool(meta.Partial)
+	for _, g := range l {
+		if len(g.Files) == 0 {
+			continue
+		}
+		hash, err := hex.DecodeString(g.Hash)
+		if err != nil {
+			return fmt.Errorf("无效的Hash值 %q", g.Hash)
+		}
+		b.uint(uint64(len(g.Files)))
+		b.uint(uint64(g.Size))
+		b.string(string(hash))
+		b.string(g.Strategy)
+		for _, f := range g.Files {
+			b.path(f.Path)
+			b.string(f.Type)
+			b.string(f.Owner)
+		}
+		if err := b.w.Flush(); err != nil {
+			return err
+		}
+	}
+	b.uint(0)
+	return b.w.Flush()
+}
+
+// binaryReader 二进制清单的读取器，出错后后续读取均返回零值，最后统一检查 err
+type binaryReader struct {
+	r    *bufio.Reader
+	last string
+	err  error
+}
+
+func (b *binaryReader) uint() uint64 {
+	if b.err != nil {
+		return 0
+	}
+	v, err := binary.ReadUvarint(b.r)
+	b.err = err
+	return v
+}
+
+func (b *binaryReader) string() string {
+	n := b.uint()
+	if b.err != nil {
+		return ""
+	}
+	// 防止损坏的长度导致分配过多内存
+	if n > 1<<20 {
+		b.err = errors.New("字符串长度异常")
+		return ""
+	}
+	buf := make([]byte, n)
+	_, b.err = io.ReadFull(b.r, buf)
+	return string(buf)
+}
+
+func (b *binaryReader) bool() bool {
+	if b.err != nil {
+		return false
+	}
+	c, err := b.r.ReadByte()
+	b.err = err
+	return c != 0
+}
+
+func (b *binaryReader) path() string {
+	n := b.uint()
+	rest := b.string()
+	if b.err == nil && n > uint64(len(b.last)) {
+		b.err = errors.New("路径前缀长度异常")
+	}
+	if b.err != nil {
+		return ""
+	}
+	b.last = b.last[:n] + rest
+	return b.last
+}
+
+// parseBinary 解析二进制格式，缺少结束标记时视为清单不完整
+func parseBinary(r *bufio.Reader) (*listMeta, duplicate.GroupList, error) {
+	head := make([]byte, len(binaryMagic)+1)
+	if _, err := io.ReadFull(r, head); err != nil {
+		return nil, nil, err
+	}
+	if string(head[:len(binaryMagic)]) != binaryMagic {
+		return nil, nil, errors.New("不是二进制清单")
+	}
+	v := int(head[len(binaryMagic)])
+	if err := duplicate.CheckVersion("二进制清单", v, binaryOldest, binaryVersion); err != nil {
+		return nil, nil, err
+	}
+	b := &binaryReader{r: r}
+	meta := &listMeta{}
+	if v >= 2 {
+		meta.Generator = b.string()
+	}
+	for i := b.uint(); i > 0 && b.err == nil; i-- {
+		meta.Roots = append(meta.Roots, rootInfo{Path: b.string(), FS: b.string()})
+	}
+	meta.Partial = b.bool()
+	groups := duplicate.GroupList{}
+	for b.err == nil {
+		n := b.uint()
+		if n == 0 || b.err != nil {
+			break
+		}
+		g := duplicate.Group{Size: int64(b.uint())}
+		g.Hash = hex.EncodeToString([]byte(b.string()))
+		g.Strategy = b.string()
+		for i := uint64(0); i < n && b.err == nil; i++ {
+			f := duplicate.FileInfo{Path: b.path(), Size: g.Size, Hash: g.Hash}
+			f.Type = b.string()
+			f.Owner = b.string()
+			g.Files = append(g.Files, f)
+		}
+		groups = append(groups, g)
+	}
+	if errors.Is(b.err, io.EOF) || errors.Is(b.err, io.ErrUnexpectedEOF) {
+		return nil, nil, errors.New("清单不完整，缺少结束标记，可能是写入时被中断")
+	}
+	if b.err != nil {
+		return nil, nil, b.err
+	}
+	return meta, groups, nil
+}
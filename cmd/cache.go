@@ -0,0 +1,41 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package cmd
+
+import (
+	"duplicate-cleaner/duplicate"
+	"fmt"
+)
+
+// openCache 打开 -cache 指定的Hash值缓存，不存在时创建
+func openCache(path string, hashName string) (*duplicate.HashCache, error) {
+	c, err := duplicate.OpenHashCache(path, hashName)
+	if err != nil {
+		return nil, fmt.Errorf("打开缓存 %s 失败: %w", path, err)
+	}
+	fmt.Printf("缓存 %s 中有 %d 个文件的 %s Hash值\n", path, c.Len(), duplicate.HashName(hashName))
+	return c, nil
+}
+
+// closeCache 关闭缓存，complete 为 true 时先移除扫描路径下已删除或已改变的文件的条目
+func closeCache(c *duplicate.HashCache, path string, roots []string, complete bool) {
+	if complete {
+		n, err := c.Prune(roots)
+		if err != nil {
+			fmt.Printf("清理缓存 %s 失败: %v\n", path, err)
+		} else if n > 0 {
+			fmt.Printf("已从缓存中移除 %d 个过期条目\n", n)
+		}
+	}
+	if err := c.Close(); err != nil {
+		fmt.Printf("保存缓存 %s 失败: %v\n", path, err)
+	}
+}
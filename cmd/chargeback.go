@@ -0,0 +1,152 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package cmd
+
+import (
+	"duplicate-cleaner/duplicate"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 计费汇总的统计维度
+const (
+	chargeOwner = "owner" // 按文件所有者
+	chargeRoot  = "root"  // 按扫描路径(项目)
+)
+
+// chargeAccount 单个账户的占用汇总
+type chargeAccount struct {
+	Account     string `json:"account"`
+	Files       int    `json:"files"`        // 扫描到的文件数
+	Bytes       int64  `json:"bytes"`        // 扫描到的文件总大小
+	DupFiles    int    `json:"dup_files"`    // 可清理的重复副本数
+	DupBytes    int64  `json:"dup_bytes"`    // 可清理的重复副本大小
+	UniqueBytes int64  `json:"unique_bytes"` // 去重后仍需占用的大小
+}
+
+// chargeback 在扫描过程中按账户累计文件占用，扫描结束后结合重复清单生成计费汇总
+type chargeback struct {
+	m        sync.Mutex
+	by       string
+	roots    []string
+	accounts map[string]*chargeAccount
+}
+
+func newChargeback(by string, roots []rootInfo) *chargeback {
+	c := &chargeback{by: by, accounts: map[string]*chargeAccount{}}
+	for _, r := range roots {
+		c.roots = append(c.roots, r.Path)
+	}
+	// 嵌套的扫描路径按最长的匹配
+	sort.Slice(c.roots, func(i, j int) bool { return len(c.roots[i]) > len(c.roots[j]) })
+	return c
+}
+
+// Attach 挂接到扫描选项的回调上，原有回调仍会被调用
+func (c *chargeback) Attach(opts *duplicate.Options) {
+	onScanned := opts.OnFileScanned
+	opts.OnFileScanned = func(f duplicate.FileInfo) {
+		c.m.Lock()
+		a := c.account(f)
+		a.Files += 1
+		a.Bytes += f.Size
+		c.m.Unlock()
+		if onScanned != nil {
+			onScanned(f)
+		}
+	}
+	if c.by == chargeOwner {
+		opts.Owners = true
+	}
+}
+
+// account 返回文件所属的账户
+func (c *chargeback) account(f duplicate.FileInfo) *chargeAccount {
+	name := ownerName(f.Owner)
+	if c.by == chargeRoot {
+		name = c.root(f.Path)
+	}
+	a, ok := c.accounts[name]
+	if !ok {
+		a = &chargeAccount{Account: name}
+		c.accounts[name] = a
+	}
+	return a
+}
+
+// root 返回文件所在的扫描路径
+func (c *chargeback) root(path string) string {
+	for _, r := range c.roots {
+		if path == r || strings.HasPrefix(path, r+string(filepath.Separator)) || strings.HasPrefix(path, r+duplicate.ArchiveSep) {
+			return r
+		}
+	}
+	return "unknown"
+}
+
+// Summary 按重复清单计入各账户的重复副本，每组第一个文件视为原件
+func (c *chargeback) Summary(l duplicate.GroupList) []chargeAccount {
+	c.m.Lock()
+	defer c.m.Unlock()
+	for _, g := range l {
+		for _, f := range g.Files[1:] {
+			a := c.account(f)
+			a.DupFiles += 1
+			a.DupBytes += f.Size
+		}
+	}
+	lst := []chargeAccount{}
+	for _, a := range c.accounts {
+		a.UniqueBytes = a.Bytes - a.DupBytes
+		lst = append(lst, *a)
+	}
+	sort.Slice(lst, func(i, j int) bool { return lst[i].Account < lst[j].Account })
+	return lst
+}
+
+// writeChargeback 写出计费汇总，扩展名为 .json 时输出 JSON，否则输出 CSV
+func writeChargeback(w io.Writer, file string, by string, lst []chargeAccount) error {
+	if strings.EqualFold(filepath.Ext(file), ".json") {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			By       string          `json:"by"`
+			Accounts []chargeAccount `json:"accounts"`
+		}{by, lst})
+	}
+	cw := csv.NewWriter(w)
+	cw.Write([]string{by, "files", "bytes", "dup_files", "dup_bytes", "unique_bytes"})
+	for _, a := range lst {
+		cw.Write([]string{a.Account, strconv.Itoa(a.Files), strconv.FormatInt(a.Bytes, 10),
+			strconv.Itoa(a.DupFiles), strconv.FormatInt(a.DupBytes, 10), strconv.FormatInt(a.UniqueBytes, 10)})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// saveChargeback 以原子方式保存计费汇总
+func saveChargeback(file string, by string, lst []chargeAccount) error {
+	f, err := createAtomic(file)
+	if err != nil {
+		return err
+	}
+	if err := writeChargeback(f, file, by, lst); err != nil {
+		f.Abort()
+		return err
+	}
+	return f.Commit()
+}
@@ -0,0 +1,113 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package cmd
+
+import (
+	"duplicate-cleaner/duplicate"
+	"fmt"
+)
+
+// check 预检删除清单并输出报告，有未通过的文件时返回这些文件的错误
+func check(cfg *Config) error {
+	pick, err := cfg.picker()
+	if err != nil {
+		return err
+	}
+	pm, err := cfg.pathMap()
+	if err != nil {
+		return err
+	}
+	files, err := readListFiles(cfg.args, pick, pm)
+	if err != nil {
+		return err
+	}
+	results := duplicate.NewCleaner(duplicate.CleanOptions{Progress: cfg.showBar(), OnProgress: cfg.onProgress(), AllowRisky: cfg.allowRisky, Protected: cfg.protectDirs()}).Check(files)
+	errs := []error{}
+	for _, r := range results {
+		if r.Err != nil {
+			fmt.Printf("FAIL\t%s\t%v\n", r.Path, r.Err)
+			errs = append(errs, r.Err)
+		} else if cfg.verbose {
+			fmt.Printf("PASS\t%s\n", r.Path)
+		}
+	}
+	fmt.Printf("预检 %d 个文件，通过 %d 个，未通过 %d 个\n", len(results), len(results)-len(errs), len(errs))
+	if len(errs) == 0 {
+		return nil
+	}
+	return checkError(errs)
+}
+
+// dryRun 按清单和 -keep 等参数列出将被清理的文件，只检查文件存在、可删除且未受保护，
+// 汇总将释放的空间，不修改任何文件；有无法清理的文件时返回这些文件的错误
+func dryRun(cfg *Config) error {
+	pick, err := cfg.picker()
+	if err != nil {
+		return err
+	}
+	pm, err := cfg.pathMap()
+	if err != nil {
+		return err
+	}
+	files, err := readListFiles(cfg.args, pick, pm)
+	if err != nil {
+		return err
+	}
+	// 多个清单中重复出现的文件只清理一次
+	seen := map[string]bool{}
+	uniq := files[:0]
+	for _, f := range files {
+		if !seen[f.Path] {
+			seen[f.Path] = true
+			uniq = append(uniq, f)
+		}
+	}
+	results := duplicate.NewCleaner(duplicate.CleanOptions{AllowRisky: cfg.allowRisky, Protected: cfg.protectDirs()}).DryRun(uniq)
+	errs := []error{}
+	var total int64
+	for _, r := range results {
+		if r.Err != nil {
+			fmt.Printf("FAIL\t%s\t%v\n", r.Path, r.Err)
+			errs = append(errs, r.Err)
+			continue
+		}
+		total += r.Size
+		if cfg.verbose {
+			fmt.Printf("OK\t%s\t%s\n", r.Path, formatSize(r.Size))
+		}
+	}
+	n := len(results) - len(errs)
+	switch {
+	case cfg.quarantine != "":
+		fmt.Printf("试运行: 将把 %d 个文件(%s)移到隔离目录 %s", n, formatSize(total), cfg.quarantine)
+	case cfg.trash:
+		fmt.Printf("试运行: 将把 %d 个文件(%s)移到回收站，清空回收站后释放", n, formatSize(total))
+	default:
+		fmt.Printf("试运行: 将清理 %d 个文件，释放 %s", n, formatSize(total))
+	}
+	fmt.Printf("，%d 个文件无法清理，未修改任何文件\n", len(errs))
+	if len(errs) == 0 {
+		return nil
+	}
+	return checkError(errs)
+}
+
+// checkError 预检未通过，各文件的错误已在报告中列出，这里只给出汇总，
+// 仍可通过 errors.Is 判断错误分类以确定退出码
+type checkError []error
+
+func (e checkError) Error() string {
+	return fmt.Sprintf("%d 个文件未通过预检", len(e))
+}
+
+func (e checkError) Unwrap() []error {
+	return e
+}
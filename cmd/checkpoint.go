@@ -0,0 +1,92 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package cmd
+
+import (
+	"context"
+	"duplicate-cleaner/duplicate"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"slices"
+)
+
+// checkpointSuffix 未指定 -checkpoint 时，检查点与清单放在一起
+const checkpointSuffix = ".checkpoint"
+
+// indexSuffix 增量扫描未指定 -checkpoint 时，索引与清单放在一起
+const indexSuffix = ".dci"
+
+// loadCheckpoint 读取检查点中已算出的Hash值，文件不存在时返回空索引。
+// 检查点中其他算法算出的Hash值予以保留，扫描时用于排除不重复的文件，可能重复的文件按本次的算法重新计算
+func loadCheckpoint(path string, hashName string) (*duplicate.HashIndex, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return duplicate.NewHashIndex(hashName), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	idx, err := duplicate.LoadHashIndex(f)
+	if err != nil {
+		return nil, fmt.Errorf("读取检查点 %s 失败: %w", path, err)
+	}
+	idx.UseHash(hashName)
+	algos := idx.Algos()
+	for _, algo := range slices.Sorted(maps.Keys(algos)) {
+		if algo != idx.Hash() {
+			fmt.Printf("检查点 %s 中有 %d 个文件的Hash值使用 %s 算法，与本次的 %s 不同，可能重复的文件将重新计算\n", path, algos[algo], algo, idx.Hash())
+		}
+	}
+	if idx.Version() < duplicate.IndexVersion {
+		fmt.Printf("检查点 %s 为旧版本 v%d，已自动迁移，保存时将写为 v%d\n", path, idx.Version(), duplicate.IndexVersion)
+	}
+	if saved := idx.Saved(); !saved.IsZero() {
+		fmt.Printf("从检查点 %s 载入 %d 个文件的Hash值(保存于 %s)\n", path, idx.Len(), saved.Local().Format("2006-01-02 15:04:05"))
+	} else {
+		fmt.Printf("从检查点 %s 载入 %d 个文件的Hash值\n", path, idx.Len())
+	}
+	return idx, nil
+}
+
+// saveCheckpoint 以原子方式保存检查点
+func saveCheckpoint(path string, idx *duplicate.HashIndex) error {
+	f, err := createAtomic(path)
+	if err != nil {
+		return err
+	}
+	if err := idx.Save(f); err != nil {
+		f.Abort()
+		return err
+	}
+	return f.Commit()
+}
+
+// splitDeadline 判断扫描是否因超时停止，并去掉错误中的超时部分
+func splitDeadline(err error) (bool, error) {
+	if !errors.Is(err, context.DeadlineExceeded) {
+		return false, err
+	}
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return true, nil
+	}
+	errs := []error{}
+	for _, e := range joined.Unwrap() {
+		if !errors.Is(e, context.DeadlineExceeded) {
+			errs = append(errs, e)
+		}
+	}
+	return true, errors.Join(errs...)
+}
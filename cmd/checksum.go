@@ -0,0 +1,49 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package cmd
+
+import (
+	"bufio"
+	"duplicate-cleaner/duplicate"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// sumEscaper 按 coreutils 的规则转义文件名中的反斜杠和换行符
+var sumEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, "\r", `\r`)
+
+// writeSum 写出与 md5sum、sha256sum、b3sum 等校验工具兼容的 `Hash值  路径` 行，可用 `sha256sum -c` 等重新校验。
+// 归档内的文件无法由这些工具读取，不输出
+func writeSum(w io.Writer, l duplicate.GroupList) error {
+	bw := bufio.NewWriter(w)
+	for _, g := range l {
+		for _, f := range g.Files {
+			if duplicate.IsArchivePath(f.Path) {
+				continue
+			}
+			hash := f.Hash
+			if hash == "" {
+				hash = g.Hash
+			}
+			if hash == "" {
+				return fmt.Errorf("%s: 清单中没有Hash值，无法输出校验和", f.Path)
+			}
+			// 文件名需要转义时，行首加上反斜杠
+			if path := sumEscaper.Replace(f.Path); path != f.Path {
+				fmt.Fprintf(bw, "\\%s  %s\n", hash, path)
+			} else {
+				fmt.Fprintf(bw, "%s  %s\n", hash, path)
+			}
+		}
+	}
+	return bw.Flush()
+}
@@ -11,150 +11,1115 @@ license that can be found in the LICENSE file.
 package cmd
 
 import (
-	"bufio"
+	"context"
 	"duplicate-cleaner/duplicate"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 )
 
 type Config struct {
 	hash    string
+	format  string
 	list    bool
 	clean   bool
 	outFile string
 	count   int
 	args    []string
+
+	progress string
+
+	maxFiles  int
+	maxBytes  byteSize
+	minSize   byteSize
+	maxSize   byteSize
+	walkQPS   float64
+	archives  stringList
+	byExt     bool
+	adaptive  bool
+	verbose   bool
+	estimate  bool
+	record    string
+	replay    string
+	resume    bool
+	types     string
+	exts      string
+	noHidden  bool
+	maxDepth  int
+	follow    bool
+	oneFS     bool
+	noIgnore  bool
+	gitIgnore bool
+	older     timeBound
+	newer     timeBound
+	showType  bool
+	byOwner   bool
+	ownerDir  string
+	charge    string
+	chargeBy  string
+	check     bool
+
+	maxDuration time.Duration
+	checkpoint  string
+	cache       string
+	incremental bool
+	workdir     string
+	minFree     byteSize
+	noAccel     bool
+	signature   bool
+	screen      bool
+
+	digest    string
+	digestTop int
+	mailTo    string
+	mailFrom  string
+	smtp      string
+	smtpUser  string
+
+	config      string
+	webhook     string
+	webhookType string
+	reportURL   string
+	keepReports int
+	convert     string
+	splitBy     string
+	keep        string
+	preferName  string
+	protect     stringList
+	exclude     stringList
+	include     stringList
+	keepMatch   stringList
+	deleteMatch stringList
+	scanShared  bool
+	minCopies   int
+	describe    bool
+	preview     int
+	verifyIndex bool
+	sample      int
+	latin       bool
+	uri         bool
+	emitScript  string
+	deleteList  string
+	prefilter   bool
+	apply       string
+	restore     string
+	dryRun      bool
+	verify      bool
+	tolerant    bool
+	skipOpen    bool
+	scope       string
+	hardlink    bool
+	preset      string
+	symlink     bool
+	relative    bool
+	allowRisky  bool
+	reflink     bool
+	trash       bool
+	quarantine  string
+	ledger      string
+	history     bool
+	showVersion bool
+	selfUpdate  bool
+	updateURL   string
+	downgrade   bool
+	schema      string
+	validate    bool
+	interactive bool
+	tui         bool
+	session     string
+	mapPaths    stringList
+	priorities  stringList
+	template    string
+
+	purge         bool
+	retain        time.Duration
+	quarantineMax byteSize
 }
 
 const splitLine = "--------"
 
 // Execute 运行命令行
 func Execute() {
-	cfg := parseConfig()
-	if err := checkConfig(cfg); err != nil {
+	cfg, err := parseConfig()
+	if err == nil {
+		err = checkConfig(cfg)
+	}
+	if err != nil {
 		fmt.Println(err)
-		return
+		os.Exit(exitUsage)
+	}
+	if cfg.noAccel && !duplicate.AccelDisabled() {
+		os.Exit(runWithoutAccel())
+	}
+	if cfg.showVersion {
+		printVersion()
+	}
+	if cfg.history {
+		err = history(cfg)
+	}
+	if cfg.selfUpdate {
+		err = selfUpdate(cfg)
+	}
+	if cfg.validate {
+		err = validate(cfg)
+	} else if cfg.schema != "" {
+		err = printSchema(cfg)
 	}
 	if cfg.list {
-		if err := list(cfg); err != nil {
+		err = list(cfg)
+	}
+	if cfg.clean {
+		err = clean(cfg)
+	}
+	if err != nil {
+		fmt.Println(err)
+	}
+	os.Exit(exitCode(err))
+}
+
+// list 列出重复文件
+func list(cfg *Config) error {
+	if cfg.screen {
+		return screen(cfg)
+	}
+	if cfg.verifyIndex {
+		return verifyIndex(cfg)
+	}
+	if cfg.convert != "" {
+		return convert(cfg)
+	}
+	args, err := duplicate.ExpandRoots(cfg.args)
+	if err != nil {
+		return err
+	}
+	roots, overlaps := duplicate.MergeRoots(args)
+	for _, o := range overlaps {
+		if o.Root == o.Parent {
+			fmt.Printf("警告: 扫描路径 %s 重复指定，只扫描一次\n", o.Root)
+		} else {
+			fmt.Printf("警告: 扫描路径 %s 位于 %s 之下，已合并\n", o.Root, o.Parent)
+		}
+	}
+	if kept, shared := duplicate.SharedRoots(roots); len(shared) > 0 {
+		for _, s := range shared {
+			fmt.Printf("警告: 扫描路径 %s 与 %s 是同一份数据(绑定挂载或同一子卷的多次挂载)，两者之间的“重复文件”其实是同一个文件", s.Root, s.Same)
+			if cfg.scanShared {
+				fmt.Println("，切勿清理")
+			} else {
+				fmt.Println("，已跳过")
+			}
+		}
+		if !cfg.scanShared {
+			roots = kept
+		}
+	}
+	cfg.args = roots
+	if cfg.estimate {
+		return estimate(cfg)
+	}
+	if cfg.replay != "" {
+		return replay(cfg)
+	}
+	tmpl, err := cfg.listTemplate()
+	if err != nil {
+		return err
+	}
+	meta := &listMeta{Generator: currentBuild().String(), Roots: detectRoots(cfg.args), Verbose: cfg.verbose, Latin: cfg.latin, Template: tmpl}
+	for _, a := range cfg.archives {
+		meta.Roots = append(meta.Roots, rootInfo{Path: duplicate.CanonicalPath(a), FS: strings.TrimPrefix(strings.ToLower(filepath.Ext(a)), ".")})
+	}
+	roots = []string{}
+	for _, r := range meta.Roots {
+		roots = append(roots, r.Path)
+	}
+	lk, err := acquireLock(lockScan, roots)
+	if err != nil {
+		return err
+	}
+	defer lk.Release()
+	opts := cfg.scanOptions()
+	if cfg.record != "" {
+		f, err := os.Create(cfg.record)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		rec := duplicate.NewTraceRecorder(f, roots, opts)
+		rec.Attach(&opts)
+		defer func() {
+			if err := rec.Err(); err != nil {
+				fmt.Printf("写入轨迹文件 %s 失败: %v\n", cfg.record, err)
+			}
+		}()
+	}
+	var sum *summary
+	if cfg.digest != "" || cfg.mailTo != "" || cfg.webhook != "" {
+		sum = newSummary(meta.Roots)
+		sum.Latin = cfg.latin
+		sum.URI = cfg.uri
+		sum.Attach(&opts)
+	}
+	var cb *chargeback
+	if cfg.charge != "" {
+		cb = newChargeback(cfg.chargeBy, meta.Roots)
+		cb.Attach(&opts)
+	}
+	var cov *archiveCoverage
+	if len(cfg.archives) > 0 {
+		cov = newArchiveCoverage(cfg.archives)
+		cov.Attach(&opts)
+	}
+	ctx := context.Background()
+	if cfg.maxDuration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.maxDuration)
+		defer cancel()
+	}
+	ckpt := cfg.checkpoint
+	switch {
+	case ckpt != "":
+	case cfg.incremental:
+		ckpt = cfg.workPath(cfg.outFile + indexSuffix)
+	case cfg.maxDuration > 0:
+		ckpt = cfg.workPath(cfg.outFile + checkpointSuffix)
+	}
+	if err := cfg.checkFreeSpace(cfg.outFile, ckpt); err != nil {
+		return err
+	}
+	if ckpt != "" {
+		if opts.Index, err = loadCheckpoint(ckpt, cfg.hash); err != nil {
+			return err
+		}
+		opts.Signatures = cfg.signature
+	}
+	if cfg.cache != "" {
+		if opts.Cache, err = openCache(cfg.cache, cfg.hash); err != nil {
+			return err
+		}
+	}
+	if cfg.verbose {
+		fmt.Printf("Hash算法 %s，实现: %s\n", duplicate.HashName(cfg.hash), duplicate.Accel(cfg.hash))
+	}
+	sc := duplicate.NewScanner(cfg.args, opts)
+	var l duplicate.GroupList
+	var stream *jsonlStream
+	if cfg.format == formatJSONL {
+		if cfg.keepReports > 0 {
+			if err := rotateReport(cfg.outFile, cfg.keepReports); err != nil {
+				return fmt.Errorf("归档历史清单失败: %v", err)
+			}
+		}
+		stream = &jsonlStream{path: cfg.outFile, meta: meta}
+		defer stream.Abort()
+		l, err = streamList(ctx, cfg, sc, stream)
+	} else {
+		l, err = sc.ListContext(ctx)
+	}
+	if cfg.verbose {
+		st := sc.Stats()
+		fmt.Printf("遍历目录 %d 个，最大深度 %d\n", st.Dirs, st.MaxDepth)
+		for _, m := range st.Mounts {
+			fmt.Printf("跳过其他文件系统: %s\n", m)
+		}
+		if st.Hardlinks > 0 {
+			fmt.Printf("跳过 %d 个与其他候选文件是同一文件的硬链接\n", st.Hardlinks)
+		}
+	}
+	meta.Volatile = sc.Volatile()
+	printVolatile(meta.Volatile)
+	meta.Open = sc.OpenFiles()
+	printOpen(meta.Open)
+	meta.Partial, err = splitDeadline(err)
+	if ckpt != "" {
+		// 扫描完成且检查点是自动生成的，不再需要；增量扫描的索引总会保留
+		if !meta.Partial && cfg.checkpoint == "" && !cfg.incremental {
+			os.Remove(ckpt)
+		} else {
+			if cfg.incremental && !meta.Partial && err == nil {
+				if n := opts.Index.Prune(roots); n > 0 {
+					fmt.Printf("已从索引中移除 %d 个已删除或已改变的文件\n", n)
+				}
+			}
+			if e := saveCheckpoint(ckpt, opts.Index); e != nil {
+				fmt.Printf("保存检查点 %s 失败: %v\n", ckpt, e)
+			}
+		}
+	}
+	if opts.Cache != nil {
+		closeCache(opts.Cache, cfg.cache, roots, !meta.Partial && err == nil)
+	}
+	if meta.Partial {
+		fmt.Printf("已达到最长扫描时间 %v，清单不完整；再次运行相同的命令将从检查点 %s 继续\n", cfg.maxDuration, ckpt)
+	}
+	if err != nil {
+		return err
+	}
+	if stream == nil {
+		cfg.annotate(l)
+	}
+	if cov != nil {
+		printCoverage(cov.Summary(l), meta.Partial)
+	}
+	// 没有重复文件时也输出汇总，以便计费系统获得各账户的总占用
+	if cb != nil {
+		if err := saveChargeback(cfg.charge, cfg.chargeBy, cb.Summary(l)); err != nil {
+			return err
+		}
+	}
+	if sum != nil {
+		sum.Partial = meta.Partial
+		if len(l) > 0 {
+			sum.Report, _ = filepath.Abs(cfg.outFile)
+		}
+		sum.Finish(l, cfg.digestTop)
+		if err := notifyDigest(cfg, sum); err != nil {
+			return err
+		}
+		if cfg.webhook != "" {
+			if err := postWebhook(cfg, sum); err != nil {
+				return err
+			}
+		}
+	}
+	if meta.Partial && len(l) == 0 {
+		fmt.Println("停止前尚未确认任何重复的组")
+		return nil
+	}
+	if stream != nil {
+		if err := stream.Close(); err != nil {
+			return err
+		}
+	} else {
+		if cfg.keepReports > 0 {
+			if err := rotateReport(cfg.outFile, cfg.keepReports); err != nil {
+				return fmt.Errorf("归档历史清单失败: %v", err)
+			}
+		}
+		if err := saveList(cfg.outFile, cfg.format, meta, l); err != nil {
+			return err
+		}
+	}
+	if cfg.splitBy != "" {
+		if err := writeSplit(cfg.outFile, cfg.format, cfg.splitBy, meta, l); err != nil {
+			return err
+		}
+	}
+	if cfg.deleteList != "" {
+		n, size, err := writeDeleteList(cfg, cfg.deleteList, l)
+		if err != nil {
+			return fmt.Errorf("写入删除列表失败: %v", err)
+		}
+		fmt.Printf("删除列表 %s: %d 个文件，可释放 %s，审阅后可用 -c %s 清理\n", cfg.deleteList, n, formatSize(size), cfg.deleteList)
+	}
+	warnRisky(l)
+	if cfg.byOwner {
+		printOwners(l)
+	}
+	if cfg.ownerDir != "" {
+		return writeOwnerReports(cfg.ownerDir, cfg.format, meta, l)
+	}
+	return nil
+}
+
+// annotate 按 -describe、-preview、-uri 为各组补充元数据、文本预览和 URI
+func (cfg *Config) annotate(l duplicate.GroupList) {
+	if cfg.describe {
+		if err := l.Describe(); err != nil && cfg.verbose {
 			fmt.Println(err)
 		}
-		return
 	}
-	if cfg.clean {
-		if err := clean(cfg); err != nil {
+	if cfg.preview > 0 {
+		if err := l.Preview(cfg.preview); err != nil && cfg.verbose {
 			fmt.Println(err)
 		}
-		return
+	}
+	if cfg.uri {
+		l.URIs()
 	}
 }
 
-// list 列出重复文件
-func list(cfg *Config) error {
-	l, err := duplicate.List(cfg.args, cfg.hash, cfg.count)
+// replay 根据轨迹文件重新分组并输出清单，不访问被扫描的文件
+func replay(cfg *Config) error {
+	f, err := os.Open(cfg.replay)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	l, header, err := duplicate.Replay(f)
+	if err != nil {
+		return err
+	}
+	l = l.MinCopies(cfg.minCopies)
+	tmpl, err := cfg.listTemplate()
 	if err != nil {
 		return err
 	}
-	if err := saveList(cfg.outFile, l); err != nil {
+	meta := &listMeta{Generator: currentBuild().String(), Verbose: cfg.verbose, Latin: cfg.latin, Template: tmpl}
+	for _, r := range header.Roots {
+		meta.Roots = append(meta.Roots, rootInfo{Path: r, FS: "replay"})
+	}
+	return saveList(cfg.outFile, cfg.format, meta, l)
+}
+
+// convert 将清单转换为 -format 指定的格式，如将二进制清单转为文本以便查看
+func convert(cfg *Config) error {
+	meta, l, err := loadList(cfg.convert)
+	if err != nil {
+		return err
+	}
+	if meta.Template, err = cfg.listTemplate(); err != nil {
+		return err
+	}
+	meta.Verbose, meta.Latin = cfg.verbose, cfg.latin
+	// 保留原清单记录的生成程序，没有记录时(如文本格式)记为本程序
+	if meta.Generator == "" {
+		meta.Generator = currentBuild().String()
+	}
+	if cfg.uri {
+		l.URIs()
+	}
+	return saveList(cfg.outFile, cfg.format, meta, l)
+}
+
+// estimate 只按大小分组，输出重复情况的上限
+func estimate(cfg *Config) error {
+	est, err := duplicate.NewScanner(cfg.args, cfg.scanOptions()).Estimate()
+	if err != nil {
 		return err
 	}
+	fmt.Printf("候选文件 %d 个，大小相同的组 %d 组\n", est.Files, est.Groups)
+	fmt.Printf("最多 %d 个重复文件，最多可释放 %s (%d 字节)\n", est.Dups, formatSize(est.Bytes), est.Bytes)
 	return nil
 }
 
+// detectRoots 检测各扫描路径所在的文件系统，网络或用户态文件系统给出警告
+func detectRoots(dirs []string) []rootInfo {
+	roots := []rootInfo{}
+	for _, dir := range dirs {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			abs = dir
+		}
+		fs, _ := duplicate.DetectFS(abs)
+		if fs.Network {
+			fmt.Printf("警告: %s 位于网络或用户态文件系统(%s)上，修改时间和 inode 可能不可靠，云存储挂载可用 -walk-qps 限制请求速率\n", abs, fs.Type)
+		}
+		roots = append(roots, rootInfo{Path: abs, FS: fs.Type})
+	}
+	return roots
+}
+
+// scanOptions 根据参数生成扫描选项
+func (cfg *Config) scanOptions() duplicate.Options {
+	opts := duplicate.Options{
+		Hash:       cfg.hash,
+		Count:      cfg.count,
+		Progress:   cfg.showBar(),
+		MaxFiles:   cfg.maxFiles,
+		OnProgress: cfg.onProgress(),
+		MaxBytes:   int64(cfg.maxBytes),
+		MinSize:    int64(cfg.minSize),
+		MaxSize:    int64(cfg.maxSize),
+
+		ModifiedBefore: cfg.older.t,
+		ModifiedAfter:  cfg.newer.t,
+		WalkQPS:        cfg.walkQPS,
+		Archives:       cfg.archives,
+		ByExt:          cfg.byExt,
+		Adaptive:       cfg.adaptive,
+
+		Prefilter:      cfg.prefilter,
+		Verify:         cfg.verify,
+		Tolerant:       cfg.tolerant,
+		SkipOpen:       cfg.skipOpen,
+		Scope:          cfg.scope,
+		MinCopies:      cfg.minCopies,
+		DetectTypes:    cfg.showType,
+		Owners:         cfg.byOwner || cfg.ownerDir != "",
+		SkipHidden:     cfg.noHidden,
+		MaxDepth:       cfg.maxDepth,
+		FollowSymlinks: cfg.follow,
+		OneFileSystem:  cfg.oneFS,
+	}
+	if cfg.exts != "" {
+		for _, e := range strings.Split(cfg.exts, ",") {
+			if e = strings.TrimSpace(e); e != "" {
+				opts.Exts = append(opts.Exts, e)
+			}
+		}
+	}
+	if cfg.types != "" {
+		opts.Types = strings.Split(cfg.types, ",")
+	}
+	if cfg.preset != "" {
+		// 名称已在 checkConfig 中检查过
+		opts.Exclude, _ = duplicate.PresetExcludes(strings.Split(cfg.preset, ","))
+	}
+	// -exclude 同时用于目录和文件
+	opts.Exclude = append(opts.Exclude, cfg.exclude...)
+	opts.Skip = cfg.exclude
+	opts.Include = cfg.include
+	if !cfg.noIgnore {
+		opts.IgnoreFiles = []string{duplicate.DcIgnore}
+	}
+	if cfg.gitIgnore {
+		opts.IgnoreFiles = append(opts.IgnoreFiles, duplicate.GitIgnores...)
+	}
+	// 已在 checkConfig 中检查过
+	opts.Priorities, _ = cfg.scanPriorities()
+	if cfg.verbose {
+		opts.OnError = func(err error) { fmt.Println(err) }
+	}
+	return opts
+}
+
 // saveList 保存重复清单
-func saveList(f string, l duplicate.DupList) error {
+func saveList(f string, format string, meta *listMeta, l duplicate.GroupList) error {
 	if len(l) == 0 {
-		return errors.New("无重复文件")
+		return errNoDuplicates
+	}
+	// 二进制清单不在屏幕上显示
+	return writeListFile(f, format, meta, l, format != formatBinary)
+}
+
+// writeListFile 原子地写出清单文件，按扩展名压缩，echo 为 true 时同时输出到屏幕
+func writeListFile(f string, format string, meta *listMeta, l duplicate.GroupList, echo bool) error {
+	file, err := createAtomic(f)
+	if err != nil {
+		return err
 	}
-	file, err := os.OpenFile(f, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	zw, err := compressWriter(file, f)
 	if err != nil {
+		file.Abort()
 		return err
 	}
-	defer file.Close()
-	writer := io.MultiWriter(file, os.Stdout)
-	for _, v := range l {
-		io.WriteString(writer, splitLine+"\n")
-		for _, s := range v {
-			io.WriteString(writer, fmt.Sprintf("%s\t%dB\t%s\n", s.Path, s.Size, s.Hash))
-		}
+	var writer io.Writer = zw
+	if echo {
+		writer = io.MultiWriter(zw, os.Stdout)
 	}
-	return nil
+	if err := writeList(writer, format, meta, l); err != nil {
+		file.Abort()
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		file.Abort()
+		return err
+	}
+	return file.Commit()
 }
 
 // clean
 func clean(cfg *Config) error {
-	delList, err := readList(cfg.args)
+	if cfg.check {
+		return check(cfg)
+	}
+	if cfg.emitScript != "" {
+		return emitScript(cfg)
+	}
+	if cfg.apply != "" {
+		return applyPlan(cfg)
+	}
+	if cfg.restore != "" {
+		return restore(cfg)
+	}
+	if cfg.purge {
+		return purge(cfg)
+	}
+	if cfg.dryRun {
+		return dryRun(cfg)
+	}
+	if cfg.hardlink || cfg.symlink || cfg.reflink {
+		return replaceWithLinks(cfg)
+	}
+	pick, err := cfg.picker()
 	if err != nil {
 		return err
 	}
-	n, err := duplicate.Clean(delList)
+	pm, err := cfg.pathMap()
 	if err != nil {
 		return err
 	}
-	fmt.Printf("成功清理 %d 个文件", n)
+	var infos []duplicate.FileInfo
+	switch {
+	case cfg.interactive:
+		infos, err = selectInteractive(cfg.args, pm, os.Stdin, os.Stdout)
+	case cfg.tui:
+		infos, err = selectTUI(cfg.args, pm, cfg.session)
+	default:
+		infos, err = readListFiles(cfg.args, pick, pm)
+	}
+	if err != nil {
+		return err
+	}
+	if (cfg.interactive || cfg.tui) && len(infos) == 0 {
+		fmt.Println("未选择要清理的文件")
+		return nil
+	}
+	delList := make([]string, 0, len(infos))
+	for _, f := range infos {
+		delList = append(delList, f.Path)
+	}
+	lk, err := acquireLock(lockClean, cfg.cleanLockRoots(delList))
+	if err != nil {
+		return err
+	}
+	defer lk.Release()
+	jpath := cfg.workPath(journalPath(cfg.args))
+	upath := cfg.workPath(cfg.args[0] + undoSuffix)
+	if err := cfg.checkFreeSpace(jpath, upath); err != nil {
+		return err
+	}
+	j, done, err := openJournal(jpath, cfg.resume)
+	if err != nil {
+		return err
+	}
+	undo, err := duplicate.OpenUndoLog(upath)
+	if err != nil {
+		j.Close(false)
+		return err
+	}
+	defer undo.Close()
+	undo.Expect(infos)
+	if len(done) > 0 {
+		remain := delList[:0]
+		for _, f := range delList {
+			if !done[f] {
+				remain = append(remain, f)
+			}
+		}
+		fmt.Printf("跳过上次已清理的 %d 个文件\n", len(delList)-len(remain))
+		delList = remain
+	}
+	sizes := map[string]int64{}
+	for _, f := range infos {
+		sizes[f.Path] = f.Size
+	}
+	cleaned := []duplicate.FileInfo{}
+	var jerr error
+	n, err := duplicate.NewCleaner(duplicate.CleanOptions{
+		Progress:      cfg.showBar(),
+		OnProgress:    cfg.onProgress(),
+		IgnoreMissing: cfg.resume,
+		AllowRisky:    cfg.allowRisky,
+		Protected:     cfg.protectDirs(),
+		Trash:         cfg.trash,
+		Quarantine:    cfg.quarantine,
+		Undo:          undo,
+		OnCleaned: func(path string) {
+			if e := j.Done(path); e != nil && jerr == nil {
+				jerr = fmt.Errorf("写入清理日志失败: %v", e)
+			}
+			cleaned = append(cleaned, duplicate.FileInfo{Path: path, Size: sizes[path]})
+		},
+	}).Clean(delList)
+	if e := recordLedger(cfg.ledger, cfg.cleanAction(), cleaned); e != nil {
+		fmt.Printf("写入账本 %s 失败: %v\n", cfg.ledger, e)
+	}
+	err = errors.Join(err, jerr)
+	// 有失败的文件时保留日志，修正后可用 -resume 只重试失败的部分
+	j.Close(err == nil)
+	if err != nil {
+		return err
+	}
+	// 审阅的结果已全部执行，不再需要继续
+	if cfg.session != "" {
+		os.Remove(cfg.session)
+	}
+	if cfg.quarantine != "" {
+		fmt.Printf("成功将 %d 个文件移到隔离目录 %s，原位置记录在其中的 %s 中，可用 -c -restore %s 还原\n", n, cfg.quarantine, duplicate.QuarantineManifest, upath)
+		if cfg.autoPurge() {
+			return purgeQuarantine(cfg)
+		}
+		return nil
+	}
+	if cfg.trash {
+		// Windows 回收站中的位置由系统决定，撤销日志无法还原，只能从回收站中还原
+		if undo.Restorable() > 0 {
+			fmt.Printf("成功将 %d 个文件移到回收站，可用 -c -restore %s 还原\n", n, upath)
+		} else {
+			fmt.Printf("成功将 %d 个文件移到回收站，可在系统的回收站中还原\n", n)
+		}
+		return nil
+	}
+	fmt.Printf("成功清理 %d 个文件\n", n)
 	return nil
 }
 
-// readList 读取删除清单
-func readList(files []string) ([]string, error) {
-	delList := []string{}
+// readListFiles 读取删除清单中的文件及记录的大小和Hash值，路径按 pm 转换为本机路径，
+// pick 不为空时只返回由它从各组中选出的文件，否则返回清单中的全部文件
+func readListFiles(files []string, pick pickFunc, pm pathMap) ([]duplicate.FileInfo, error) {
+	infos := []duplicate.FileInfo{}
 	for _, f := range files {
-		file, err := os.OpenFile(f, os.O_RDONLY, 0644)
+		groups, err := parseList(f, pm)
 		if err != nil {
-			return nil, fmt.Errorf("无法打开文件 %s: %v", f, err)
-		}
-		defer file.Close()
-		scanner := bufio.NewScanner(file)
-		for scanner.Scan() {
-			line := scanner.Text()
-			if line != splitLine {
-				s := strings.Split(line, "\t")
-				if len(s) < 1 {
-					return nil, fmt.Errorf("文件 %s 格式错误: 每行应包含文件路径", f)
-				}
-				delList = append(delList, s[0])
-			}
+			return nil, err
+		}
+		if pick != nil {
+			infos = append(infos, pick(groups)...)
+			continue
+		}
+		for _, g := range groups {
+			infos = append(infos, g.Files...)
 		}
 	}
-	return delList, nil
+	return infos, nil
 }
 
 // checkConfig 检查参数
 func checkConfig(cfg *Config) error {
+	if !slices.Contains(progressKinds, cfg.progress) {
+		return fmt.Errorf("不支持的进度显示方式: %s，可选: %s", cfg.progress, strings.Join(progressKinds, " | "))
+	}
+	if cfg.showVersion {
+		if cfg.list || cfg.clean || cfg.history || cfg.selfUpdate || len(cfg.args) > 0 {
+			return errors.New("-version 不能与其他操作同时使用")
+		}
+		return nil
+	}
+	if cfg.history {
+		if cfg.list || cfg.clean || len(cfg.args) > 0 {
+			return errors.New("-history 不能与 -l、-c 同时使用，也不需要指定路径")
+		}
+		return nil
+	}
+	if (cfg.updateURL != "" || cfg.downgrade) && !cfg.selfUpdate {
+		return errors.New("-update-url 和 -allow-downgrade 只能与 -self-update 一起使用")
+	}
+	if cfg.updateURL != "" && !strings.HasPrefix(strings.ToLower(cfg.updateURL), "https://") {
+		return errors.New("-update-url 必须是 https 地址")
+	}
+	if cfg.selfUpdate {
+		if cfg.list || cfg.clean || len(cfg.args) > 0 {
+			return errors.New("-self-update 不能与 -l、-c 同时使用，也不需要指定路径")
+		}
+		return nil
+	}
+	if cfg.schema != "" && !slices.Contains(schemaKinds, cfg.schema) {
+		return fmt.Errorf("不支持的文件类型: %s，可选: %s", cfg.schema, strings.Join(schemaKinds, " | "))
+	}
+	if cfg.validate || cfg.schema != "" {
+		if cfg.list || cfg.clean {
+			return errors.New("-validate、-schema 不能与 -l、-c 同时使用")
+		}
+		if cfg.validate && len(cfg.args) == 0 {
+			return errors.New("-validate 需要指定要校验的文件")
+		}
+		if !cfg.validate && len(cfg.args) > 0 {
+			return errors.New("-schema 单独使用时输出 schema，不需要指定文件")
+		}
+		return nil
+	}
 	if (cfg.list && cfg.clean) || (!cfg.list && !cfg.clean) {
 		return errors.New("-l 和 -c 必须二选一")
 	}
+	if !slices.Contains([]string{formatText, formatJSON, formatJSONL, formatCSV, formatBinary, formatSum, formatHTML}, cfg.format) {
+		return fmt.Errorf("不支持的输出格式: %s", cfg.format)
+	}
+	if cfg.check && !cfg.clean {
+		return errors.New("-check 只能与 -c 一起使用")
+	}
+	if cfg.emitScript != "" {
+		if !slices.Contains(scriptKinds, cfg.emitScript) {
+			return fmt.Errorf("不支持的脚本类型: %s，可选: %s", cfg.emitScript, strings.Join(scriptKinds, " | "))
+		}
+		if !cfg.clean || cfg.check || cfg.resume {
+			return errors.New("-emit-script 只能与 -c 一起使用，且不能与 -check、-resume 同时使用")
+		}
+	}
+	if cfg.apply != "" && (!cfg.clean || cfg.check || cfg.emitScript != "" || cfg.keep != "" || cfg.preferName != "" || len(cfg.keepMatch) > 0 || len(cfg.deleteMatch) > 0 || len(cfg.args) > 0) {
+		return errors.New("-apply 只能与 -c 一起使用，计划中已确定要删除的文件，不能再指定清单、-keep、-prefer-name、-check 或 -emit-script")
+	}
 	if cfg.count < 1 {
 		return errors.New("同时计算数不能小于1")
 	}
+	if cfg.types != "" {
+		for _, t := range strings.Split(cfg.types, ",") {
+			if !slices.Contains(duplicate.FileTypes, t) {
+				return fmt.Errorf("不支持的文件类别: %s，可选: %s", t, strings.Join(duplicate.FileTypes, " | "))
+			}
+		}
+	}
+	if cfg.chargeBy != chargeOwner && cfg.chargeBy != chargeRoot {
+		return fmt.Errorf("不支持的汇总维度: %s", cfg.chargeBy)
+	}
+	if (cfg.screen || cfg.signature || cfg.verifyIndex) && cfg.checkpoint == "" {
+		return errors.New("-screen、-signature 和 -verify-index 需要用 -checkpoint 指定索引文件")
+	}
+	if cfg.sample < 0 {
+		return errors.New("-sample 不能小于0")
+	}
+	if cfg.maxDuration < 0 {
+		return errors.New("-max-duration 不能小于0")
+	}
+	if cfg.mailTo != "" && (cfg.smtp == "" || cfg.mailFrom == "") {
+		return errors.New("发送摘要邮件需要指定 -smtp 和 -mail-from")
+	}
+	if cfg.webhookType != "" && cfg.webhookType != webhookSlack && cfg.webhookType != webhookTeams {
+		return fmt.Errorf("不支持的 webhook 类型: %s", cfg.webhookType)
+	}
+	if cfg.keepReports < 0 {
+		return errors.New("-keep-reports 不能小于0")
+	}
+	if cfg.keep != "" && !slices.Contains(duplicate.KeepPolicies, cfg.keep) {
+		return fmt.Errorf("不支持的保留策略: %s，可选: %s", cfg.keep, strings.Join(duplicate.KeepPolicies, " | "))
+	}
+	replace := 0
+	for _, b := range []bool{cfg.hardlink, cfg.symlink, cfg.reflink} {
+		if b {
+			replace += 1
+		}
+	}
+	if replace > 1 {
+		return errors.New("-hardlink、-symlink 和 -reflink 只能选一个")
+	}
+	if cfg.trash && (!cfg.clean || cfg.check || cfg.emitScript != "" || replace > 0) {
+		return errors.New("-trash 只能与 -c 或 -apply 一起使用")
+	}
+	if cfg.quarantine != "" && (!cfg.clean || cfg.check || cfg.emitScript != "" || replace > 0) {
+		return errors.New("-quarantine 只能与 -c 或 -apply 一起使用")
+	}
+	if cfg.quarantine != "" && cfg.trash {
+		return errors.New("-quarantine 和 -trash 只能选一个")
+	}
+	if cfg.retain < 0 || cfg.quarantineMax < 0 {
+		return errors.New("-retain 和 -quarantine-max 不能小于0")
+	}
+	if (cfg.retain > 0 || cfg.quarantineMax > 0) && cfg.quarantine == "" {
+		return errors.New("-retain 和 -quarantine-max 需要用 -quarantine 指定隔离目录")
+	}
+	if cfg.purge && (!cfg.clean || !cfg.autoPurge() || cfg.check || cfg.emitScript != "" || cfg.apply != "" || cfg.restore != "" || cfg.dryRun || cfg.interactive || cfg.tui || cfg.resume || len(cfg.args) > 0) {
+		return errors.New("-purge 只能与 -c、-quarantine 和 -retain 或 -quarantine-max 一起使用，不需要指定清单")
+	}
+	if cfg.relative && !cfg.symlink {
+		return errors.New("-relative 只能与 -symlink 一起使用")
+	}
+	if cfg.restore != "" && (!cfg.clean || cfg.check || cfg.emitScript != "" || cfg.apply != "" || cfg.trash || cfg.quarantine != "" || replace > 0 || len(cfg.args) > 0) {
+		return errors.New("-restore 只能与 -c 一起使用，且不能再指定清单或其他清理方式")
+	}
+	if cfg.dryRun && (!cfg.clean || cfg.check || cfg.emitScript != "" || cfg.apply != "" || cfg.restore != "" || cfg.resume || replace > 0) {
+		return errors.New("-dry-run 只能与 -c 一起使用，且不能与 -check、-emit-script、-apply、-restore、-resume 或链接替换同时使用")
+	}
+	if cfg.interactive && cfg.tui {
+		return errors.New("-interactive 和 -tui 只能选一个")
+	}
+	if cfg.session != "" && !cfg.tui {
+		return errors.New("-session 只能与 -tui 一起使用")
+	}
+	if (cfg.interactive || cfg.tui) && (!cfg.clean || cfg.check || cfg.emitScript != "" || cfg.apply != "" || cfg.restore != "" || cfg.dryRun || cfg.resume || cfg.keep != "" || cfg.preferName != "" || len(cfg.keepMatch) > 0 || len(cfg.deleteMatch) > 0 || replace > 0) {
+		return errors.New("-interactive 和 -tui 只能与 -c 一起使用，由用户选择保留的文件，不能与 -keep、-prefer-name、-check、-emit-script、-apply、-restore、-dry-run、-resume 或链接替换同时使用")
+	}
+	if replace > 0 && (!cfg.clean || cfg.check || cfg.emitScript != "" || cfg.apply != "" || cfg.resume) {
+		return errors.New("-hardlink、-symlink 和 -reflink 只能与 -c 一起使用，且不能与 -check、-emit-script、-apply、-resume 同时使用")
+	}
+	if replace > 0 && !cfg.selectsKeepers() {
+		return errors.New("-hardlink、-symlink 和 -reflink 需要用 -keep、-prefer-name、-keep-match、-delete-match 或 -protect 确定每组保留的文件")
+	}
+	if cfg.deleteList != "" && !cfg.list {
+		return errors.New("-emit-delete-list 只能与 -l 一起使用")
+	}
+	if cfg.selectsKeepers() && !cfg.clean && cfg.deleteList == "" {
+		return errors.New("-keep、-prefer-name、-keep-match、-delete-match 和 -protect 只能与 -c 或 -emit-delete-list 一起使用")
+	}
+	if _, err := cfg.picker(); err != nil {
+		return err
+	}
+	if _, err := cfg.pathMap(); err != nil {
+		return err
+	}
+	if cfg.splitBy != "" && !slices.Contains(splitKinds, cfg.splitBy) {
+		return fmt.Errorf("不支持的拆分方式: %s，可选: %s", cfg.splitBy, strings.Join(splitKinds, " | "))
+	}
+	if _, err := cfg.scanPriorities(); err != nil {
+		return err
+	}
+	if len(cfg.priorities) > 0 && !cfg.list {
+		return errors.New("-priority 只能与 -l 一起使用")
+	}
+	if cfg.template != "" && (!cfg.list || cfg.format != formatText) {
+		return errors.New("-template 只能与 -l 一起使用，代替 -format 指定的格式")
+	}
+	if _, err := cfg.listTemplate(); err != nil {
+		return err
+	}
+	if cfg.preset != "" {
+		if _, err := duplicate.PresetExcludes(strings.Split(cfg.preset, ",")); err != nil {
+			return err
+		}
+	}
+	for _, p := range cfg.exclude {
+		if err := duplicate.CheckPattern(p); err != nil {
+			return fmt.Errorf("无效的 -exclude %q: %v", p, err)
+		}
+	}
+	for _, p := range cfg.include {
+		if err := duplicate.CheckPattern(p); err != nil {
+			return fmt.Errorf("无效的 -include %q: %v", p, err)
+		}
+	}
+	if !slices.Contains(duplicate.Scopes, cfg.scope) {
+		return fmt.Errorf("不支持的重复范围: %s，可选: %s", cfg.scope, strings.Join(duplicate.Scopes, " | "))
+	}
+	if cfg.maxSize > 0 && cfg.minSize > cfg.maxSize {
+		return errors.New("-min-size 不能大于 -max-size")
+	}
+	if !cfg.older.t.IsZero() && !cfg.newer.t.IsZero() && !cfg.newer.t.Before(cfg.older.t) {
+		return errors.New("-newer-than 的时间应早于 -older-than 的时间，否则没有文件同时满足")
+	}
+	if cfg.walkQPS < 0 {
+		return errors.New("-walk-qps 不能小于0")
+	}
+	if cfg.preview < 0 {
+		return errors.New("-preview 不能小于0")
+	}
+	if cfg.minCopies < 2 {
+		return errors.New("-min-copies 不能小于2")
+	}
+	if cfg.maxDepth < 0 {
+		return errors.New("-max-depth 不能小于0")
+	}
+	if cfg.maxFiles < 0 {
+		return errors.New("-max-files 不能小于0")
+	}
 	if len(cfg.args) == 0 {
-		if cfg.list {
+		if cfg.list && len(cfg.archives) == 0 && cfg.replay == "" && cfg.convert == "" && !cfg.verifyIndex {
 			return errors.New("请指定待分析的路径")
 		}
-		if cfg.clean {
+		if cfg.clean && cfg.apply == "" && cfg.restore == "" && !cfg.purge {
 			return errors.New("请指定待清理文件的列表")
 		}
 	}
 	return nil
 }
 
-// parseConfig 解析命令行参数
-func parseConfig() *Config {
+// parseConfig 解析命令行参数和配置文件
+func parseConfig() (*Config, error) {
 	cfg := Config{}
 
 	flag.BoolVar(&cfg.list, "l", false, "列出重复文件清单，与 -c 必须二选一")
-	flag.StringVar(&cfg.hash, "f", "md5", "比较方式: md5 | sha1 | sha256 | sha512")
+	flag.StringVar(&cfg.hash, "f", "md5", "比较方式: md5 | sha1 | sha256 | sha512 | blake3 | xxh64 | xxh128，xxh 为非加密Hash，速度最快")
 	flag.StringVar(&cfg.outFile, "o", "list.txt", "将重复清单输出到指定文件")
+	flag.StringVar(&cfg.format, "format", formatText, "输出格式: text | json | jsonl | csv | binary | sum | html，-c 会自动识别清单格式(sum、html 除外)")
+	flag.StringVar(&cfg.convert, "convert", "", "将指定的清单(任意格式)转换为 -format 格式并输出到 -o")
+	flag.StringVar(&cfg.splitBy, "split-by", "", "另外按 root | ext | sizeclass 将清单拆分为多个文件 <清单>.<键>.<扩展名>，便于分发给各自负责的人")
 	flag.IntVar(&cfg.count, "n", 10, "同时计算数量")
+	flag.BoolVar(&cfg.noAccel, "no-accel", false, "不使用 CPU 的 SHA 扩展指令和 SIMD 加速，用于排查硬件或兼容性问题")
 	flag.BoolVar(&cfg.clean, "c", false, "清理指定的文件，与 -l 必须二选一")
+	flag.StringVar(&cfg.keep, "keep", "", "按保留策略从未经编辑的清单中选出要删除的文件: first(每组保留第一个) | per-dir(每个目录各保留一个) | newest(保留最新的) | oldest(保留最早的) | shortest-path(保留路径最短的) | first-dir(保留目录排在最前的)，默认删除清单中的全部文件")
+	flag.Var(&cfg.protect, "protect", "受保护的主目录，其中的文件不会被清理，同组有这样的文件时其他副本全部删除，未指定 -keep 时按 first 处理，可重复指定")
+	flag.Var(&cfg.keepMatch, "keep-match", "完整路径(分隔符为 /)匹配该正则表达式的副本总是保留，同组有这样的文件时其他副本全部删除，未指定 -keep 时按 first 处理，可重复指定")
+	flag.Var(&cfg.deleteMatch, "delete-match", "完整路径(分隔符为 /)匹配该正则表达式的副本总是删除(如 '/Downloads/')，保留策略只在其余副本中选择，同组全部匹配时仍保留一个，可重复指定")
+	flag.StringVar(&cfg.preferName, "prefer-name", "", "优先保留文件名匹配该正则表达式的副本，以 ! 开头表示优先保留不匹配的，未指定 -keep 时按 first 处理")
+	flag.BoolVar(&cfg.hardlink, "hardlink", false, "不删除重复文件，而是替换为指向保留文件的硬链接，目录结构不变，需配合 -keep 或 -prefer-name")
+	flag.BoolVar(&cfg.symlink, "symlink", false, "不删除重复文件，而是替换为指向保留文件的符号链接，需配合 -keep 或 -prefer-name")
+	flag.BoolVar(&cfg.relative, "relative", false, "-symlink 使用相对路径，目录树整体移动后链接仍然有效")
+	flag.BoolVar(&cfg.trash, "trash", false, "将文件移到回收站而不是直接删除，便于误删后恢复")
+	flag.StringVar(&cfg.quarantine, "quarantine", "", "将文件移到指定的隔离目录而不是直接删除，保留原目录结构并记录原位置，确认无误后再删除该目录")
+	flag.BoolVar(&cfg.reflink, "reflink", false, "不删除重复文件，而是替换为保留文件的写时复制克隆(Btrfs、XFS、APFS)，共享存储但内容互相独立，需配合 -keep 或 -prefer-name")
+	flag.BoolVar(&cfg.purge, "purge", false, "按 -retain、-quarantine-max 永久删除 -quarantine 指定的隔离目录中过期或超出容量的文件")
+	flag.DurationVar(&cfg.retain, "retain", 0, "隔离目录中的文件保留时间(如 720h)，超过后由 -purge 永久删除，清理到隔离目录后也会自动执行，0为不限制")
+	flag.Var(&cfg.quarantineMax, "quarantine-max", "隔离目录的容量上限(如 50G)，超出时从最早隔离的文件开始永久删除，0为不限制")
+	flag.BoolVar(&cfg.allowRisky, "allow-risky", false, "允许清理位于应用程序管理的目录(照片图库、iTunes、Thunderbird、Docker 等)中的文件")
+	flag.BoolVar(&cfg.check, "check", false, "只预检清单中的文件能否安全清理并输出报告，不删除任何文件")
+	flag.StringVar(&cfg.deleteList, "emit-delete-list", "", "同时将每组中要删除的文件(按 -keep 等选择，默认保留第一个)每行一个写到该文件，可直接用 -c 清理")
+	flag.StringVar(&cfg.emitScript, "emit-script", "", "不执行删除，将删除计划转换为带安全检查的 sh | powershell 脚本，或供 -apply 执行的 json 计划，输出到屏幕")
+	flag.StringVar(&cfg.ledger, "ledger", defaultLedgerPath(), "累计记录每次清理释放空间的账本文件，为空时不记录")
+	flag.BoolVar(&cfg.history, "history", false, "按月份和目录汇总账本，显示历次清理累计释放的空间")
+	flag.BoolVar(&cfg.showVersion, "version", false, "输出版本、提交、构建时间和本平台可用的功能")
+	flag.BoolVar(&cfg.selfUpdate, "self-update", false, "下载最新发布版本，校验签名和校验和后替换当前程序")
+	flag.StringVar(&cfg.updateURL, "update-url", "", "-self-update 从该 https 地址下载，而不是 GitHub，地址下直接存放 SHA256SUMS、SHA256SUMS.sig、VERSION 和各平台的程序，用于内网镜像")
+	flag.BoolVar(&cfg.downgrade, "allow-downgrade", false, "-self-update 时允许安装不比当前版本新的版本(回退)")
+	flag.StringVar(&cfg.schema, "schema", "", "输出指定文件类型的 JSON Schema: "+strings.Join(schemaKinds, " | ")+"，与 -validate 一起使用时指定文件类型")
+	flag.BoolVar(&cfg.validate, "validate", false, "按内嵌的 schema 校验指定的 JSON/NDJSON 文件，未指定 -schema 时根据内容判断类型")
+	flag.BoolVar(&cfg.interactive, "interactive", false, "逐组显示清单中的重复文件，由用户选择每组保留哪个副本后清理")
+	flag.BoolVar(&cfg.tui, "tui", false, "在全屏界面中浏览清单，逐组标记要删除的文件，确认后清理")
+	flag.StringVar(&cfg.session, "session", "", "-tui 的审阅进度文件，退出时保存已审阅的组和标记，下次从中继续，清理完成后删除")
+	flag.BoolVar(&cfg.dryRun, "dry-run", false, "试运行，只检查清单中的文件能否清理并汇总将释放的空间，不修改任何文件")
+	flag.StringVar(&cfg.restore, "restore", "", "按撤销日志(<清单>.undo)将移到回收站或隔离目录的文件放回原位置")
+	flag.StringVar(&cfg.apply, "apply", "", "执行 -emit-script json 生成的删除计划，逐个预检并确认保留的副本完好后删除，处理结果追加到 <计划>.audit")
+	flag.BoolVar(&cfg.resume, "resume", false, "根据清理日志跳过已清理的文件，继续上次中断的清理")
+	flag.IntVar(&cfg.maxFiles, "max-files", 0, "候选文件数上限，超出时中止扫描，0为不限制")
+	flag.Var(&cfg.maxBytes, "max-bytes", "候选文件总大小上限(如 500G)，超出时中止扫描，0为不限制")
+	flag.Var(&cfg.minSize, "min-size", "只比较不小于该大小的文件(如 10M)，跳过大量释放不了多少空间的小文件")
+	flag.Var(&cfg.maxSize, "max-size", "只比较不大于该大小的文件(如 2G)，0为不限制")
+	flag.DurationVar(&cfg.maxDuration, "max-duration", 0, "最长扫描时间(如 2h、30m)，到时停止并输出标记为不完整的清单，同时保存检查点，0为不限制")
+	flag.BoolVar(&cfg.incremental, "incremental", false, "增量扫描：载入上次扫描保存的索引(默认为 <清单>.dci，可用 -checkpoint 指定)，只重新计算新增和有变化的文件，扫描后更新索引")
+	flag.StringVar(&cfg.cache, "cache", "", "Hash值缓存文件，按路径、大小和修改时间记录已算出的Hash值，反复扫描同一存储时只计算有变化的文件，不存在时创建")
+	flag.StringVar(&cfg.checkpoint, "checkpoint", "", "检查点文件，记录已算出的Hash值，下次扫描时跳过未变化的文件，默认在设置 -max-duration 时使用 <清单>.checkpoint")
+	flag.BoolVar(&cfg.signature, "signature", false, "在检查点中同时记录所有文件的快速签名(大小+开头和结尾4KB的Hash值)，供 -screen 使用")
+	flag.BoolVar(&cfg.screen, "screen", false, "用 -checkpoint 中的签名预筛指定的新文件或目录，只对可能重复的文件计算完整Hash值")
+	flag.BoolVar(&cfg.verifyIndex, "verify-index", false, "随机抽查 -checkpoint 指定的索引，核对文件是否存在、大小和Hash值，移除过期条目并更正不符的条目")
+	flag.IntVar(&cfg.sample, "sample", 100, "-verify-index 抽查的条目数，0为检查全部")
+	flag.StringVar(&cfg.digest, "digest", "", "将扫描摘要(纯文本和 HTML)保存为指定的 .eml 文件")
+	flag.IntVar(&cfg.digestTop, "digest-top", 10, "摘要中列出可释放空间最多的组数")
+	flag.StringVar(&cfg.mailTo, "mail-to", "", "扫描结束后将摘要邮件发送到这些地址，多个用逗号分隔")
+	flag.StringVar(&cfg.mailFrom, "mail-from", "", "摘要邮件的发件人")
+	flag.StringVar(&cfg.smtp, "smtp", "", "发送摘要邮件的 SMTP 服务器(host:port)")
+	flag.StringVar(&cfg.smtpUser, "smtp-user", "", "SMTP 用户名，密码从环境变量 "+smtpPasswordEnv+" 读取")
+	flag.StringVar(&cfg.webhook, "webhook", "", "扫描结束后将摘要发送到 Slack 或 Teams 的 incoming webhook 地址")
+	flag.StringVar(&cfg.webhookType, "webhook-type", "", "webhook 类型: slack | teams，默认按地址判断")
+	flag.StringVar(&cfg.reportURL, "report-url", "", "清单所在的网址前缀，webhook 中以此链接到完整清单，默认给出本地路径")
+	flag.IntVar(&cfg.keepReports, "keep-reports", 0, "输出前将已有的清单压缩归档为 <清单>.<时间>.gz，只保留最近的指定份数，0为直接覆盖")
+	flag.StringVar(&cfg.config, "config", "", "配置文件，每行 `参数名 = 值`，默认读取用户配置目录下的 duplicate-cleaner/config")
+	flag.StringVar(&cfg.workdir, "workdir", "", "检查点、清理日志等工作文件的存放目录，默认与清单放在一起")
+	cfg.minFree = 100 << 20
+	flag.Var(&cfg.minFree, "min-free", "输出清单和工作文件的磁盘至少应有的可用空间，不足时不开始，0为不检查")
+	flag.Float64Var(&cfg.walkQPS, "walk-qps", 0, "遍历时每秒最多的目录读取和文件信息请求数，避免 rclone、s3fs 等云存储挂载触发限流，0为不限制")
+	flag.BoolVar(&cfg.byExt, "by-ext", false, "按扩展名分桶比较，扩展名不同的文件不视为重复")
+	flag.IntVar(&cfg.minCopies, "min-copies", 2, "只列出至少有这么多个副本的组，用于查找被大量重复的文件")
+	flag.BoolVar(&cfg.prefilter, "prefilter", false, "先比较大小相同的文件开头64KB，只对开头相同的文件计算完整Hash值，适合大量大小相同但内容不同的文件")
+	flag.StringVar(&cfg.template, "template", "", "用 Go text/template 模板输出清单(如生成脚本或报告)，值为模板文件，含 {{ 时视为模板内容")
+	flag.Var(&cfg.priorities, "priority", "目录的优先级，格式为 模式=优先级(如 Documents=10、/data/archive=-5)，优先级高的先遍历和计算，默认为0，可重复指定")
+	flag.Var(&cfg.exclude, "exclude", "跳过匹配该模式的目录和文件，如 node_modules、*.tmp、.cache/*、/data/tmp，写法与 -preset 中的相同，可重复指定")
+	flag.Var(&cfg.include, "include", "只把匹配该模式的文件作为候选，如 *.jpg，写法与 -exclude 相同，可重复指定，匹配任一即可")
+	flag.IntVar(&cfg.maxDepth, "max-depth", 0, "最多进入的目录层数，1为只扫描扫描路径下直接包含的文件，0为不限制")
+	flag.BoolVar(&cfg.follow, "follow-symlinks", false, "进入指向目录的符号链接，同一目录只扫描一次，链接成环时不会无限遍历")
+	flag.BoolVar(&cfg.oneFS, "one-file-system", false, "不进入其他文件系统的挂载点(如网络共享、外接磁盘、绑定挂载)，适合扫描 / 等包含挂载点的目录")
+	flag.BoolVar(&cfg.noHidden, "skip-hidden", false, "跳过隐藏的文件和目录(名称以 . 开头，Windows 上还包括带有隐藏属性的)，如各程序的配置文件")
+	flag.BoolVar(&cfg.noIgnore, "no-dcignore", false, "不读取扫描路径中的 .dcignore 忽略文件")
+	flag.BoolVar(&cfg.gitIgnore, "respect-gitignore", false, "跳过各级 .gitignore 和 .git/info/exclude 中忽略的路径，如构建产物和依赖目录")
+	flag.StringVar(&cfg.preset, "preset", "", "跳过预置的应用数据目录，多个用逗号分隔: dev(node_modules、.m2、pip 缓存等) | home(浏览器、Steam、邮件) | server(docker、数据库、NAS 系统目录)")
+	flag.StringVar(&cfg.scope, "scope", duplicate.ScopeAll, "重复范围: all | cross-dir(只列出不同目录间的重复) | same-dir(只列出同一目录下的重复)")
+	flag.BoolVar(&cfg.verify, "verify", false, "输出前逐字节比较Hash值相同的文件，确认内容完全相同，不依赖Hash算法")
+	flag.BoolVar(&cfg.tolerant, "tolerant", false, "容忍扫描期间消失或被修改的文件，从结果中去掉并在清单中单独列出，而不是作为错误")
+	flag.BoolVar(&cfg.skipOpen, "skip-open", false, "推迟计算正被其他进程写入的文件(如正在增长的日志、正在下载的文件)，到最后仍在写入的不计入清单并单独列出，仅支持 Linux 和 Windows")
+	flag.BoolVar(&cfg.adaptive, "adaptive", false, "按各组文件的数量和大小自动选择比较策略，减少读取量")
+	flag.BoolVar(&cfg.verbose, "v", false, "输出详细信息")
+	flag.StringVar(&cfg.progress, "progress", progressBar, "进度显示方式: bar(进度条) | json(每秒最多一行 JSON 进度事件，输出到标准错误，供包装程序和图形界面使用) | none")
+	flag.BoolVar(&cfg.estimate, "estimate", false, "只按大小分组并估计重复文件数和可释放空间的上限，不计算Hash值")
+	flag.StringVar(&cfg.record, "record", "", "将遍历和Hash值计算结果记录到指定的轨迹文件(.dcr)")
+	flag.StringVar(&cfg.replay, "replay", "", "根据轨迹文件重新分组输出清单，不访问文件系统")
+	flag.Var(&cfg.older, "older-than", "只扫描修改时间早于该时间的文件，如 1y(一年内未修改)、90d、36h 或日期 2024-01-31")
+	flag.Var(&cfg.newer, "newer-than", "只扫描修改时间晚于该时间的文件，写法同 -older-than")
+	flag.StringVar(&cfg.exts, "ext", "", "只扫描指定扩展名的文件，多个用逗号分隔，如 jpg,mp4,raw，不区分大小写")
+	flag.StringVar(&cfg.types, "type", "", "只扫描指定类别的文件，按内容判断，多个用逗号分隔: image | video | audio | document | archive | other")
+	flag.BoolVar(&cfg.describe, "describe", false, "为每组提取一个文件的元数据(图片尺寸、音视频时长、文档标题)写入清单和摘要")
+	flag.IntVar(&cfg.preview, "preview", 0, "为文本文件的组记录开头的指定行数，显示在 JSON 清单和摘要中，0为不记录")
+	flag.BoolVar(&cfg.uri, "uri", false, "在 JSON 清单中为每个文件附上 file:// 形式的 URI，HTML 摘要中的路径改为可点击的链接")
+	flag.BoolVar(&cfg.latin, "latin", false, "在文本清单和摘要中为含西里尔字母、希腊字母、日文假名或韩文的路径附上拉丁转写")
+	flag.BoolVar(&cfg.showType, "show-type", false, "在清单中标注按内容判断的文件类别")
+	flag.BoolVar(&cfg.byOwner, "by-owner", false, "按文件所有者汇总重复文件占用的空间")
+	flag.StringVar(&cfg.ownerDir, "owner-reports", "", "在指定目录下为每个所有者输出一份清单")
+	flag.StringVar(&cfg.charge, "chargeback", "", "将各账户的总占用、重复占用和去重后占用输出到指定文件(.json 为 JSON，否则为 CSV)")
+	flag.StringVar(&cfg.chargeBy, "chargeback-by", chargeOwner, "计费汇总的维度: owner | root")
+	flag.Var(&cfg.mapPaths, "map-path", "清单中的路径前缀与本机路径的对应关系(如 /volume1/share=Z:\\share)，用于在其他系统上清理生成的清单，可重复指定")
+	flag.BoolVar(&cfg.scanShared, "scan-shared", false, "仍然扫描通过绑定挂载等与其他扫描路径指向同一份数据的路径，默认跳过")
+	flag.Var(&cfg.archives, "archive", "将 zip、tar 归档或 iso 光盘镜像以只读方式挂载参与比较，无需解压，可重复指定")
 
 	flag.Parse()
 
 	cfg.args = flag.Args()
 
-	return &cfg
+	if cfg.config != "" {
+		return &cfg, loadConfig(cfg.config, true)
+	}
+	if path := defaultConfigPath(); path != "" {
+		return &cfg, loadConfig(path, false)
+	}
+	return &cfg, nil
 }
@@ -0,0 +1,65 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// defaultConfigPath 默认的配置文件位置，如 ~/.config/duplicate-cleaner/config
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "duplicate-cleaner", "config")
+}
+
+// loadConfig 读取配置文件，每行 `参数名 = 值`，参数名与命令行参数相同(不带 -)，
+// # 开头的行为注释；命令行中已指定的参数优先。explicit 为 false 时文件不存在不算错误
+func loadConfig(path string, explicit bool) error {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) && !explicit {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	set := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		name, value, ok := strings.Cut(text, "=")
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if !ok || flag.Lookup(name) == nil {
+			return fmt.Errorf("配置文件 %s 第 %d 行无效: %s", path, line, text)
+		}
+		if set[name] || name == "config" {
+			continue
+		}
+		if err := flag.Set(name, value); err != nil {
+			return fmt.Errorf("配置文件 %s 第 %d 行: %v", path, line, err)
+		}
+	}
+	return scanner.Err()
+}
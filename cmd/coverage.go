@@ -0,0 +1,111 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package cmd
+
+import (
+	"duplicate-cleaner/duplicate"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// archiveUsage 一个归档或镜像中的文件，以及其中在归档外有副本的部分
+type archiveUsage struct {
+	Path        string
+	Files       int
+	Bytes       int64
+	CopiedFiles int
+	CopiedBytes int64
+}
+
+// archiveCoverage 统计 -archive 挂载的各归档中有多少文件在归档外另有副本，帮助判断旧的归档或镜像能否删除
+type archiveCoverage struct {
+	m        sync.Mutex
+	archives []*archiveUsage
+}
+
+func newArchiveCoverage(archives []string) *archiveCoverage {
+	c := &archiveCoverage{}
+	for _, a := range archives {
+		c.archives = append(c.archives, &archiveUsage{Path: duplicate.CanonicalPath(a)})
+	}
+	return c
+}
+
+// Attach 挂接到扫描选项的回调上统计归档内的文件，原有回调仍会被调用
+func (c *archiveCoverage) Attach(opts *duplicate.Options) {
+	onScanned := opts.OnFileScanned
+	opts.OnFileScanned = func(f duplicate.FileInfo) {
+		if a := c.archive(f.Path); a != nil {
+			c.m.Lock()
+			a.Files += 1
+			a.Bytes += f.Size
+			c.m.Unlock()
+		}
+		if onScanned != nil {
+			onScanned(f)
+		}
+	}
+}
+
+// archive 文件所在的归档，不在归档内时返回 nil
+func (c *archiveCoverage) archive(path string) *archiveUsage {
+	for _, a := range c.archives {
+		if strings.HasPrefix(path, a.Path+duplicate.ArchiveSep) {
+			return a
+		}
+	}
+	return nil
+}
+
+// Summary 按重复清单统计各归档中在归档外有副本的文件，只有普通文件算作副本，其他归档中的不算
+func (c *archiveCoverage) Summary(l duplicate.GroupList) []archiveUsage {
+	c.m.Lock()
+	defer c.m.Unlock()
+	for _, g := range l {
+		loose := false
+		for _, f := range g.Files {
+			if !duplicate.IsArchivePath(f.Path) {
+				loose = true
+				break
+			}
+		}
+		if !loose {
+			continue
+		}
+		for _, f := range g.Files {
+			if a := c.archive(f.Path); a != nil {
+				a.CopiedFiles += 1
+				a.CopiedBytes += f.Size
+			}
+		}
+	}
+	lst := []archiveUsage{}
+	for _, a := range c.archives {
+		lst = append(lst, *a)
+	}
+	return lst
+}
+
+// printCoverage 输出各归档的副本情况，partial 为 true 时扫描不完整，不给出可以删除的提示
+func printCoverage(lst []archiveUsage, partial bool) {
+	for _, a := range lst {
+		fmt.Printf("归档 %s: %d 个文件(%s)中 %d 个(%s)在归档外有副本", a.Path, a.Files, formatSize(a.Bytes), a.CopiedFiles, formatSize(a.CopiedBytes))
+		switch {
+		case partial:
+			fmt.Println("，扫描不完整，仅供参考")
+		case a.Files > 0 && a.CopiedFiles == a.Files:
+			fmt.Println("，内容已全部另有副本，可以考虑删除该归档")
+		default:
+			fmt.Printf("，其余 %d 个文件只存在于归档中\n", a.Files-a.CopiedFiles)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package cmd
+
+import (
+	"bufio"
+	"duplicate-cleaner/duplicate"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// writeDeleteList 按 -keep 等参数(未指定时为 first)从各组中选出要删除的文件，每行一个路径写到 f，
+// 每组至少保留一个文件，-c 可直接使用而无需手工编辑；返回写出的文件数和可释放的空间
+func writeDeleteList(cfg *Config, f string, l duplicate.GroupList) (int, int64, error) {
+	pick, err := cfg.picker()
+	if err != nil {
+		return 0, 0, err
+	}
+	if pick == nil {
+		pick = func(l duplicate.GroupList) duplicate.FileInfos { return l.Deletions(duplicate.KeepFirst{}) }
+	}
+	// 偏好会调整组内顺序，不影响之后输出的其他清单
+	groups := make(duplicate.GroupList, len(l))
+	for i, g := range l {
+		g.Files = slices.Clone(g.Files)
+		groups[i] = g
+	}
+	files := pick(groups)
+	for _, fi := range files {
+		// 清单按行读取，含换行符的路径会被拆成其他路径
+		if strings.ContainsAny(fi.Path, "\r\n") {
+			return 0, 0, fmt.Errorf("路径 %q 含有换行符，无法写入删除列表", fi.Path)
+		}
+	}
+	file, err := createAtomic(f)
+	if err != nil {
+		return 0, 0, err
+	}
+	zw, err := compressWriter(file, f)
+	if err != nil {
+		file.Abort()
+		return 0, 0, err
+	}
+	w := bufio.NewWriter(zw)
+	total := int64(0)
+	for _, fi := range files {
+		fmt.Fprintln(w, fi.Path)
+		total += fi.Size
+	}
+	if err := w.Flush(); err != nil {
+		file.Abort()
+		return 0, 0, err
+	}
+	if err := zw.Close(); err != nil {
+		file.Abort()
+		return 0, 0, err
+	}
+	return len(files), total, file.Commit()
+}
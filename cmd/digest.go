@@ -0,0 +1,182 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"duplicate-cleaner/duplicate"
+	"fmt"
+	htmltemplate "html/template"
+	"maps"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// smtpPasswordEnv SMTP 密码从环境变量读取，避免出现在命令行和进程列表中
+const smtpPasswordEnv = "DC_SMTP_PASSWORD"
+
+var digestFuncs = template.FuncMap{
+	"inc":    func(i int) int { return i + 1 },
+	"size":   formatSize,
+	"wasted": func(size int64, n int) string { return formatSize(size * int64(n-1)) },
+	"latin":  romanize,
+	"indent": func(s string) string { return "   | " + strings.ReplaceAll(s, "\n", "\n   | ") },
+}
+
+const digestText = `重复文件扫描摘要
+================
+
+扫描路径: {{range $i, $r := .Roots}}{{if $i}}, {{end}}{{$r}}{{end}}
+开始时间: {{.Started.Format "2006-01-02 15:04:05"}}，用时 {{.Duration}}{{if .Partial}}
+注意: 扫描在完成前停止，结果不完整{{end}}
+扫描文件: {{.Files}} 个，共 {{size .Bytes}}
+重复文件: {{.Groups}} 组，可清理 {{.Dups}} 个，可释放 {{size .Wasted}}{{if .Report}}
+完整清单: {{.Report}}{{end}}
+{{if .Top}}
+可释放空间最多的 {{len .Top}} 组:
+{{range $i, $g := .Top}}
+{{$i | inc}}. {{size $g.Size}} x {{len $g.Files}}，可释放 {{wasted $g.Size (len $g.Files)}}{{if $g.Meta}}，{{$g.Meta}}{{end}}
+{{range $g.Files}}   {{.Path}}{{if $.Latin}}{{with latin .Path}} ({{.}}){{end}}{{end}}
+{{end}}{{if $g.Preview}}   ----
+{{indent $g.Preview}}
+{{end}}{{end}}{{end}}`
+
+const digestHTML = `<html><body style="font-family: sans-serif">
+<h2>重复文件扫描摘要</h2>
+<table cellpadding="4">
+<tr><td>扫描路径</td><td>{{range $i, $r := .Roots}}{{if $i}}<br>{{end}}{{$r}}{{end}}</td></tr>
+<tr><td>开始时间</td><td>{{.Started.Format "2006-01-02 15:04:05"}}，用时 {{.Duration}}</td></tr>
+<tr><td>扫描文件</td><td>{{.Files}} 个，共 {{size .Bytes}}</td></tr>
+<tr><td>重复文件</td><td>{{.Groups}} 组，可清理 {{.Dups}} 个，<b>可释放 {{size .Wasted}}</b></td></tr>
+{{if .Report}}<tr><td>完整清单</td><td>{{if .URI}}<a href="{{fileURL .Report}}">{{.Report}}</a>{{else}}{{.Report}}{{end}}</td></tr>{{end}}
+</table>
+{{if .Partial}}<p style="color: #c00">扫描在完成前停止，结果不完整</p>{{end}}
+{{if .Top}}<h3>可释放空间最多的 {{len .Top}} 组</h3>
+<ol>{{range .Top}}
+<li>{{size .Size}} x {{len .Files}}，可释放 {{wasted .Size (len .Files)}}{{if .Meta}}，<i>{{.Meta}}</i>{{end}}<ul>{{range .Files}}<li>{{if $.URI}}<a href="{{fileURL .Path}}"><code>{{.Path}}</code></a>{{else}}<code>{{.Path}}</code>{{end}}{{if $.Latin}}{{with latin .Path}} <small>({{.}})</small>{{end}}{{end}}</li>{{end}}</ul>{{if .Preview}}
+<pre style="background: #f4f4f4; padding: 4px">{{.Preview}}</pre>{{end}}</li>{{end}}
+</ol>{{end}}
+</body></html>
+`
+
+// renderDigest 生成纯文本和 HTML 两种形式的摘要
+func renderDigest(s *summary) (string, string, error) {
+	text := &bytes.Buffer{}
+	if err := template.Must(template.New("text").Funcs(digestFuncs).Parse(digestText)).Execute(text, s); err != nil {
+		return "", "", err
+	}
+	html := &bytes.Buffer{}
+	// html/template 默认拒绝 file: 链接，路径经 FileURI 编码后标记为可信
+	funcs := htmltemplate.FuncMap(maps.Clone(digestFuncs))
+	funcs["fileURL"] = func(path string) htmltemplate.URL { return htmltemplate.URL(duplicate.FileURI(path)) }
+	if err := htmltemplate.Must(htmltemplate.New("html").Funcs(funcs).Parse(digestHTML)).Execute(html, s); err != nil {
+		return "", "", err
+	}
+	return text.String(), html.String(), nil
+}
+
+// buildDigestMail 生成 multipart/alternative 邮件
+func buildDigestMail(from string, to []string, s *summary) ([]byte, error) {
+	text, html, err := renderDigest(s)
+	if err != nil {
+		return nil, err
+	}
+	host, _ := os.Hostname()
+	subject := fmt.Sprintf("[duplicate-cleaner] %s: 可释放 %s", host, formatSize(s.Wasted))
+	buf := &bytes.Buffer{}
+	mw := multipart.NewWriter(buf)
+	fmt.Fprintf(buf, "From: %s\r\n", from)
+	if len(to) > 0 {
+		fmt.Fprintf(buf, "To: %s\r\n", strings.Join(to, ", "))
+	}
+	fmt.Fprintf(buf, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", subject))
+	fmt.Fprintf(buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(buf, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", mw.Boundary())
+	for _, part := range []struct{ typ, body string }{{"text/plain", text}, {"text/html", html}} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.typ + "; charset=UTF-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		qp.Write([]byte(part.body))
+		qp.Close()
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// sendDigest 通过 SMTP 发送摘要邮件，设置了用户名时使用 PLAIN 认证
+func sendDigest(cfg *Config, s *summary) error {
+	to := mailRecipients(cfg.mailTo)
+	msg, err := buildDigestMail(cfg.mailFrom, to, s)
+	if err != nil {
+		return err
+	}
+	var auth smtp.Auth
+	if cfg.smtpUser != "" {
+		host, _, err := net.SplitHostPort(cfg.smtp)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", cfg.smtpUser, os.Getenv(smtpPasswordEnv), host)
+	}
+	if err := smtp.SendMail(cfg.smtp, auth, cfg.mailFrom, to, msg); err != nil {
+		return fmt.Errorf("发送摘要邮件失败: %v", err)
+	}
+	return nil
+}
+
+// saveDigest 将摘要邮件保存为 .eml 文件，便于预览或交给其他程序发送
+func saveDigest(cfg *Config, s *summary) error {
+	msg, err := buildDigestMail(cfg.mailFrom, mailRecipients(cfg.mailTo), s)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(cfg.digest, msg, 0644)
+}
+
+// notifyDigest 按参数保存或发送摘要
+func notifyDigest(cfg *Config, s *summary) error {
+	if cfg.digest != "" {
+		if err := saveDigest(cfg, s); err != nil {
+			return err
+		}
+	}
+	if cfg.mailTo != "" {
+		return sendDigest(cfg, s)
+	}
+	return nil
+}
+
+// mailRecipients 拆分逗号分隔的收件人
+func mailRecipients(list string) []string {
+	to := []string{}
+	for _, addr := range strings.Split(list, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			to = append(to, addr)
+		}
+	}
+	return to
+}
@@ -0,0 +1,56 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package cmd
+
+import (
+	"duplicate-cleaner/duplicate"
+	"errors"
+)
+
+// 退出码
+const (
+	exitOK           = 0
+	exitError        = 1 // 其他错误
+	exitUsage        = 2 // 参数错误
+	exitPermission   = 3 // 权限不足
+	exitNotFound     = 4 // 文件不存在
+	exitHashMismatch = 5 // Hash值不匹配
+	exitProtected    = 6 // 受保护的路径
+	exitLocked       = 7 // 路径正被其他进程扫描或清理
+	exitVersion      = 8 // 索引、轨迹、清单等文件的版本不兼容
+	exitRisky        = 9 // 位于应用程序管理的目录中，需要 -allow-risky
+)
+
+// errNoDuplicates 没有找到重复文件，不视为失败
+var errNoDuplicates = errors.New("无重复文件")
+
+// exitCode 根据错误分类确定退出码，多个错误时按分类的先后取第一个匹配的
+func exitCode(err error) int {
+	switch {
+	case err == nil, errors.Is(err, errNoDuplicates):
+		return exitOK
+	case errors.Is(err, errLocked):
+		return exitLocked
+	case errors.Is(err, duplicate.ErrVersion):
+		return exitVersion
+	case errors.Is(err, duplicate.ErrProtectedPath):
+		return exitProtected
+	case errors.Is(err, duplicate.ErrRiskyPath):
+		return exitRisky
+	case errors.Is(err, duplicate.ErrHashMismatch):
+		return exitHashMismatch
+	case errors.Is(err, duplicate.ErrPermission):
+		return exitPermission
+	case errors.Is(err, duplicate.ErrNotFound):
+		return exitNotFound
+	}
+	return exitError
+}
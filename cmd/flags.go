@@ -0,0 +1,80 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// stringList 可重复指定的字符串参数
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+// timeBound 以时长(距现在多久，如 36h、90d、2w、1y)或日期(如 2024-01-31、2024-01-31 08:00:00、RFC 3339)指定的时间点，
+// 时长在解析参数时换算为时间点，日期按本地时区
+type timeBound struct {
+	s string
+	t time.Time
+}
+
+func (b *timeBound) String() string {
+	return b.s
+}
+
+func (b *timeBound) Set(s string) error {
+	t, err := parseTimeBound(s, time.Now())
+	if err != nil {
+		return err
+	}
+	b.s, b.t = s, t
+	return nil
+}
+
+// timeUnits Go 的时长不支持的天、周、年，一年按 365 天计
+var timeUnits = map[byte]time.Duration{'d': 24 * time.Hour, 'w': 7 * 24 * time.Hour, 'y': 365 * 24 * time.Hour}
+
+// parseTimeBound 解析 timeBound 的写法，now 为换算时长的基准
+func parseTimeBound(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range []string{time.DateOnly, time.DateTime, time.RFC3339} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	if n := len(s); n > 1 {
+		if unit, ok := timeUnits[s[n-1]]; ok {
+			if v, err := strconv.ParseFloat(s[:n-1], 64); err == nil && v >= 0 {
+				// 超出 time.Duration 的范围(约 292 年)时换算会溢出
+				d := v * float64(unit)
+				if d >= math.MaxInt64 {
+					return time.Time{}, fmt.Errorf("无效的时间: %s，时长不能超过 292 年", s)
+				}
+				return now.Add(-time.Duration(d)), nil
+			}
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("无效的时间: %s，应为时长(如 36h、90d、2w、1y)或日期(如 2024-01-31)", s)
+}
@@ -0,0 +1,407 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"duplicate-cleaner/duplicate"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// 清单格式
+const (
+	formatLegacy = "legacy" // 旧版文本格式，无文件头
+	formatText   = "text"   // v2 文本格式
+	formatJSON   = "json"
+	formatJSONL  = "jsonl" // JSON Lines，扫描时逐组写出，见 jsonl.go
+	formatCSV    = "csv"
+	formatBinary = "binary" // 紧凑的二进制格式，见 binary.go
+	formatSum    = "sum"    // 与 md5sum、sha256sum 等兼容的校验和，只用于输出，见 checksum.go
+	formatHTML   = "html"   // 独立的 HTML 报告，只用于输出，见 report.go
+)
+
+const (
+	listVersion = 2
+	textMagic   = "# duplicate-cleaner list v"
+	csvHeader   = "group,path,size,hash"
+	csvSkip     = "skip"  // CSV 清单中可选的第五列，任一行为 true 或 !skip 时整组跳过清理
+	skipMarker  = "!skip" // 文本清单中标记整组跳过清理
+	textEnd     = "# end" // v2 文本清单的结束标记，缺少时说明写入中断
+)
+
+// listMeta 清单头部信息
+type listMeta struct {
+	Generator string                   `json:"generator,omitempty"` // 生成清单的程序的版本和构建信息
+	Roots     []rootInfo               `json:"roots,omitempty"`
+	Partial   bool                     `json:"partial,omitempty"`  // 扫描未完成，清单只包含已确认的组
+	Volatile  []duplicate.VolatileFile `json:"volatile,omitempty"` // 扫描期间消失或被修改、未计入清单的文件，见 -tolerant
+	Open      []string                 `json:"open,omitempty"`     // 一直被其他进程写入、未计入清单的文件，见 -skip-open
+	Verbose   bool                     `json:"-"`                  // 文本格式中输出各组的详细信息
+	Latin     bool                     `json:"-"`                  // 文本格式中为含西里尔字母、假名等的路径附上拉丁转写
+	Template  *template.Template       `json:"-"`                  // 不为空时按 -template 指定的模板输出，代替文本格式
+}
+
+// rootInfo 扫描路径及其所在的文件系统
+type rootInfo struct {
+	Path string `json:"path"`
+	FS   string `json:"fs"`
+}
+
+// jsonList JSON 格式的清单
+type jsonList struct {
+	Version int `json:"version"`
+	listMeta
+	Groups duplicate.GroupList `json:"groups"`
+}
+
+// writeList 按指定格式写出重复清单
+func writeList(w io.Writer, format string, meta *listMeta, l duplicate.GroupList) error {
+	if meta.Template != nil {
+		return writeTemplate(w, meta.Template, meta, l)
+	}
+	switch format {
+	case formatText:
+		return writeText(w, meta, l)
+	case formatJSON:
+		return writeJSON(w, meta, l)
+	case formatJSONL:
+		return writeJSONL(w, meta, l)
+	case formatCSV:
+		return writeCSV(w, l)
+	case formatBinary:
+		return writeBinary(w, meta, l)
+	case formatSum:
+		return writeSum(w, l)
+	case formatHTML:
+		return writeHTML(w, meta, l)
+	default:
+		return fmt.Errorf("不支持的输出格式: %s", format)
+	}
+}
+
+// writeText 写出 v2 文本格式，每写完一组就刷新一次，最后写入结束标记
+func writeText(w io.Writer, meta *listMeta, l duplicate.GroupList) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "%s%d\n", textMagic, listVersion)
+	if meta.Generator != "" {
+		fmt.Fprintf(bw, "# generator: %s\n", meta.Generator)
+	}
+	for _, r := range meta.Roots {
+		fmt.Fprintf(bw, "# root: %s (%s)\n", r.Path, r.FS)
+	}
+	if meta.Partial {
+		fmt.Fprintf(bw, "# partial: 扫描在完成前停止，清单只包含已确认的组\n")
+	}
+	for _, v := range meta.Volatile {
+		fmt.Fprintf(bw, "# volatile: %s\t%s\n", v.Path, v.Reason)
+	}
+	for _, p := range meta.Open {
+		fmt.Fprintf(bw, "# open: %s\n", p)
+	}
+	for _, v := range l {
+		bw.WriteString(splitLine + "\n")
+		if meta.Verbose && v.Strategy != "" {
+			fmt.Fprintf(bw, "# strategy: %s\n", v.Strategy)
+		}
+		if v.Meta != "" {
+			fmt.Fprintf(bw, "# meta: %s\n", v.Meta)
+		}
+		for _, s := range v.Files {
+			if s.Type != "" {
+				fmt.Fprintf(bw, "%s\t%dB\t%s\t%s\n", s.Path, s.Size, s.Hash, s.Type)
+			} else {
+				fmt.Fprintf(bw, "%s\t%dB\t%s\n", s.Path, s.Size, s.Hash)
+			}
+			if meta.Latin {
+				if latin := romanize(s.Path); latin != "" {
+					fmt.Fprintf(bw, "# latin: %s\n", latin)
+				}
+			}
+		}
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+	}
+	bw.WriteString(textEnd + "\n")
+	return bw.Flush()
+}
+
+// writeJSON 写出 JSON 格式
+func writeJSON(w io.Writer, meta *listMeta, l duplicate.GroupList) error {
+	doc := jsonList{Version: listVersion, listMeta: *meta, Groups: l}
+	if doc.Groups == nil {
+		doc.Groups = duplicate.GroupList{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// writeCSV 写出 CSV 格式
+func writeCSV(w io.Writer, l duplicate.GroupList) error {
+	cw := csv.NewWriter(w)
+	cw.Write(strings.Split(csvHeader, ","))
+	for i, v := range l {
+		for _, s := range v.Files {
+			cw.Write([]string{strconv.Itoa(i + 1), s.Path, strconv.FormatInt(s.Size, 10), s.Hash})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// detectFormat 根据文件开头的内容判断清单格式
+func detectFormat(r *bufio.Reader) (string, error) {
+	head, err := r.Peek(512)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return "", err
+	}
+	trimmed := bytes.TrimLeft(head, " \t\r\n")
+	line, _, _ := bytes.Cut(head, []byte("\n"))
+	line = bytes.TrimRight(line, "\r")
+	switch {
+	case bytes.HasPrefix(head, []byte(binaryMagic)):
+		return formatBinary, nil
+	case len(trimmed) == 0:
+		return formatLegacy, nil
+	case bytes.HasPrefix(bytes.ToLower(trimmed), []byte("<!doctype html")):
+		return "", errors.New("HTML 报告只用于查看，不能作为清单使用")
+	case trimmed[0] == '{':
+		// JSON Lines 的第一行是完整的头部，不含各组
+		if json.Valid(line) && !bytes.Contains(line, []byte(`"groups"`)) {
+			return formatJSONL, nil
+		}
+		return formatJSON, nil
+	case bytes.HasPrefix(line, []byte(textMagic)):
+		v, err := strconv.Atoi(strings.TrimPrefix(string(line), textMagic))
+		if err != nil {
+			return "", fmt.Errorf("无效的清单文件头: %s", line)
+		}
+		if err := duplicate.CheckVersion("清单", v, listVersion, listVersion); err != nil {
+			return "", err
+		}
+		return formatText, nil
+	case string(line) == csvHeader || string(line) == csvHeader+","+csvSkip:
+		return formatCSV, nil
+	}
+	// 文本清单中不会出现 NUL，出现则视为二进制或 UTF-16 等无法识别的内容
+	if bytes.IndexByte(head, 0) >= 0 {
+		return "", errors.New("无法识别的清单格式")
+	}
+	return formatLegacy, nil
+}
+
+// parseList 解析单个清单文件，返回分组后的文件，其中的路径按 pm 转换为本机路径
+func parseList(f string, pm pathMap) (duplicate.GroupList, error) {
+	_, groups, err := loadList(f)
+	if err != nil {
+		return nil, err
+	}
+	if err := pm.apply(groups); err != nil {
+		return nil, fmt.Errorf("清单 %s 中的路径无法转换为本机路径:\n%w", f, err)
+	}
+	return groups, nil
+}
+
+// loadList 解析单个清单文件，JSON 和二进制格式同时返回头部信息，其他格式返回空的头部
+func loadList(f string) (*listMeta, duplicate.GroupList, error) {
+	file, err := os.Open(f)
+	if err != nil {
+		return nil, nil, fmt.Errorf("无法打开文件 %s: %v", f, err)
+	}
+	defer file.Close()
+	r, closeReader, err := decompressReader(bufio.NewReader(file))
+	if err != nil {
+		return nil, nil, fmt.Errorf("无法解压文件 %s: %v", f, err)
+	}
+	defer closeReader()
+	if bom, _ := r.Peek(3); string(bom) == "\xef\xbb\xbf" {
+		r.Discard(3)
+	}
+	format, err := detectFormat(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("文件 %s: %w", f, err)
+	}
+	meta := &listMeta{}
+	var groups duplicate.GroupList
+	switch format {
+	case formatLegacy:
+		groups, err = parseText(r, false)
+	case formatText:
+		groups, err = parseText(r, true)
+	case formatJSON, formatJSONL:
+		meta, groups, err = parseJSON(r)
+	case formatCSV:
+		groups, err = parseCSV(r)
+	case formatBinary:
+		meta, groups, err = parseBinary(r)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("文件 %s 格式错误(%s): %w", f, format, err)
+	}
+	return meta, groups, nil
+}
+
+// parseText 解析文本格式(含旧版)，# 开头的行为注释，含 !skip 的组整组跳过，
+// requireEnd 为 true 时缺少结束标记视为清单不完整
+func parseText(r io.Reader, requireEnd bool) (duplicate.GroupList, error) {
+	groups := duplicate.GroupList{}
+	var cur duplicate.FileInfos
+	skip := false
+	ended := false
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line != "" {
+			ended = line == textEnd
+		}
+		switch {
+		case line == splitLine:
+			if len(cur) > 0 && !skip {
+				groups = append(groups, newGroup(cur))
+			}
+			cur = nil
+			skip = false
+		case strings.TrimSpace(line) == skipMarker:
+			skip = true
+		case line == "" || strings.HasPrefix(line, "#"):
+		default:
+			fi, err := parseTextLine(line)
+			if err != nil {
+				return nil, err
+			}
+			cur = append(cur, fi)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if requireEnd && !ended {
+		return nil, errors.New("清单不完整，缺少结束标记，可能是写入时被中断")
+	}
+	if len(cur) > 0 && !skip {
+		groups = append(groups, newGroup(cur))
+	}
+	return groups, nil
+}
+
+// newGroup 由文件记录构造分组，大小和Hash值取自首个文件
+func newGroup(files duplicate.FileInfos) duplicate.Group {
+	return duplicate.Group{Hash: files[0].Hash, Size: files[0].Size, Files: files}
+}
+
+// parseTextLine 解析一行文件记录: 路径\t大小B\tHash[\t类别]，大小和Hash可省略
+func parseTextLine(line string) (duplicate.FileInfo, error) {
+	s := strings.Split(line, "\t")
+	fi := duplicate.FileInfo{Path: s[0]}
+	if len(s) > 1 {
+		size, err := strconv.ParseInt(strings.TrimSuffix(s[1], "B"), 10, 64)
+		if err != nil {
+			return fi, fmt.Errorf("无效的文件大小 %q", s[1])
+		}
+		fi.Size = size
+	}
+	if len(s) > 2 {
+		fi.Hash = s[2]
+	}
+	if len(s) > 3 {
+		fi.Type = s[3]
+	}
+	return fi, nil
+}
+
+// parseJSON 解析 JSON 格式，也用于 JSON Lines 格式
+func parseJSON(r io.Reader) (*listMeta, duplicate.GroupList, error) {
+	doc := jsonList{}
+	dec := json.NewDecoder(r)
+	if err := dec.Decode(&doc); err != nil {
+		return nil, nil, err
+	}
+	if err := duplicate.CheckVersion("清单", doc.Version, listVersion, listVersion); err != nil {
+		return nil, nil, err
+	}
+	// JSON 格式总会输出 groups，没有时是 JSON Lines 格式的头部
+	if doc.Groups == nil {
+		if err := parseJSONL(dec, &doc); err != nil {
+			return nil, nil, err
+		}
+	}
+	return &doc.listMeta, dropSkipped(doc.Groups), nil
+}
+
+// dropSkipped 去掉标记为整组跳过的组
+func dropSkipped(l duplicate.GroupList) duplicate.GroupList {
+	res := duplicate.GroupList{}
+	for _, g := range l {
+		if !g.Skip {
+			res = append(res, g)
+		}
+	}
+	return res
+}
+
+// parseCSV 解析 CSV 格式，按 group 列分组，有 skip 列时去掉标记为跳过的组
+func parseCSV(r io.Reader) (duplicate.GroupList, error) {
+	cr := csv.NewReader(r)
+	// 各行的列数须与表头一致
+	cr.FieldsPerRecord = 0
+	if _, err := cr.Read(); err != nil {
+		return nil, err
+	}
+	groups := []duplicate.FileInfos{}
+	index := map[string]int{}
+	skipped := map[int]bool{}
+	for {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.ParseInt(rec[2], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("无效的文件大小 %q", rec[2])
+		}
+		i, ok := index[rec[0]]
+		if !ok {
+			i = len(groups)
+			index[rec[0]] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], duplicate.FileInfo{Path: rec[1], Size: size, Hash: rec[3]})
+		if len(rec) > 4 && rec[4] != "" {
+			skip, err := strconv.ParseBool(rec[4])
+			if err != nil && rec[4] != skipMarker {
+				return nil, fmt.Errorf("无效的 skip 值 %q，应为 true、false 或 !skip", rec[4])
+			}
+			if skip || rec[4] == skipMarker {
+				skipped[i] = true
+			}
+		}
+	}
+	lst := duplicate.GroupList{}
+	for i, g := range groups {
+		if !skipped[i] {
+			lst = append(lst, newGroup(g))
+		}
+	}
+	return lst, nil
+}
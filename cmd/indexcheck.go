@@ -0,0 +1,44 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package cmd
+
+import (
+	"duplicate-cleaner/duplicate"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// verifyIndex 抽查 -checkpoint 指定的索引，移除过期条目、更正不符的条目后保存
+func verifyIndex(cfg *Config) error {
+	f, err := os.Open(cfg.checkpoint)
+	if err != nil {
+		return err
+	}
+	idx, err := duplicate.LoadHashIndex(f)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("读取索引 %s 失败: %w", cfg.checkpoint, err)
+	}
+	res := idx.Verify(cfg.sample, cfg.showBar())
+	for _, err := range res.Errs {
+		fmt.Println(err)
+	}
+	fmt.Printf("抽查 %d 个条目(共 %d 个): 一致 %d 个，文件已不存在 %d 个，已变化 %d 个(均已移除)，已更正 %d 个，无法读取 %d 个\n",
+		res.Checked, idx.Len()+res.Missing+res.Changed, res.OK, res.Missing, res.Changed, res.Repaired, len(res.Errs))
+	if res.Missing+res.Changed+res.Repaired > 0 {
+		if err := saveCheckpoint(cfg.checkpoint, idx); err != nil {
+			return err
+		}
+		fmt.Printf("已更新索引 %s\n", cfg.checkpoint)
+	}
+	return errors.Join(res.Errs...)
+}
@@ -0,0 +1,157 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package cmd
+
+import (
+	"bufio"
+	"duplicate-cleaner/cmd/tui"
+	"duplicate-cleaner/duplicate"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// errCanceled 在交互选择中放弃清理
+var errCanceled = errors.New("已取消，未清理任何文件")
+
+// askKeep 除文件下标外的返回值
+const (
+	keepAll  = -1 // 本组全部保留
+	keepSkip = -2 // 剩余各组全部保留
+	keepRest = -3 // 本组及剩余各组保留第一个
+)
+
+const interactiveHelp = `  数字 保留该编号的文件，删除同组其他文件
+  f    保留第一个，删除其余
+  a    全部保留
+  r    本组及剩余各组都保留第一个
+  s    跳过剩余各组，全部保留
+  q    放弃，不清理任何文件`
+
+// readGroups 读取多个清单中的全部分组
+func readGroups(files []string, pm pathMap) (duplicate.GroupList, error) {
+	var l duplicate.GroupList
+	for _, f := range files {
+		groups, err := parseList(f, pm)
+		if err != nil {
+			return nil, err
+		}
+		l = append(l, groups...)
+	}
+	return l, nil
+}
+
+// selectInteractive 逐组显示清单中的重复文件，由用户选择每组保留哪个副本，返回要删除的文件
+func selectInteractive(files []string, pm pathMap, in io.Reader, out io.Writer) ([]duplicate.FileInfo, error) {
+	l, err := readGroups(files, pm)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(out, "共 %d 组重复文件，请逐组选择要保留的文件:\n%s\n", len(l), interactiveHelp)
+	r := bufio.NewReader(in)
+	dels := []duplicate.FileInfo{}
+	rest := false
+	for i, g := range l {
+		if len(g.Files) == 0 {
+			continue
+		}
+		if rest {
+			dels = append(dels, g.Files[1:]...)
+			continue
+		}
+		fmt.Fprintf(out, "\n[%d/%d] %s  %s\n", i+1, len(l), formatSize(g.Size), g.Hash)
+		for j, f := range g.Files {
+			fmt.Fprintf(out, "  %d) %s\n", j+1, f.Path)
+		}
+		keep, err := askKeep(r, out, len(g.Files))
+		if err != nil {
+			return nil, err
+		}
+		switch keep {
+		case keepAll:
+			continue
+		case keepSkip:
+			return dels, nil
+		case keepRest:
+			rest = true
+			keep = 0
+		}
+		for j, f := range g.Files {
+			if j != keep {
+				dels = append(dels, f)
+			}
+		}
+	}
+	return dels, nil
+}
+
+// askKeep 读取一组的选择，返回保留文件的下标或 keepAll 等操作，输入无效时重新询问
+func askKeep(r *bufio.Reader, out io.Writer, n int) (int, error) {
+	for {
+		fmt.Fprintf(out, "保留 [1-%d/f/a/r/s/q/?]: ", n)
+		line, err := r.ReadString('\n')
+		if err != nil && line == "" {
+			if errors.Is(err, io.EOF) {
+				return 0, errCanceled
+			}
+			return 0, err
+		}
+		switch s := strings.ToLower(strings.TrimSpace(line)); s {
+		case "f":
+			return 0, nil
+		case "a":
+			return keepAll, nil
+		case "s":
+			return keepSkip, nil
+		case "r":
+			return keepRest, nil
+		case "q":
+			return 0, errCanceled
+		case "?", "h":
+			fmt.Fprintln(out, interactiveHelp)
+		default:
+			if k, err := strconv.Atoi(s); err == nil && k >= 1 && k <= n {
+				return k - 1, nil
+			}
+			fmt.Fprintln(out, "无效的选择，输入 ? 查看帮助")
+		}
+	}
+}
+
+// selectTUI 在全屏界面中浏览清单，返回用户标记并确认删除的文件。
+// session 不为空时从中恢复审阅进度，退出或确认时保存
+func selectTUI(files []string, pm pathMap, session string) ([]duplicate.FileInfo, error) {
+	l, err := readGroups(files, pm)
+	if err != nil {
+		return nil, err
+	}
+	if session == "" {
+		return tui.Run(l, nil, nil)
+	}
+	s, err := loadSession(session, files)
+	if err != nil {
+		return nil, err
+	}
+	save := func(s *tui.Session) error {
+		return saveSession(session, files, s)
+	}
+	dels, err := tui.Run(l, s, save)
+	if err == nil || errors.Is(err, tui.ErrCanceled) {
+		if e := save(s); e != nil {
+			fmt.Printf("保存审阅进度到 %s 失败: %v\n", session, e)
+		} else if err != nil {
+			fmt.Printf("审阅进度已保存到 %s，再次使用 -session %s 继续\n", session, session)
+		}
+	}
+	return dels, err
+}
@@ -0,0 +1,112 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package cmd
+
+import (
+	"bufio"
+	"duplicate-cleaner/duplicate"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	journalSuffix  = ".journal"
+	journalMagic   = "# duplicate-cleaner journal v"
+	journalVersion = 1
+	journalDone    = "done" // 已确认清理的文件
+)
+
+// journal 清理日志，每确认清理一个文件就追加一行，进程中途崩溃后可据此继续清理
+type journal struct {
+	f    *os.File
+	path string
+}
+
+// journalPath 清理日志与第一个清单文件放在一起
+func journalPath(lists []string) string {
+	return lists[0] + journalSuffix
+}
+
+// openJournal 打开清理日志，resume 为 true 时读取已清理的文件并继续追加，
+// 否则要求不存在未完成的日志
+func openJournal(path string, resume bool) (*journal, map[string]bool, error) {
+	done := map[string]bool{}
+	if resume {
+		var err error
+		if done, err = readJournal(path); err != nil {
+			return nil, nil, err
+		}
+	} else if _, err := os.Stat(path); err == nil {
+		return nil, nil, fmt.Errorf("发现未完成的清理日志 %s，请使用 -resume 继续清理，或确认后删除该日志", path)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(done) == 0 {
+		if _, err := fmt.Fprintf(f, "%s%d\n", journalMagic, journalVersion); err != nil {
+			f.Close()
+			return nil, nil, err
+		}
+	}
+	return &journal{f: f, path: path}, done, nil
+}
+
+// readJournal 读取已清理的文件，日志不存在时返回空集合
+func readJournal(path string) (map[string]bool, error) {
+	done := map[string]bool{}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return done, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if v, ok := strings.CutPrefix(line, journalMagic); ok {
+			n, _ := strconv.Atoi(v)
+			if err := duplicate.CheckVersion("清理日志", n, journalVersion, journalVersion); err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		// 崩溃时最后一行可能不完整，不完整的行没有路径部分，自然被忽略
+		if action, path, ok := strings.Cut(line, "\t"); ok && action == journalDone {
+			done[path] = true
+		}
+	}
+	return done, scanner.Err()
+}
+
+// Done 记录一个已清理的文件，每条记录单独写入，不经过缓冲
+func (j *journal) Done(path string) error {
+	_, err := fmt.Fprintf(j.f, "%s\t%s\n", journalDone, path)
+	return err
+}
+
+// Close 关闭日志，finished 为 true 表示清理全部成功，删除日志
+func (j *journal) Close(finished bool) error {
+	err := j.f.Close()
+	if finished {
+		return os.Remove(j.path)
+	}
+	return err
+}
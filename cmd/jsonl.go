@@ -0,0 +1,180 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"duplicate-cleaner/duplicate"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+)
+
+// jsonlHeader JSON Lines 清单的第一行
+type jsonlHeader struct {
+	Version   int        `json:"version"`
+	Generator string     `json:"generator,omitempty"`
+	Roots     []rootInfo `json:"roots,omitempty"`
+}
+
+// jsonlEnd JSON Lines 清单的最后一行，包含扫描结束后才能确定的头部信息，缺少时说明写入中断
+type jsonlEnd struct {
+	End      bool                     `json:"end"`
+	Partial  bool                     `json:"partial,omitempty"`
+	Volatile []duplicate.VolatileFile `json:"volatile,omitempty"`
+	Open     []string                 `json:"open,omitempty"`
+}
+
+// jsonlWriter 逐行写出 JSON Lines 格式的清单：头部一行，每组一行，最后是结束行
+type jsonlWriter struct {
+	enc *json.Encoder
+}
+
+// newJSONLWriter 写出头部
+func newJSONLWriter(w io.Writer, meta *listMeta) (*jsonlWriter, error) {
+	j := &jsonlWriter{enc: json.NewEncoder(w)}
+	return j, j.enc.Encode(jsonlHeader{Version: listVersion, Generator: meta.Generator, Roots: meta.Roots})
+}
+
+// Group 写出一组
+func (j *jsonlWriter) Group(g duplicate.Group) error {
+	return j.enc.Encode(g)
+}
+
+// End 写出结束行
+func (j *jsonlWriter) End(meta *listMeta) error {
+	return j.enc.Encode(jsonlEnd{End: true, Partial: meta.Partial, Volatile: meta.Volatile, Open: meta.Open})
+}
+
+// writeJSONL 写出 JSON Lines 格式
+func writeJSONL(w io.Writer, meta *listMeta, l duplicate.GroupList) error {
+	bw := bufio.NewWriter(w)
+	j, err := newJSONLWriter(bw, meta)
+	if err != nil {
+		return err
+	}
+	for _, g := range l {
+		if err := j.Group(g); err != nil {
+			return err
+		}
+	}
+	if err := j.End(meta); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// parseJSONL 解析 JSON Lines 格式的剩余部分，头部已由调用方读出
+func parseJSONL(dec *json.Decoder, doc *jsonList) error {
+	doc.Groups = duplicate.GroupList{}
+	for dec.More() {
+		var line struct {
+			duplicate.Group
+			jsonlEnd
+		}
+		if err := dec.Decode(&line); err != nil {
+			return err
+		}
+		if line.End {
+			doc.Partial, doc.Volatile, doc.Open = line.Partial, line.Volatile, line.Open
+			if dec.More() {
+				return errors.New("结束行之后还有多余的内容")
+			}
+			return nil
+		}
+		doc.Groups = append(doc.Groups, line.Group)
+	}
+	return errors.New("清单不完整，缺少结束行，可能是写入时被中断或扫描仍在进行")
+}
+
+// jsonlStream 扫描过程中逐组写入的 JSON Lines 清单文件。为了让其他程序在扫描过程中就能读取，
+// 直接写入目标文件而不经过临时文件，每写完一组就刷新一次；写入中断的清单缺少结束行，不会被 -c 使用
+type jsonlStream struct {
+	path string
+	meta *listMeta
+	file *os.File
+	zw   io.WriteCloser
+	jw   *jsonlWriter
+}
+
+// Group 写入一组，写第一组时才创建文件，没有找到重复文件时不会覆盖原有的清单
+func (s *jsonlStream) Group(g duplicate.Group) error {
+	if s.file == nil {
+		f, err := os.Create(s.path)
+		if err != nil {
+			return err
+		}
+		s.file = f
+		if s.zw, err = compressWriter(f, s.path); err != nil {
+			return err
+		}
+		if s.jw, err = newJSONLWriter(io.MultiWriter(s.zw, os.Stdout), s.meta); err != nil {
+			return err
+		}
+	}
+	if err := s.jw.Group(g); err != nil {
+		return err
+	}
+	if fl, ok := s.zw.(interface{ Flush() error }); ok {
+		return fl.Flush()
+	}
+	return nil
+}
+
+// Close 写入结束行并关闭文件，没有写入任何组时返回 errNoDuplicates
+func (s *jsonlStream) Close() error {
+	if s.file == nil {
+		return errNoDuplicates
+	}
+	defer s.Abort()
+	if err := s.jw.End(s.meta); err != nil {
+		return err
+	}
+	if err := s.zw.Close(); err != nil {
+		return err
+	}
+	return s.file.Sync()
+}
+
+// Abort 关闭文件，已写入的内容保留，未写入结束行时清单被视为不完整
+func (s *jsonlStream) Abort() {
+	if s.file != nil {
+		s.file.Close()
+		s.file = nil
+	}
+}
+
+// streamList 扫描并在每组确认后立即写入 JSON Lines 清单，返回全部的组供汇总、拆分等后续处理使用
+func streamList(ctx context.Context, cfg *Config, sc *duplicate.Scanner, stream *jsonlStream) (duplicate.GroupList, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	l := duplicate.GroupList{}
+	var werr error
+	for g := range sc.Groups(ctx) {
+		// 写入失败后停止扫描，取走通道中剩余的组
+		if werr != nil {
+			continue
+		}
+		one := duplicate.GroupList{g}
+		cfg.annotate(one)
+		if werr = stream.Group(one[0]); werr != nil {
+			cancel()
+			continue
+		}
+		l = append(l, one[0])
+	}
+	if werr != nil {
+		return l, werr
+	}
+	return l, sc.Err()
+}
@@ -0,0 +1,95 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package cmd
+
+import (
+	"duplicate-cleaner/duplicate"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// pickFunc 从清单的各组中选出要删除的文件
+type pickFunc func(l duplicate.GroupList) duplicate.FileInfos
+
+// picker 根据 -keep、-prefer-name、-keep-match、-delete-match、-protect 生成选取函数，
+// 均未指定时返回 nil，即删除清单中的全部文件
+func (cfg *Config) picker() (pickFunc, error) {
+	protect := cfg.protectDirs()
+	if !cfg.selectsKeepers() {
+		return nil, nil
+	}
+	keep := cfg.keep
+	if keep == "" {
+		keep = duplicate.KeepFirstName
+	}
+	policy, err := duplicate.NewKeepPolicy(keep)
+	if err != nil {
+		return nil, err
+	}
+	var prefs []duplicate.Preference
+	if cfg.preferName != "" {
+		expr, negate := strings.CutPrefix(cfg.preferName, "!")
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("无效的 -prefer-name: %v", err)
+		}
+		prefs = append(prefs, duplicate.PreferName(re, negate))
+	}
+	if len(cfg.keepMatch) > 0 || len(cfg.deleteMatch) > 0 {
+		rules := duplicate.KeepRules{Policy: policy, OnAllDeleted: func(files duplicate.FileInfos) {
+			fmt.Printf("警告: 同组的 %d 个文件全部匹配 -delete-match，不会全部删除，按保留策略保留其中一个: %s 等\n", len(files), files[0].Path)
+		}}
+		if rules.KeepMatch, err = compileRules("-keep-match", cfg.keepMatch); err != nil {
+			return nil, err
+		}
+		if rules.DeleteMatch, err = compileRules("-delete-match", cfg.deleteMatch); err != nil {
+			return nil, err
+		}
+		policy = rules
+	}
+	if len(protect) > 0 {
+		policy = duplicate.KeepProtected{Policy: policy, Dirs: protect}
+	}
+	return func(l duplicate.GroupList) duplicate.FileInfos {
+		for _, p := range prefs {
+			l.Prefer(p)
+		}
+		return l.Deletions(policy)
+	}, nil
+}
+
+// selectsKeepers 是否指定了从各组中选出保留文件的参数
+func (cfg *Config) selectsKeepers() bool {
+	return cfg.keep != "" || cfg.preferName != "" || len(cfg.keepMatch) > 0 || len(cfg.deleteMatch) > 0 || len(cfg.protect) > 0
+}
+
+// compileRules 编译 -keep-match、-delete-match 指定的正则表达式
+func compileRules(name string, exprs []string) ([]*regexp.Regexp, error) {
+	res := []*regexp.Regexp{}
+	for _, expr := range exprs {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("无效的 %s: %v", name, err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+// protectDirs -protect 指定的目录，转换为绝对路径
+func (cfg *Config) protectDirs() []string {
+	dirs := []string{}
+	for _, d := range cfg.protect {
+		dirs = append(dirs, duplicate.CanonicalPath(d))
+	}
+	return dirs
+}
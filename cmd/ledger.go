@@ -0,0 +1,191 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package cmd
+
+import (
+	"bufio"
+	"duplicate-cleaner/duplicate"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ledgerMagic   = "# duplicate-cleaner ledger v"
+	ledgerVersion = 1
+)
+
+// ledgerRecord 账本中的一条记录：一次清理在某个目录下清理的文件数和大小
+type ledgerRecord struct {
+	Time   time.Time
+	Root   string
+	Action string // 清理方式，见 duplicate.ActionDelete 等
+	Files  int
+	Bytes  int64
+}
+
+// defaultLedgerPath 默认的账本位置，与配置文件放在一起，如 ~/.config/duplicate-cleaner/ledger
+func defaultLedgerPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "duplicate-cleaner", "ledger")
+}
+
+// cleanAction 本次清理的方式
+func (cfg *Config) cleanAction() string {
+	switch {
+	case cfg.quarantine != "":
+		return duplicate.ActionQuarantine
+	case cfg.trash:
+		return duplicate.ActionTrash
+	}
+	return duplicate.ActionDelete
+}
+
+// recordLedger 将本次清理的文件按所在的最上层目录汇总后追加到账本，
+// 每行为 时间、目录、清理方式、文件数、字节数，以制表符分隔
+func recordLedger(path, action string, cleaned []duplicate.FileInfo) error {
+	if path == "" || len(cleaned) == 0 {
+		return nil
+	}
+	paths := make([]string, 0, len(cleaned))
+	for _, f := range cleaned {
+		paths = append(paths, f.Path)
+	}
+	roots := cleanRoots(paths)
+	sums := make([]ledgerRecord, len(roots))
+	for _, f := range cleaned {
+		abs, _ := filepath.Abs(f.Path)
+		for i, r := range roots {
+			if duplicate.IsWithin(abs, r) {
+				sums[i].Files += 1
+				sums[i].Bytes += f.Size
+				break
+			}
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(file)
+	if info, err := file.Stat(); err == nil && info.Size() == 0 {
+		fmt.Fprintf(w, "%s%d\n", ledgerMagic, ledgerVersion)
+	}
+	now := time.Now().Format(time.RFC3339)
+	for i, r := range roots {
+		if sums[i].Files > 0 {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\n", now, r, action, sums[i].Files, sums[i].Bytes)
+		}
+	}
+	err = w.Flush()
+	if e := file.Close(); err == nil {
+		err = e
+	}
+	return err
+}
+
+// readLedger 读取账本中的全部记录，账本不存在时返回空列表
+func readLedger(path string) ([]ledgerRecord, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	records := []ledgerRecord{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if v, ok := strings.CutPrefix(line, ledgerMagic); ok {
+			n, _ := strconv.Atoi(v)
+			if err := duplicate.CheckVersion("账本", n, ledgerVersion, ledgerVersion); err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if strings.HasPrefix(line, "#") || len(fields) != 5 {
+			continue
+		}
+		r := ledgerRecord{Root: fields[1], Action: fields[2]}
+		var e1, e2, e3 error
+		r.Time, e1 = time.Parse(time.RFC3339, fields[0])
+		r.Files, e2 = strconv.Atoi(fields[3])
+		r.Bytes, e3 = strconv.ParseInt(fields[4], 10, 64)
+		// 写了一半的行被忽略
+		if errors.Join(e1, e2, e3) == nil {
+			records = append(records, r)
+		}
+	}
+	return records, scanner.Err()
+}
+
+// history 按月份和目录汇总账本，显示历次清理累计释放的空间
+func history(cfg *Config) error {
+	records, err := readLedger(cfg.ledger)
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		fmt.Printf("账本 %s 中还没有清理记录\n", cfg.ledger)
+		return nil
+	}
+	type key struct{ month, root, action string }
+	sums := map[key]*ledgerRecord{}
+	keys := []key{}
+	for _, r := range records {
+		k := key{r.Time.Local().Format("2006-01"), r.Root, r.Action}
+		if sums[k] == nil {
+			sums[k] = &ledgerRecord{}
+			keys = append(keys, k)
+		}
+		sums[k].Files += r.Files
+		sums[k].Bytes += r.Bytes
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].month != keys[j].month {
+			return keys[i].month < keys[j].month
+		}
+		if keys[i].root != keys[j].root {
+			return keys[i].root < keys[j].root
+		}
+		return keys[i].action < keys[j].action
+	})
+	names := map[string]string{duplicate.ActionDelete: "删除", duplicate.ActionTrash: "回收站", duplicate.ActionQuarantine: "隔离"}
+	fmt.Println("月份\t方式\t文件数\t空间\t目录")
+	files, deleted, moved := 0, int64(0), int64(0)
+	for _, k := range keys {
+		s := sums[k]
+		fmt.Printf("%s\t%s\t%d\t%s\t%s\n", k.month, names[k.action], s.Files, formatSize(s.Bytes), k.root)
+		files += s.Files
+		if k.action == duplicate.ActionDelete {
+			deleted += s.Bytes
+		} else {
+			moved += s.Bytes
+		}
+	}
+	fmt.Printf("累计清理 %d 个文件，直接删除释放 %s，移到回收站或隔离目录 %s(清空后释放)\n", files, formatSize(deleted), formatSize(moved))
+	return nil
+}
@@ -0,0 +1,93 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package cmd
+
+import (
+	"duplicate-cleaner/duplicate"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// replaceWithLinks 按保留策略选出各组要保留的文件，将其余文件替换为指向它的硬链接、符号链接或它的克隆
+func replaceWithLinks(cfg *Config) error {
+	pick, err := cfg.picker()
+	if err != nil {
+		return err
+	}
+	pm, err := cfg.pathMap()
+	if err != nil {
+		return err
+	}
+	groups := duplicate.GroupList{}
+	paths := []string{}
+	for _, f := range cfg.args {
+		l, err := parseList(f, pm)
+		if err != nil {
+			return err
+		}
+		for _, g := range l {
+			groups = append(groups, g)
+			for _, f := range g.Files {
+				paths = append(paths, f.Path)
+			}
+		}
+	}
+	lk, err := acquireLock(lockClean, cleanRoots(paths))
+	if err != nil {
+		return err
+	}
+	defer lk.Release()
+	// 逐组处理，不为每组显示进度条
+	opts := duplicate.CleanOptions{RelativeLinks: cfg.relative, AllowRisky: cfg.allowRisky, Protected: cfg.protectDirs()}
+	if cfg.verbose {
+		opts.OnCleaned = func(path string) { fmt.Printf("LINK\t%s\n", path) }
+	}
+	cleaner := duplicate.NewCleaner(opts)
+	link, kind := cleaner.Hardlink, "硬链接"
+	switch {
+	case cfg.symlink:
+		link, kind = cleaner.Symlink, "符号链接"
+	case cfg.reflink:
+		link, kind = cleaner.Reflink, "克隆"
+	}
+	n := 0
+	var saved int64
+	errs := []error{}
+	for _, g := range groups {
+		dels := pick(duplicate.GroupList{g})
+		if len(dels) == 0 {
+			continue
+		}
+		// 每组链接到第一个保留的文件，per-dir 等保留多个文件时其余保留的文件不变
+		i := slices.IndexFunc(g.Files, func(f duplicate.FileInfo) bool {
+			return !slices.ContainsFunc(dels, func(d duplicate.FileInfo) bool { return d.Path == f.Path })
+		})
+		keeper := g.Files[i]
+		g.Files = append(duplicate.FileInfos{keeper}, dels...)
+		m, err := link(g, keeper.Path)
+		n += m
+		saved += int64(m) * g.Size
+		if err != nil {
+			errs = append(errs, err)
+		}
+		// 文件系统不支持克隆时其余的组也不会成功
+		if errors.Is(err, duplicate.ErrCloneUnsupported) && m == 0 {
+			break
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		fmt.Printf("替换 %d 个文件为%s，释放 %s\n", n, kind, formatSize(saved))
+		return err
+	}
+	fmt.Printf("成功替换 %d 个文件为%s，释放 %s\n", n, kind, formatSize(saved))
+	return nil
+}
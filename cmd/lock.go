@@ -0,0 +1,199 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package cmd
+
+import (
+	"duplicate-cleaner/duplicate"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// 加锁的操作
+const (
+	lockScan  = "scan"
+	lockClean = "clean"
+)
+
+// errLocked 路径正被另一个进程扫描或清理
+var errLocked = errors.New("路径正被其他进程使用")
+
+// lockVersion 锁文件的版本，更新版本的锁文件只要能读出进程号和路径同样生效
+const lockVersion = 1
+
+// lockInfo 锁文件的内容
+type lockInfo struct {
+	Version int       `json:"version"`
+	Op      string    `json:"op"`
+	Pid     int       `json:"pid"`
+	Roots   []string  `json:"roots"`
+	Started time.Time `json:"started"`
+}
+
+// lock 本进程持有的建议锁
+type lock struct {
+	path string
+}
+
+// lockDir 所有用户的进程共用的锁目录
+func lockDir() string {
+	return filepath.Join(os.TempDir(), "duplicate-cleaner-locks")
+}
+
+// makeLockDir 创建锁目录。目录须对所有用户可写，受 umask 影响创建时的权限不够，
+// 需再设置为 1777(同 /tmp，各用户只能删除自己的文件)；目录属于其他用户时无法也无需修改
+func makeLockDir(dir string) error {
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return err
+	}
+	const mode = 0777 | os.ModeSticky
+	if info, err := os.Stat(dir); err == nil && info.Mode()&mode != mode {
+		os.Chmod(dir, mode)
+	}
+	return nil
+}
+
+// writeLock 先写入临时文件再改名，其他进程不会读到写了一半的锁文件
+func writeLock(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), ".lock-*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	_, err = f.Write(data)
+	err = errors.Join(err, f.Chmod(0644), f.Close())
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
+
+// acquireLock 为涉及的路径加建议锁。扫描与清理互斥，清理与清理互斥，
+// 多个扫描可以同时进行；路径互为上下级即视为重叠。
+// 先写入自己的锁再检查其他锁，两个进程同时加锁时至多一方成功
+func acquireLock(op string, roots []string) (*lock, error) {
+	dir := lockDir()
+	if err := makeLockDir(dir); err != nil {
+		return nil, err
+	}
+	info := lockInfo{Version: lockVersion, Op: op, Pid: os.Getpid(), Roots: roots, Started: time.Now()}
+	data, err := json.Marshal(info)
+	if err != nil {
+		return nil, err
+	}
+	l := &lock{path: filepath.Join(dir, strconv.Itoa(info.Pid)+".lock")}
+	if err := writeLock(l.path, data); err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		l.Release()
+		return nil, err
+	}
+	for _, e := range entries {
+		p := filepath.Join(dir, e.Name())
+		if p == l.path || filepath.Ext(p) != ".lock" {
+			continue
+		}
+		other, err := readLock(p)
+		if errors.Is(err, fs.ErrNotExist) {
+			// 其他进程刚刚释放
+			continue
+		}
+		// 无法确认锁的内容时按被占用处理，宁可让用户手动删除，也不能与未知的进程同时操作
+		if err != nil {
+			l.Release()
+			return nil, fmt.Errorf("%w: 无法读取锁文件 %s(%v)，确认没有其他 duplicate-cleaner 进程在运行后可删除该文件", errLocked, p, err)
+		}
+		// 清理残留的锁
+		if !processAlive(other.Pid) {
+			os.Remove(p)
+			continue
+		}
+		if op == lockScan && other.Op == lockScan {
+			continue
+		}
+		if r, o, ok := overlapRoots(roots, other.Roots); ok {
+			l.Release()
+			return nil, fmt.Errorf("%w: %s 与进程 %d 正在%s的 %s 重叠，请等待其结束后再试(锁文件 %s)",
+				errLocked, r, other.Pid, opName(other.Op), o, p)
+		}
+	}
+	return l, nil
+}
+
+// Release 释放锁
+func (l *lock) Release() {
+	os.Remove(l.path)
+}
+
+// readLock 读取锁文件
+func readLock(path string) (*lockInfo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	info := &lockInfo{}
+	if err := json.Unmarshal(data, info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// overlapRoots 查找两组路径中互为上下级的一对
+func overlapRoots(a, b []string) (string, string, bool) {
+	for _, x := range a {
+		for _, y := range b {
+			if duplicate.IsWithin(x, y) || duplicate.IsWithin(y, x) {
+				return x, y, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// cleanRoots 待清理文件所在的目录，已包含在上级目录中的不再重复列出
+func cleanRoots(files []string) []string {
+	dirs := map[string]bool{}
+	for _, f := range files {
+		if abs, err := filepath.Abs(f); err == nil {
+			dirs[filepath.Dir(abs)] = true
+		}
+	}
+	sorted := make([]string, 0, len(dirs))
+	for d := range dirs {
+		sorted = append(sorted, d)
+	}
+	sort.Strings(sorted)
+	roots := []string{}
+	for _, d := range sorted {
+		if len(roots) == 0 || !duplicate.IsWithin(d, roots[len(roots)-1]) {
+			roots = append(roots, d)
+		}
+	}
+	return roots
+}
+
+func opName(op string) string {
+	if op == lockClean {
+		return "清理"
+	}
+	return "扫描"
+}
@@ -0,0 +1,68 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package cmd
+
+import (
+	"duplicate-cleaner/duplicate"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// printOwners 按所有者输出重复文件占用的空间
+func printOwners(l duplicate.GroupList) {
+	fmt.Println("按所有者统计的重复占用:")
+	for _, u := range duplicate.UsageByOwner(l) {
+		fmt.Printf("  %-20s %6d 个  %s\n", ownerName(u.Owner), u.Files, formatSize(u.Bytes))
+	}
+}
+
+// writeOwnerReports 为每个所有者输出一份只包含其文件所在组的清单，便于分别通知
+func writeOwnerReports(dir string, format string, meta *listMeta, l duplicate.GroupList) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	ext := map[string]string{formatText: ".txt", formatJSON: ".json", formatJSONL: ".jsonl", formatCSV: ".csv", formatBinary: ".dcb", formatSum: ".sum", formatHTML: ".html"}[format]
+	for _, u := range duplicate.UsageByOwner(l) {
+		f := filepath.Join(dir, ownerFileName(u.Owner)+ext)
+		file, err := createAtomic(f)
+		if err != nil {
+			return err
+		}
+		if err := writeList(file, format, meta, l.ByOwner(u.Owner)); err != nil {
+			file.Abort()
+			return err
+		}
+		if err := file.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ownerName 未能识别所有者时显示为 unknown
+func ownerName(owner string) string {
+	if owner == "" {
+		return "unknown"
+	}
+	return owner
+}
+
+// ownerFileName 将所有者名转换为可用作文件名的形式，如 DOMAIN\user 转为 DOMAIN_user
+func ownerFileName(owner string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`\/:*?"<>|`, r) || r < ' ' {
+			return '_'
+		}
+		return r
+	}, ownerName(owner))
+}
@@ -0,0 +1,135 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package cmd
+
+import (
+	"duplicate-cleaner/duplicate"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// pathRule 一条路径对应关系，from 为清单中(可能来自其他系统)的路径前缀，to 为本机的路径
+type pathRule struct {
+	from    string // 分隔符统一为 /，不含末尾的分隔符
+	to      string
+	windows bool // from 是 Windows 路径，以 \ 和 / 为分隔符，不区分大小写
+}
+
+// pathMap 按 -map-path 将清单中的路径转换为本机路径，较长的前缀优先
+type pathMap []pathRule
+
+// pathMap 解析 -map-path，未指定时返回 nil，同一前缀指定了多次时返回错误
+func (cfg *Config) pathMap() (pathMap, error) {
+	m := pathMap{}
+	for _, s := range cfg.mapPaths {
+		from, to, ok := strings.Cut(s, "=")
+		if !ok || from == "" || to == "" {
+			return nil, fmt.Errorf("无效的 -map-path: %s，应为 原路径=本机路径", s)
+		}
+		r := pathRule{windows: isWindowsAbs(from), to: to}
+		r.from = strings.TrimRight(r.normalize(from), "/")
+		for _, o := range m {
+			if o.windows == r.windows && o.key(o.from) == r.key(r.from) {
+				return nil, fmt.Errorf("-map-path 中的 %s 指定了多次", from)
+			}
+		}
+		m = append(m, r)
+	}
+	if len(m) == 0 {
+		return nil, nil
+	}
+	sort.SliceStable(m, func(i, j int) bool { return len(m[i].from) > len(m[j].from) })
+	return m, nil
+}
+
+// normalize 将路径的分隔符统一为 /，Unix 路径中的 \ 是文件名的一部分，保持不变
+func (r pathRule) normalize(p string) string {
+	if r.windows {
+		return strings.ReplaceAll(p, `\`, "/")
+	}
+	return p
+}
+
+// key 用于比较的形式，Windows 路径不区分大小写
+func (r pathRule) key(p string) string {
+	if r.windows {
+		return strings.ToLower(p)
+	}
+	return p
+}
+
+// match 路径以 from 开头时返回其余部分(以 / 分隔)
+func (r pathRule) match(p string) (string, bool) {
+	p = r.normalize(p)
+	if len(p) < len(r.from) || r.key(p[:len(r.from)]) != r.key(r.from) {
+		return "", false
+	}
+	rest := p[len(r.from):]
+	if rest != "" && rest[0] != '/' {
+		return "", false
+	}
+	return strings.TrimLeft(rest, "/"), true
+}
+
+// mapPath 转换单个路径。没有匹配的对应关系但路径明显来自其他系统，
+// 或 Unix 文件名中的 \ 在本机会被当作分隔符时，无法确定本机路径，返回错误
+func (m pathMap) mapPath(p string) (string, error) {
+	for _, r := range m {
+		rest, ok := r.match(p)
+		if !ok {
+			continue
+		}
+		if !r.windows && filepath.Separator == '\\' && strings.ContainsAny(rest, `\:`) {
+			return "", errors.New("文件名中含有在 Windows 上不能使用的 \\ 或 :，无法转换")
+		}
+		return filepath.Join(r.to, filepath.FromSlash(rest)), nil
+	}
+	if isForeignPath(p) {
+		return "", errors.New("是其他系统的路径，请用 -map-path 指定对应的本机路径")
+	}
+	return p, nil
+}
+
+// apply 转换清单中的全部路径
+func (m pathMap) apply(l duplicate.GroupList) error {
+	errs := []error{}
+	for _, g := range l {
+		for i, f := range g.Files {
+			p, err := m.mapPath(f.Path)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", f.Path, err))
+				continue
+			}
+			g.Files[i].Path = p
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// isWindowsAbs 是否为 Windows 的绝对路径，如 C:\a、C:/a 或 \\server\share
+func isWindowsAbs(p string) bool {
+	if strings.HasPrefix(p, `\\`) {
+		return true
+	}
+	return len(p) >= 3 && p[1] == ':' && (p[2] == '\\' || p[2] == '/') &&
+		(('a' <= p[0] && p[0] <= 'z') || ('A' <= p[0] && p[0] <= 'Z'))
+}
+
+// isForeignPath 路径是否来自其他系统：Unix 上的 Windows 绝对路径，或 Windows 上以 / 开头的 Unix 路径
+func isForeignPath(p string) bool {
+	if filepath.Separator == '\\' {
+		return strings.HasPrefix(p, "/") && !strings.HasPrefix(p, "//")
+	}
+	return isWindowsAbs(p)
+}
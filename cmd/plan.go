@@ -0,0 +1,303 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package cmd
+
+import (
+	"duplicate-cleaner/duplicate"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+)
+
+const (
+	planVersion = 1
+	auditSuffix = ".audit"
+)
+
+// cleanPlan 删除计划，由 -emit-script json 生成，审批后用 -apply 执行
+type cleanPlan struct {
+	Version   int        `json:"version"`
+	Created   time.Time  `json:"created"`
+	Generator string     `json:"generator,omitempty"` // 生成计划的程序的版本和构建信息
+	Lists     []string   `json:"lists"`               // 生成计划所用的清单
+	Items     []planItem `json:"items"`
+}
+
+// planItem 计划中的一个待删除文件及同组保留的文件
+type planItem struct {
+	Path string   `json:"path"`
+	Size int64    `json:"size"`
+	Hash string   `json:"hash,omitempty"`
+	Keep []string `json:"keep,omitempty"` // 删除前至少要有一个仍然完好，为空时不检查
+	Risk string   `json:"risk,omitempty"` // 文件位于该应用程序管理的目录中，执行时需要 -allow-risky
+}
+
+// buildPlan 读取清单并按 -keep、-prefer-name 生成删除计划，
+// 有文件位于应用程序管理的目录中且未指定 -allow-risky 时返回这些文件的错误
+func buildPlan(cfg *Config) (*cleanPlan, error) {
+	pick, err := cfg.picker()
+	if err != nil {
+		return nil, err
+	}
+	pm, err := cfg.pathMap()
+	if err != nil {
+		return nil, err
+	}
+	plan := &cleanPlan{Version: planVersion, Created: time.Now(), Generator: currentBuild().String(), Lists: cfg.args, Items: []planItem{}}
+	for _, f := range cfg.args {
+		groups, err := parseList(f, pm)
+		if err != nil {
+			return nil, err
+		}
+		for _, g := range groups {
+			dels, keep := g.Files, []string(nil)
+			// 未指定保留策略时清单已由人工编辑，无法得知保留的是哪些文件
+			if pick != nil {
+				dels = pick(duplicate.GroupList{g})
+				for _, f := range g.Files {
+					if !slices.ContainsFunc(dels, func(d duplicate.FileInfo) bool { return d.Path == f.Path }) {
+						keep = append(keep, f.Path)
+					}
+				}
+			}
+			for _, d := range dels {
+				plan.Items = append(plan.Items, planItem{Path: d.Path, Size: d.Size, Hash: d.Hash, Keep: keep, Risk: duplicate.RiskyApp(d.Path)})
+			}
+		}
+	}
+	if !cfg.allowRisky {
+		errs := []error{}
+		for _, it := range plan.Items {
+			if it.Risk != "" {
+				errs = append(errs, riskyError(it.Path, it.Risk))
+			}
+		}
+		if len(errs) > 0 {
+			return nil, errors.Join(errs...)
+		}
+	}
+	return plan, nil
+}
+
+// total 计划删除的总字节数
+func (p *cleanPlan) total() int64 {
+	var n int64
+	for _, it := range p.Items {
+		n += it.Size
+	}
+	return n
+}
+
+// loadPlan 读取删除计划
+func loadPlan(path string) (*cleanPlan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	plan := &cleanPlan{}
+	if err := json.Unmarshal(data, plan); err != nil {
+		return nil, fmt.Errorf("无法解析删除计划 %s: %v", path, err)
+	}
+	if err := duplicate.CheckVersion("删除计划", plan.Version, planVersion, planVersion); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return plan, nil
+}
+
+// mapPaths 将计划中的路径按 pm 转换为本机路径
+func (p *cleanPlan) mapPaths(pm pathMap) error {
+	errs := []error{}
+	mapPath := func(path *string) {
+		mapped, err := pm.mapPath(*path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", *path, err))
+			return
+		}
+		*path = mapped
+	}
+	for i := range p.Items {
+		mapPath(&p.Items[i].Path)
+		for j := range p.Items[i].Keep {
+			mapPath(&p.Items[i].Keep[j])
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("删除计划中的路径无法转换为本机路径:\n%w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// applyPlan 执行删除计划。每个文件先预检(存在、可删除、大小和Hash值一致)，
+// 并确认同组保留的副本至少有一个仍然完好，未通过的文件跳过；
+// 与 -c 一样加锁并写清理日志，可用 -resume 继续，另在 <计划>.audit 中追加每个文件的处理结果
+func applyPlan(cfg *Config) error {
+	plan, err := loadPlan(cfg.apply)
+	if err != nil {
+		return err
+	}
+	pm, err := cfg.pathMap()
+	if err != nil {
+		return err
+	}
+	if err := plan.mapPaths(pm); err != nil {
+		return err
+	}
+	paths := make([]string, 0, len(plan.Items))
+	for _, it := range plan.Items {
+		paths = append(paths, it.Path)
+	}
+	lk, err := acquireLock(lockClean, cfg.cleanLockRoots(paths))
+	if err != nil {
+		return err
+	}
+	defer lk.Release()
+	jpath := cfg.workPath(cfg.apply + journalSuffix)
+	apath := cfg.workPath(cfg.apply + auditSuffix)
+	upath := cfg.workPath(cfg.apply + undoSuffix)
+	if err := cfg.checkFreeSpace(jpath, apath, upath); err != nil {
+		return err
+	}
+	j, done, err := openJournal(jpath, cfg.resume)
+	if err != nil {
+		return err
+	}
+	audit, err := os.OpenFile(apath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		j.Close(false)
+		return err
+	}
+	defer audit.Close()
+	undo, err := duplicate.OpenUndoLog(upath)
+	if err != nil {
+		j.Close(false)
+		return err
+	}
+	defer undo.Close()
+	// 每条记录都带上执行的程序的版本，同一计划可能由不同版本多次 -resume
+	generator := currentBuild().String()
+	record := func(result, path, detail string) {
+		fmt.Fprintf(audit, "%s\t%s\t%s\t%s\t%s\n", time.Now().Format(time.RFC3339), result, path, detail, generator)
+	}
+	fmt.Printf("执行删除计划 %s(生成于 %s)，共 %d 个文件 %s\n", cfg.apply, plan.Created.Format(time.DateTime), len(plan.Items), formatSize(plan.total()))
+	// 逐个预检，不显示进度条
+	checker := duplicate.NewCleaner(duplicate.CleanOptions{AllowRisky: cfg.allowRisky, Protected: cfg.protectDirs()})
+	delList := []string{}
+	kept := map[string]string{}
+	skipped := []error{}
+	for _, it := range plan.Items {
+		if done[it.Path] {
+			continue
+		}
+		f := duplicate.FileInfo{Path: it.Path, Size: it.Size, Hash: it.Hash}
+		if r := checker.Check([]duplicate.FileInfo{f}); r[0].Err != nil {
+			record("skip", it.Path, r[0].Err.Error())
+			skipped = append(skipped, r[0].Err)
+			continue
+		}
+		if len(it.Keep) > 0 {
+			keeper := ""
+			for _, k := range it.Keep {
+				if checker.Verify(duplicate.FileInfo{Path: k, Size: it.Size, Hash: it.Hash}) == nil {
+					keeper = k
+					break
+				}
+			}
+			if keeper == "" {
+				err := fmt.Errorf("%s: 同组保留的副本均已不存在或内容已变化", it.Path)
+				record("skip", it.Path, "同组保留的副本均已不存在或内容已变化")
+				skipped = append(skipped, err)
+				continue
+			}
+			kept[it.Path] = keeper
+		}
+		delList = append(delList, it.Path)
+		undo.Expect([]duplicate.FileInfo{f})
+	}
+	if len(done) > 0 {
+		fmt.Printf("跳过上次已清理的 %d 个文件\n", len(done))
+	}
+	sizes := map[string]int64{}
+	for _, it := range plan.Items {
+		sizes[it.Path] = it.Size
+	}
+	cleaned := []duplicate.FileInfo{}
+	var jerr error
+	cleaner := duplicate.NewCleaner(duplicate.CleanOptions{
+		Progress:      cfg.showBar(),
+		OnProgress:    cfg.onProgress(),
+		IgnoreMissing: cfg.resume,
+		AllowRisky:    cfg.allowRisky,
+		Protected:     cfg.protectDirs(),
+		Trash:         cfg.trash,
+		Quarantine:    cfg.quarantine,
+		Undo:          undo,
+		OnCleaned: func(path string) {
+			if e := j.Done(path); e != nil && jerr == nil {
+				jerr = fmt.Errorf("写入清理日志失败: %v", e)
+			}
+			// 记录保留的副本，误删时可从中复制恢复
+			detail := ""
+			if k, ok := kept[path]; ok {
+				detail = "保留 " + k
+			}
+			record("deleted", path, detail)
+			cleaned = append(cleaned, duplicate.FileInfo{Path: path, Size: sizes[path]})
+		},
+	})
+	n, err := cleaner.Clean(delList)
+	if e := recordLedger(cfg.ledger, cfg.cleanAction(), cleaned); e != nil {
+		fmt.Printf("写入账本 %s 失败: %v\n", cfg.ledger, e)
+	}
+	for _, e := range unwrapJoined(err) {
+		var pe *duplicate.PathError
+		if errors.As(e, &pe) {
+			record("failed", pe.Path, pe.Err.Error())
+		}
+	}
+	err = errors.Join(err, jerr)
+	j.Close(err == nil && len(skipped) == 0)
+	for _, e := range skipped {
+		fmt.Printf("跳过\t%v\n", e)
+	}
+	fmt.Printf("成功清理 %d 个文件，跳过 %d 个，处理记录见 %s\n", n, len(skipped), apath)
+	if err != nil {
+		return err
+	}
+	if cfg.autoPurge() {
+		err = purgeQuarantine(cfg)
+	}
+	if len(skipped) > 0 {
+		return errors.Join(err, checkError(skipped))
+	}
+	return err
+}
+
+// unwrapJoined 展开 errors.Join 合并的错误
+func unwrapJoined(err error) []error {
+	if err == nil {
+		return nil
+	}
+	if u, ok := err.(interface{ Unwrap() []error }); ok {
+		return u.Unwrap()
+	}
+	return []error{err}
+}
+
+// writePlan 将删除计划以 JSON 格式输出
+func writePlan(w io.Writer, plan *cleanPlan) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(plan)
+}
@@ -0,0 +1,35 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package cmd
+
+import (
+	"duplicate-cleaner/duplicate"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// scanPriorities 解析 -priority，模式的写法与 -preset 中的相同
+func (cfg *Config) scanPriorities() ([]duplicate.Priority, error) {
+	prios := []duplicate.Priority{}
+	for _, s := range cfg.priorities {
+		i := strings.LastIndex(s, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("无效的 -priority: %s，应为 模式=优先级", s)
+		}
+		level, err := strconv.Atoi(strings.TrimSpace(s[i+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("无效的 -priority: %s，优先级应为整数", s)
+		}
+		prios = append(prios, duplicate.Priority{Pattern: s[:i], Level: level})
+	}
+	return prios, nil
+}
@@ -0,0 +1,84 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package cmd
+
+import (
+	"duplicate-cleaner/duplicate"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// 进度的显示方式
+const (
+	progressBar  = "bar"  // 进度条
+	progressJSON = "json" // 每行一个 JSON 事件，输出到标准错误
+	progressNone = "none" // 不显示
+)
+
+var progressKinds = []string{progressBar, progressJSON, progressNone}
+
+// progressEvent -progress json 输出的进度事件，未知的总数和无法估计的剩余时间为 null
+type progressEvent struct {
+	Time       time.Time `json:"time"`
+	Stage      string    `json:"stage"`
+	Done       int64     `json:"done"`
+	Total      *int64    `json:"total"`
+	Bytes      int64     `json:"bytes"`
+	TotalBytes *int64    `json:"total_bytes"`
+	Elapsed    float64   `json:"elapsed"` // 秒
+	ETA        *float64  `json:"eta"`     // 秒
+	Final      bool      `json:"final"`
+}
+
+// showBar 是否显示进度条
+func (cfg *Config) showBar() bool {
+	return cfg.progress == progressBar
+}
+
+// onProgress -progress json 时将进度事件写到标准错误，否则返回 nil
+func (cfg *Config) onProgress() func(duplicate.Progress) {
+	if cfg.progress != progressJSON {
+		return nil
+	}
+	return func(p duplicate.Progress) {
+		writeProgress(os.Stderr, p)
+	}
+}
+
+// writeProgress 将进度事件写为一行 JSON，写入失败时忽略，不影响扫描和清理
+func writeProgress(w io.Writer, p duplicate.Progress) {
+	e := progressEvent{
+		Time:    time.Now(),
+		Stage:   p.Stage,
+		Done:    p.Done,
+		Bytes:   p.Bytes,
+		Elapsed: p.Elapsed.Seconds(),
+		Final:   p.Final,
+	}
+	if p.Total >= 0 {
+		e.Total = &p.Total
+	}
+	if p.TotalBytes > 0 {
+		e.TotalBytes = &p.TotalBytes
+	}
+	if p.ETA >= 0 {
+		eta := p.ETA.Seconds()
+		e.ETA = &eta
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "%s\n", b)
+}
@@ -0,0 +1,49 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package cmd
+
+import (
+	"duplicate-cleaner/duplicate"
+	"fmt"
+	"path/filepath"
+)
+
+// autoPurge 是否在清理到隔离目录后按 -retain、-quarantine-max 自动清空过期的文件
+func (cfg *Config) autoPurge() bool {
+	return cfg.quarantine != "" && (cfg.retain > 0 || cfg.quarantineMax > 0)
+}
+
+// cleanLockRoots 清理时加锁的路径，需要自动清空隔离目录时包括隔离目录
+func (cfg *Config) cleanLockRoots(files []string) []string {
+	if cfg.autoPurge() {
+		files = append(files[:len(files):len(files)], filepath.Join(cfg.quarantine, duplicate.QuarantineManifest))
+	}
+	return cleanRoots(files)
+}
+
+// purge 按 -purge 清空 -quarantine 指定的隔离目录中过期或超出容量的文件
+func purge(cfg *Config) error {
+	lk, err := acquireLock(lockClean, cfg.cleanLockRoots(nil))
+	if err != nil {
+		return err
+	}
+	defer lk.Release()
+	return purgeQuarantine(cfg)
+}
+
+// purgeQuarantine 永久删除隔离目录中超过 -retain 的文件，总大小仍超过 -quarantine-max 时从最早隔离的开始删除，
+// 调用方需已对隔离目录加锁
+func purgeQuarantine(cfg *Config) error {
+	res, err := duplicate.PurgeQuarantine(cfg.quarantine, cfg.retain, int64(cfg.quarantineMax))
+	fmt.Printf("永久删除隔离目录 %s 中的 %d 个文件，释放 %s，剩余 %d 个文件 %s\n",
+		cfg.quarantine, res.Files, formatSize(res.Bytes), res.KeptFiles, formatSize(res.KeptBytes))
+	return err
+}
@@ -0,0 +1,168 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package cmd
+
+import (
+	"bufio"
+	"duplicate-cleaner/duplicate"
+	"html/template"
+	"io"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// htmlReport -format html 报告中的数据
+type htmlReport struct {
+	listMeta
+	Generated time.Time
+	Groups    duplicate.GroupList
+	Files     int   // 各组文件数之和
+	Dups      int   // 可清理的重复副本数
+	Wasted    int64 // 每组只保留一个文件时可释放的空间
+	Dirs      []dirUsage
+}
+
+// dirUsage 一个目录中的重复文件，每组的第一个文件视为保留的副本，其余计入可释放的空间
+type dirUsage struct {
+	Dir    string
+	Files  int
+	Bytes  int64
+	Wasted int64
+}
+
+var reportFuncs = template.FuncMap{
+	"inc":    func(i int) int { return i + 1 },
+	"size":   formatSize,
+	"wasted": func(size int64, n int) int64 { return size * int64(n-1) },
+	"latin":  romanize,
+	// html/template 默认拒绝 file: 链接，URI 由 FileURI 编码，标记为可信
+	"fileURL": func(uri string) template.URL { return template.URL(uri) },
+}
+
+// reportHTML 独立的 HTML 报告，不引用外部资源，点击表头即可排序，便于作为邮件附件发送
+const reportHTML = `<!DOCTYPE html>
+<html lang="zh-CN"><head><meta charset="utf-8">
+<title>重复文件报告</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222 }
+table { border-collapse: collapse; margin-bottom: 2em }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top }
+th { background: #f0f0f0 }
+table.sortable th { cursor: pointer; user-select: none }
+th.asc::after { content: " \25B2" }
+th.desc::after { content: " \25BC" }
+td.num { text-align: right; white-space: nowrap }
+code { font-size: 90% }
+.warn { color: #c00 }
+</style></head><body>
+<h1>重复文件报告</h1>
+<table>
+<tr><th>生成时间</th><td>{{.Generated.Format "2006-01-02 15:04:05"}}</td></tr>
+{{if .Generator}}<tr><th>生成程序</th><td>{{.Generator}}</td></tr>{{end}}
+{{if .Roots}}<tr><th>扫描路径</th><td>{{range $i, $r := .Roots}}{{if $i}}<br>{{end}}<code>{{$r.Path}}</code> ({{$r.FS}}){{end}}</td></tr>{{end}}
+<tr><th>重复文件</th><td>{{len .Groups}} 组，{{.Files}} 个文件，可清理 {{.Dups}} 个</td></tr>
+<tr><th>可释放空间</th><td><b>{{size .Wasted}}</b> ({{.Wasted}} 字节)</td></tr>
+</table>
+{{if .Partial}}<p class="warn">扫描在完成前停止，报告只包含已确认的组</p>{{end}}
+{{if .Volatile}}<p class="warn">扫描期间有 {{len .Volatile}} 个文件消失或被修改，未计入报告</p>{{end}}
+{{if .Open}}<p class="warn">有 {{len .Open}} 个文件一直被其他进程写入，未计入报告</p>{{end}}
+<h2>按目录汇总</h2>
+<p>每组的第一个文件视为保留的副本，其余副本所在的目录计入可释放的空间。</p>
+<table class="sortable">
+<thead><tr><th>目录</th><th data-type="num">文件数</th><th data-type="num">大小</th><th data-type="num" class="desc">可释放</th></tr></thead>
+<tbody>{{range .Dirs}}
+<tr><td><code>{{.Dir}}</code></td><td class="num">{{.Files}}</td><td class="num" data-v="{{.Bytes}}">{{size .Bytes}}</td><td class="num" data-v="{{.Wasted}}">{{size .Wasted}}</td></tr>{{end}}
+</tbody></table>
+<h2>重复文件组</h2>
+<table class="sortable">
+<thead><tr><th data-type="num">#</th><th data-type="num">大小</th><th data-type="num">副本数</th><th data-type="num">可释放</th><th>Hash</th><th>文件</th></tr></thead>
+<tbody>{{range $i, $g := .Groups}}{{$w := wasted $g.Size (len $g.Files)}}
+<tr><td class="num">{{inc $i}}</td><td class="num" data-v="{{$g.Size}}">{{size $g.Size}}</td><td class="num">{{len $g.Files}}</td><td class="num" data-v="{{$w}}">{{size $w}}</td><td><code>{{$g.Hash}}</code>{{if $g.Meta}}<br><i>{{$g.Meta}}</i>{{end}}</td>
+<td>{{range $j, $f := $g.Files}}{{if $j}}<br>{{end}}{{if $f.URI}}<a href="{{fileURL $f.URI}}"><code>{{$f.Path}}</code></a>{{else}}<code>{{$f.Path}}</code>{{end}}{{if $.Latin}}{{with latin $f.Path}} <small>({{.}})</small>{{end}}{{end}}{{end}}</td></tr>{{end}}
+</tbody></table>
+<script>
+document.querySelectorAll("table.sortable").forEach(function (table) {
+  var heads = table.querySelectorAll("th");
+  heads.forEach(function (th, col) {
+    th.addEventListener("click", function () {
+      var desc = !th.classList.contains("desc");
+      var num = th.dataset.type === "num";
+      var body = table.tBodies[0];
+      var key = function (tr) {
+        var td = tr.cells[col];
+        var v = td.dataset.v !== undefined ? td.dataset.v : td.textContent;
+        return num ? Number(v) : v;
+      };
+      var rows = Array.prototype.slice.call(body.rows);
+      rows.sort(function (a, b) {
+        var x = key(a), y = key(b);
+        var c = num ? x - y : String(x).localeCompare(String(y));
+        return desc ? -c : c;
+      });
+      rows.forEach(function (tr) { body.appendChild(tr); });
+      heads.forEach(function (h) { h.classList.remove("asc", "desc"); });
+      th.classList.add(desc ? "desc" : "asc");
+    });
+  });
+});
+</script>
+</body></html>
+`
+
+// writeHTML 写出独立的 HTML 报告，包含汇总、按目录汇总和各组的可排序表格，只用于输出
+func writeHTML(w io.Writer, meta *listMeta, l duplicate.GroupList) error {
+	r := htmlReport{listMeta: *meta, Generated: time.Now(), Groups: l, Dirs: usageByDir(l)}
+	for _, g := range l {
+		r.Files += len(g.Files)
+		if len(g.Files) > 1 {
+			r.Dups += len(g.Files) - 1
+			r.Wasted += g.Size * int64(len(g.Files)-1)
+		}
+	}
+	bw := bufio.NewWriter(w)
+	t := template.Must(template.New("report").Funcs(reportFuncs).Parse(reportHTML))
+	if err := t.Execute(bw, r); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// usageByDir 按文件所在目录汇总重复文件，可释放的空间多的排在前面
+func usageByDir(l duplicate.GroupList) []dirUsage {
+	dirs := map[string]*dirUsage{}
+	for _, g := range l {
+		for i, f := range g.Files {
+			dir := filepath.Dir(f.Path)
+			u := dirs[dir]
+			if u == nil {
+				u = &dirUsage{Dir: dir}
+				dirs[dir] = u
+			}
+			u.Files += 1
+			u.Bytes += f.Size
+			if i > 0 {
+				u.Wasted += f.Size
+			}
+		}
+	}
+	res := make([]dirUsage, 0, len(dirs))
+	for _, u := range dirs {
+		res = append(res, *u)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Wasted != res[j].Wasted {
+			return res[i].Wasted > res[j].Wasted
+		}
+		return res[i].Dir < res[j].Dir
+	})
+	return res
+}
@@ -0,0 +1,38 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package cmd
+
+import (
+	"duplicate-cleaner/duplicate"
+	"fmt"
+)
+
+// riskyError 文件位于应用程序管理的目录中
+func riskyError(path, app string) error {
+	return &duplicate.PathError{Op: "清理", Path: path, Kind: duplicate.ErrRiskyPath,
+		Err: fmt.Errorf("%w: 位于 %s 管理的目录中，清理可能损坏其数据，确认后请加 -allow-risky", duplicate.ErrRiskyPath, app)}
+}
+
+// warnRisky 列出清单中位于应用程序管理的目录中的文件，清理这些文件需要 -allow-risky
+func warnRisky(l duplicate.GroupList) {
+	n := 0
+	for _, g := range l {
+		for _, f := range g.Files {
+			if app := duplicate.RiskyApp(f.Path); app != "" {
+				fmt.Printf("警告: %s 位于 %s 管理的目录中，删除可能损坏其数据\n", f.Path, app)
+				n += 1
+			}
+		}
+	}
+	if n > 0 {
+		fmt.Printf("共 %d 个文件位于应用程序管理的目录中，清理它们需要加 -allow-risky，建议通过应用程序自身去重\n", n)
+	}
+}
@@ -0,0 +1,149 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package cmd
+
+import (
+	"strings"
+	"unicode"
+)
+
+// cyrillic 西里尔字母的拉丁转写(俄、乌、白、塞尔维亚常用字母)，大写由小写推出
+var cyrillic = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "yo", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu",
+	'я': "ya", 'і': "i", 'ї': "yi", 'є': "ye", 'ґ': "g", 'ў': "u", 'ђ': "dj", 'ј': "j",
+	'љ': "lj", 'њ': "nj", 'ћ': "c", 'џ': "dz",
+}
+
+// greek 希腊字母的拉丁转写
+var greek = map[rune]string{
+	'α': "a", 'β': "v", 'γ': "g", 'δ': "d", 'ε': "e", 'ζ': "z", 'η': "i", 'θ': "th",
+	'ι': "i", 'κ': "k", 'λ': "l", 'μ': "m", 'ν': "n", 'ξ': "x", 'ο': "o", 'π': "p",
+	'ρ': "r", 'σ': "s", 'ς': "s", 'τ': "t", 'υ': "y", 'φ': "f", 'χ': "ch", 'ψ': "ps",
+	'ω': "o", 'ά': "a", 'έ': "e", 'ή': "i", 'ί': "i", 'ό': "o", 'ύ': "y", 'ώ': "o",
+}
+
+// kana 平假名的平文式罗马字，片假名先换算为平假名
+var kana = map[rune]string{
+	'あ': "a", 'い': "i", 'う': "u", 'え': "e", 'お': "o",
+	'か': "ka", 'き': "ki", 'く': "ku", 'け': "ke", 'こ': "ko",
+	'さ': "sa", 'し': "shi", 'す': "su", 'せ': "se", 'そ': "so",
+	'た': "ta", 'ち': "chi", 'つ': "tsu", 'て': "te", 'と': "to",
+	'な': "na", 'に': "ni", 'ぬ': "nu", 'ね': "ne", 'の': "no",
+	'は': "ha", 'ひ': "hi", 'ふ': "fu", 'へ': "he", 'ほ': "ho",
+	'ま': "ma", 'み': "mi", 'む': "mu", 'め': "me", 'も': "mo",
+	'や': "ya", 'ゆ': "yu", 'よ': "yo",
+	'ら': "ra", 'り': "ri", 'る': "ru", 'れ': "re", 'ろ': "ro",
+	'わ': "wa", 'ゐ': "i", 'ゑ': "e", 'を': "o", 'ん': "n",
+	'が': "ga", 'ぎ': "gi", 'ぐ': "gu", 'げ': "ge", 'ご': "go",
+	'ざ': "za", 'じ': "ji", 'ず': "zu", 'ぜ': "ze", 'ぞ': "zo",
+	'だ': "da", 'ぢ': "ji", 'づ': "zu", 'で': "de", 'ど': "do",
+	'ば': "ba", 'び': "bi", 'ぶ': "bu", 'べ': "be", 'ぼ': "bo",
+	'ぱ': "pa", 'ぴ': "pi", 'ぷ': "pu", 'ぺ': "pe", 'ぽ': "po",
+	'ぁ': "a", 'ぃ': "i", 'ぅ': "u", 'ぇ': "e", 'ぉ': "o", 'ゔ': "vu",
+}
+
+// kanaSmall 拗音的小写 や、ゆ、よ
+var kanaSmall = map[rune]string{'ゃ': "a", 'ゅ': "u", 'ょ': "o"}
+
+// 谚文音节的声母、韵母、收音(国语罗马字)
+var (
+	hangulInitial = []string{"g", "kk", "n", "d", "tt", "r", "m", "b", "pp", "s", "ss", "", "j", "jj", "ch", "k", "t", "p", "h"}
+	hangulMedial  = []string{"a", "ae", "ya", "yae", "eo", "e", "yeo", "ye", "o", "wa", "wae", "oe", "yo", "u", "wo", "we", "wi", "yu", "eu", "ui", "i"}
+	hangulFinal   = []string{"", "k", "k", "k", "n", "n", "n", "t", "l", "k", "m", "l", "l", "l", "p", "l", "m", "p", "p", "t", "t", "ng", "t", "t", "k", "t", "p", "t"}
+)
+
+// romanize 将路径中的西里尔字母、希腊字母、日文假名和韩文谚文转写为拉丁字母，
+// 汉字等其他字符保持不变；没有可转写的字符时返回空字符串
+func romanize(s string) string {
+	b := strings.Builder{}
+	changed := false
+	rs := []rune(s)
+	for i := 0; i < len(rs); i++ {
+		r := rs[i]
+		lower := unicode.ToLower(r)
+		// 片假名换算为平假名
+		if r >= 'ァ' && r <= 'ヶ' {
+			lower = r - 0x60
+		}
+		if t, ok := cyrillic[lower]; ok {
+			changed = true
+			b.WriteString(matchCase(r, t))
+			continue
+		}
+		if t, ok := greek[lower]; ok {
+			changed = true
+			b.WriteString(matchCase(r, t))
+			continue
+		}
+		if r >= 0xac00 && r <= 0xd7a3 {
+			changed = true
+			n := int(r - 0xac00)
+			b.WriteString(hangulInitial[n/588] + hangulMedial[n%588/28] + hangulFinal[n%28])
+			continue
+		}
+		if lower == 'っ' {
+			// 促音: 重复下一个音节的首个辅音
+			changed = true
+			if i+1 < len(rs) {
+				next := rs[i+1]
+				if next >= 'ァ' && next <= 'ヶ' {
+					next -= 0x60
+				}
+				if t, ok := kana[next]; ok && t[0] != 'a' && t[0] != 'i' && t[0] != 'u' && t[0] != 'e' && t[0] != 'o' {
+					b.WriteByte(t[0])
+				}
+			}
+			continue
+		}
+		if r == 'ー' {
+			changed = true
+			b.WriteString("-")
+			continue
+		}
+		if t, ok := kana[lower]; ok {
+			changed = true
+			// 拗音: き + ゃ = kya，し + ゃ = sha
+			if i+1 < len(rs) {
+				next := rs[i+1]
+				if next >= 'ァ' && next <= 'ヶ' {
+					next -= 0x60
+				}
+				if v, ok := kanaSmall[next]; ok && strings.HasSuffix(t, "i") && len(t) > 1 {
+					base := strings.TrimSuffix(t, "i")
+					if base != "sh" && base != "ch" && base != "j" {
+						base += "y"
+					}
+					b.WriteString(base + v)
+					i++
+					continue
+				}
+			}
+			b.WriteString(t)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	if !changed {
+		return ""
+	}
+	return b.String()
+}
+
+// matchCase 原字符为大写时首字母大写
+func matchCase(r rune, t string) string {
+	if t == "" || !unicode.IsUpper(r) {
+		return t
+	}
+	return strings.ToUpper(t[:1]) + t[1:]
+}
@@ -0,0 +1,97 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package cmd
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// rotateLayout 归档的历史清单在文件名中附加的时间格式，按名称排序即按时间排序
+const rotateLayout = "20060102-150405"
+
+// rotateReport 将已存在的清单以其修改时间命名并压缩为 <清单>.<时间>.gz，
+// 然后只保留最近的 keep 份历史清单
+func rotateReport(path string, keep int) error {
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	dst := path + "." + info.ModTime().Format(rotateLayout) + ".gz"
+	if err := gzipFile(path, dst); err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	return pruneReports(path, keep)
+}
+
+// gzipFile 将 src 压缩为 dst，先写入临时文件，避免留下不完整的压缩文件
+func gzipFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := createAtomic(dst)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(out)
+	zw.Name = filepath.Base(src)
+	if _, err := io.Copy(zw, in); err != nil {
+		out.Abort()
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		out.Abort()
+		return err
+	}
+	return out.Commit()
+}
+
+// pruneReports 删除超出保留份数的最旧的历史清单
+func pruneReports(path string, keep int) error {
+	matches, err := filepath.Glob(globEscape(path) + ".*.gz")
+	if err != nil {
+		return err
+	}
+	old := []string{}
+	prefix := path + "."
+	for _, m := range matches {
+		stamp := strings.TrimSuffix(strings.TrimPrefix(m, prefix), ".gz")
+		if len(stamp) == len(rotateLayout) {
+			old = append(old, m)
+		}
+	}
+	sort.Strings(old)
+	errs := []error{}
+	for len(old) > keep {
+		errs = append(errs, os.Remove(old[0]))
+		old = old[1:]
+	}
+	return errors.Join(errs...)
+}
+
+// globEscape 转义路径中的通配符
+func globEscape(path string) string {
+	return strings.NewReplacer("*", `\*`, "?", `\?`, "[", `\[`).Replace(path)
+}
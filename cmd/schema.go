@@ -0,0 +1,283 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"duplicate-cleaner/duplicate"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// 可校验的文件类型，与 schema 目录下的文件名对应
+const (
+	schemaList       = "list"       // -l -format json 输出的清单
+	schemaJSONL      = "jsonl"      // -l -format jsonl 输出的清单，NDJSON
+	schemaPlan       = "plan"       // -emit-script json 生成的删除计划
+	schemaChargeback = "chargeback" // -chargeback 输出的 JSON 汇总
+	schemaUndo       = "undo"       // 清理时生成的 <清单>.undo 撤销日志，NDJSON
+	schemaManifest   = "manifest"   // 隔离目录的 manifest.jsonl，NDJSON
+)
+
+var schemaKinds = []string{schemaList, schemaJSONL, schemaPlan, schemaChargeback, schemaUndo, schemaManifest}
+
+//go:embed schema/*.json
+var schemaFS embed.FS
+
+// schemaURL 内嵌 schema 的 $id
+func schemaURL(kind string) string {
+	return "urn:duplicate-cleaner:" + kind
+}
+
+// schemaText 内嵌的 schema 原文
+func schemaText(kind string) ([]byte, error) {
+	return schemaFS.ReadFile("schema/" + kind + ".schema.json")
+}
+
+// compileSchema 编译内嵌的 schema，ptr 为其中的子 schema，如 #/$defs/entry
+func compileSchema(kind, ptr string) (*jsonschema.Schema, error) {
+	c := jsonschema.NewCompiler()
+	c.AssertFormat = true
+	// 只使用内嵌的 schema，不从网络加载
+	c.LoadURL = func(s string) (io.ReadCloser, error) {
+		return nil, fmt.Errorf("不支持加载外部 schema: %s", s)
+	}
+	// schema 之间可以互相引用，全部加入
+	for _, k := range schemaKinds {
+		text, err := schemaText(k)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.AddResource(schemaURL(k), bytes.NewReader(text)); err != nil {
+			return nil, err
+		}
+	}
+	return c.Compile(schemaURL(kind) + ptr)
+}
+
+// printSchema 按 -schema 输出内嵌的 schema
+func printSchema(cfg *Config) error {
+	text, err := schemaText(cfg.schema)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(text)
+	return err
+}
+
+// validate 按 -validate 校验指定的文件，未指定 -schema 时根据内容判断文件类型
+func validate(cfg *Config) error {
+	errs := validateError{}
+	for _, file := range cfg.args {
+		kind, err := validateFile(file, cfg.schema)
+		if err != nil {
+			errs = append(errs, err)
+			fmt.Printf("%s: 未通过校验\n%v\n", file, err)
+			continue
+		}
+		fmt.Printf("%s: 符合 %s 格式\n", file, kind)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validateError 未通过校验的各文件的错误，各文件的错误已逐一输出，只显示汇总，保留原错误用于确定退出码
+type validateError []error
+
+func (e validateError) Error() string {
+	return fmt.Sprintf("%d 个文件未通过校验", len(e))
+}
+
+func (e validateError) Unwrap() []error {
+	return e
+}
+
+// validateFile 校验单个文件，返回其类型，gzip、zstd 压缩的文件先解压
+func validateFile(file, kind string) (string, error) {
+	data, err := readDecompressed(file)
+	if err != nil {
+		return "", err
+	}
+	if kind == "" {
+		if kind = detectSchema(data); kind == "" {
+			return "", errors.New("无法识别文件类型，请用 -schema 指定: " + strings.Join(schemaKinds, " | "))
+		}
+	}
+	switch kind {
+	case schemaJSONL:
+		err := validateLines(data, kind, "#/$defs/header", "#/$defs/line")
+		if !jsonlEnded(data) {
+			err = errors.Join(err, errors.New("缺少结束行，可能是写入时被中断或扫描仍在进行"))
+		}
+		return kind, err
+	case schemaUndo:
+		return kind, validateLines(data, kind, "#/$defs/header", "#/$defs/entry")
+	case schemaManifest:
+		return kind, validateLines(data, kind, "", "")
+	}
+	sch, err := compileSchema(kind, "")
+	if err != nil {
+		return kind, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return kind, fmt.Errorf("无效的 JSON: %v", err)
+	}
+	if dec.More() {
+		return kind, errors.New("无效的 JSON: 文档之后还有多余的内容")
+	}
+	return kind, schemaErrors(sch.Validate(doc), "")
+}
+
+// readDecompressed 读取文件的全部内容，压缩的文件返回解压后的内容
+func readDecompressed(file string) ([]byte, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r, closeReader, err := decompressReader(bufio.NewReader(f))
+	if err != nil {
+		return nil, fmt.Errorf("无法解压文件 %s: %v", file, err)
+	}
+	defer closeReader()
+	return io.ReadAll(r)
+}
+
+// validateLines 逐行校验 NDJSON 文件，first 为第一行使用的子 schema，rest 为其余各行使用的，空行被忽略
+func validateLines(data []byte, kind, first, rest string) error {
+	head, err := compileSchema(kind, first)
+	if err != nil {
+		return err
+	}
+	body, err := compileSchema(kind, rest)
+	if err != nil {
+		return err
+	}
+	errs := []error{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 1<<20)
+	sch, n, lines := head, 0, 0
+	for scanner.Scan() {
+		n += 1
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		lines += 1
+		where := fmt.Sprintf("第 %d 行", n)
+		dec := json.NewDecoder(bytes.NewReader(line))
+		dec.UseNumber()
+		var doc any
+		if err := dec.Decode(&doc); err != nil || dec.More() {
+			errs = append(errs, fmt.Errorf("%s: 无效的 JSON", where))
+		} else if err := schemaErrors(sch.Validate(doc), where); err != nil {
+			errs = append(errs, err)
+		}
+		sch = body
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if lines == 0 {
+		return errors.New("文件为空")
+	}
+	return errors.Join(errs...)
+}
+
+// detectSchema 根据内容判断文件类型，无法判断时返回空
+func detectSchema(data []byte) string {
+	var doc map[string]json.RawMessage
+	if json.Unmarshal(data, &doc) == nil {
+		switch {
+		case doc["groups"] != nil:
+			return schemaList
+		case doc["items"] != nil:
+			return schemaPlan
+		case doc["accounts"] != nil:
+			return schemaChargeback
+		}
+		// 只有一行的 NDJSON 也能整体解析，继续按行判断
+	}
+	first, rest, _ := bytes.Cut(bytes.TrimSpace(data), []byte("\n"))
+	second, _, _ := bytes.Cut(bytes.TrimSpace(rest), []byte("\n"))
+	doc = nil
+	if json.Unmarshal(first, &doc) != nil {
+		return ""
+	}
+	var next map[string]json.RawMessage
+	json.Unmarshal(second, &next)
+	switch {
+	case doc["stored"] != nil:
+		return schemaManifest
+	case doc["action"] != nil, doc["version"] != nil && next["action"] != nil:
+		return schemaUndo
+	case doc["version"] != nil && (doc["roots"] != nil || next["files"] != nil || next["end"] != nil):
+		return schemaJSONL
+	case doc["version"] != nil && len(doc) == 1:
+		return schemaUndo
+	}
+	return ""
+}
+
+// jsonlEnded JSON Lines 清单的最后一行是否为结束行
+func jsonlEnded(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	end := jsonlEnd{}
+	return json.Unmarshal(data[bytes.LastIndexByte(data, '\n')+1:], &end) == nil && end.End
+}
+
+// schemaErrors 将校验错误展开为每个不符合之处一行，version 不符时归为版本不兼容
+func schemaErrors(err error, where string) error {
+	ve, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return err
+	}
+	errs := []error{}
+	var walk func(ve *jsonschema.ValidationError)
+	walk = func(ve *jsonschema.ValidationError) {
+		if len(ve.Causes) > 0 {
+			for _, c := range ve.Causes {
+				walk(c)
+			}
+			return
+		}
+		loc := ve.InstanceLocation
+		if loc == "" {
+			loc = "/"
+		}
+		if where != "" {
+			loc = where + " " + loc
+		}
+		err := fmt.Errorf("%s: %s", loc, ve.Message)
+		if ve.InstanceLocation == "/version" {
+			err = fmt.Errorf("%w: %s: %s", duplicate.ErrVersion, loc, ve.Message)
+		}
+		if !slices.ContainsFunc(errs, func(e error) bool { return e.Error() == err.Error() }) {
+			errs = append(errs, err)
+		}
+	}
+	walk(ve)
+	return errors.Join(errs...)
+}
@@ -0,0 +1,94 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package cmd
+
+import (
+	"duplicate-cleaner/duplicate"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// screen 用索引中的大小和快速签名预筛新文件，只有可能重复的文件才计算完整Hash值确认
+func screen(cfg *Config) error {
+	f, err := os.Open(cfg.checkpoint)
+	if err != nil {
+		return err
+	}
+	idx, err := duplicate.LoadHashIndex(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	files := []string{}
+	for _, arg := range cfg.args {
+		err := filepath.WalkDir(arg, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.Type().IsRegular() {
+				abs, _ := filepath.Abs(path)
+				files = append(files, abs)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	errs := []error{}
+	newFiles, hashed := 0, 0
+	for _, file := range files {
+		dup, full, err := screenFile(idx, file)
+		switch {
+		case err != nil:
+			fmt.Printf("FAIL\t%s\t%v\n", file, err)
+			errs = append(errs, err)
+		case dup != "":
+			fmt.Printf("DUP\t%s\t%s\n", file, dup)
+		default:
+			fmt.Printf("NEW\t%s\n", file)
+			newFiles += 1
+		}
+		if full {
+			hashed += 1
+		}
+	}
+	fmt.Printf("预筛 %d 个文件，新文件 %d 个，计算完整Hash值 %d 个\n", len(files), newFiles, hashed)
+	return errors.Join(errs...)
+}
+
+// screenFile 返回与文件内容相同的已索引文件，以及是否计算了完整Hash值
+func screenFile(idx *duplicate.HashIndex, file string) (string, bool, error) {
+	candidates, err := idx.Candidates(file)
+	if err != nil || len(candidates) == 0 {
+		return "", false, err
+	}
+	sum, err := duplicate.HashFile(file, idx.Hash())
+	if err != nil {
+		return "", true, err
+	}
+	for _, c := range candidates {
+		// 其他算法算出的Hash值无法比较，按索引的当前算法重新计算
+		h := c.Hash
+		if h == "" || c.Algo != idx.Hash() {
+			if h, err = duplicate.HashFile(c.Path, idx.Hash()); err != nil {
+				continue
+			}
+		}
+		if h == sum {
+			return c.Path, true, nil
+		}
+	}
+	return "", true, nil
+}
@@ -0,0 +1,176 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	scriptShell      = "sh"
+	scriptPowerShell = "powershell"
+	scriptJSON       = "json" // 供 -apply 执行的结构化计划
+)
+
+var scriptKinds = []string{scriptShell, scriptPowerShell, scriptJSON}
+
+// emitScript 将删除清单转换为删除计划，以 shell、PowerShell 脚本或 JSON 输出到屏幕，不删除任何文件。
+// 脚本在删除每个文件前检查它仍是大小不变的普通文件，使用保留策略时还检查同组保留的副本仍然存在
+func emitScript(cfg *Config) error {
+	plan, err := buildPlan(cfg)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(os.Stdout)
+	header := fmt.Sprintf("由 duplicate-cleaner 根据 %s 生成于 %s，共 %d 个文件 %s",
+		strings.Join(plan.Lists, " "), plan.Created.Format(time.DateTime), len(plan.Items), formatSize(plan.total()))
+	switch cfg.emitScript {
+	case scriptPowerShell:
+		writePowerShell(w, header, plan.Items)
+	case scriptJSON:
+		if err := writePlan(w, plan); err != nil {
+			return err
+		}
+	default:
+		writeShell(w, header, plan.Items)
+	}
+	return w.Flush()
+}
+
+// writeShell 输出 POSIX shell 脚本
+func writeShell(w io.Writer, header string, items []planItem) {
+	fmt.Fprintf(w, `#!/bin/sh
+# %s
+# 请审阅后执行。每个文件删除前检查: 仍是普通文件、大小与清单一致、同组保留的副本仍然存在
+set -u
+failed=0
+skip() {
+	printf '跳过(%%s): %%s\n' "$1" "$2" >&2
+	failed=$((failed + 1))
+}
+# remove 大小 路径 [保留的文件...]
+remove() {
+	size=$1
+	f=$2
+	shift 2
+	if [ ! -f "$f" ] || [ -L "$f" ]; then
+		skip "不存在或不是普通文件" "$f"
+		return
+	fi
+	if [ "$(wc -c <"$f" | tr -d ' ')" != "$size" ]; then
+		skip "大小已变化" "$f"
+		return
+	fi
+	if [ $# -gt 0 ]; then
+		kept=0
+		for k in "$@"; do
+			if [ -f "$k" ] && [ "$(wc -c <"$k" | tr -d ' ')" = "$size" ]; then
+				kept=1
+				break
+			fi
+		done
+		if [ $kept -eq 0 ]; then
+			skip "保留的副本已不存在" "$f"
+			return
+		fi
+	fi
+	if rm -f -- "$f"; then
+		printf '已删除: %%s\n' "$f"
+	else
+		skip "删除失败" "$f"
+	fi
+}
+
+`, header)
+	for _, it := range items {
+		if it.Risk != "" {
+			fmt.Fprintf(w, "# 警告: 以下文件位于 %s 管理的目录中\n", it.Risk)
+		}
+		fmt.Fprintf(w, "remove %d %s", it.Size, shellQuote(it.Path))
+		for _, k := range it.Keep {
+			fmt.Fprintf(w, " %s", shellQuote(k))
+		}
+		fmt.Fprintln(w)
+	}
+	io.WriteString(w, `
+printf '完成，跳过 %s 个文件\n' "$failed"
+[ "$failed" -eq 0 ]
+`)
+}
+
+// writePowerShell 输出 PowerShell 脚本，带 UTF-8 BOM 以便 Windows PowerShell 5 正确读取中文
+func writePowerShell(w io.Writer, header string, items []planItem) {
+	fmt.Fprintf(w, "\ufeff# %s\r\n", header)
+	fmt.Fprint(w, strings.ReplaceAll(`# 请审阅后执行。每个文件删除前检查: 仍是普通文件、大小与清单一致、同组保留的副本仍然存在
+$failed = 0
+function Skip-File([string]$Reason, [string]$Path) {
+    Write-Warning ('跳过({0}): {1}' -f $Reason, $Path)
+    $script:failed++
+}
+function Test-Copy([string]$Path, [long]$Size) {
+    $item = Get-Item -LiteralPath $Path -Force -ErrorAction SilentlyContinue
+    return $item -and -not $item.PSIsContainer -and -not $item.LinkType -and $item.Length -eq $Size
+}
+function Remove-Duplicate([long]$Size, [string]$Path, [string[]]$Keep) {
+    if (-not (Test-Copy $Path $Size)) {
+        if (Test-Path -LiteralPath $Path -PathType Leaf) { Skip-File '大小已变化或是链接' $Path } else { Skip-File '不存在或不是普通文件' $Path }
+        return
+    }
+    if ($Keep.Count -gt 0 -and -not ($Keep | Where-Object { Test-Copy $_ $Size })) {
+        Skip-File '保留的副本已不存在' $Path
+        return
+    }
+    try {
+        Remove-Item -LiteralPath $Path -Force -ErrorAction Stop
+        Write-Output ('已删除: {0}' -f $Path)
+    } catch {
+        Skip-File ('删除失败 {0}' -f $_) $Path
+    }
+}
+
+`, "\n", "\r\n"))
+	for _, it := range items {
+		if it.Risk != "" {
+			fmt.Fprintf(w, "# 警告: 以下文件位于 %s 管理的目录中\r\n", it.Risk)
+		}
+		keep := make([]string, 0, len(it.Keep))
+		for _, k := range it.Keep {
+			keep = append(keep, psQuote(k))
+		}
+		fmt.Fprintf(w, "Remove-Duplicate %d %s @(%s)\r\n", it.Size, psQuote(it.Path), strings.Join(keep, ", "))
+	}
+	fmt.Fprint(w, "\r\nWrite-Output ('完成，跳过 {0} 个文件' -f $failed)\r\nif ($failed -gt 0) { exit 1 }\r\n")
+}
+
+// shellQuote 用单引号包围，内部的单引号先结束引用、转义后再重新开始引用
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// psQuote 用单引号包围，PowerShell 把弯引号也当作单引号，一并重复一次进行转义
+func psQuote(s string) string {
+	var b strings.Builder
+	b.WriteByte('\'')
+	for _, r := range s {
+		switch r {
+		case '\'', '‘', '’', '‚', '‛':
+			b.WriteRune(r)
+		}
+		b.WriteRune(r)
+	}
+	b.WriteByte('\'')
+	return b.String()
+}
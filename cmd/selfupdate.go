@@ -0,0 +1,401 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// updateRepo 发布版本所在的 GitHub 仓库
+const updateRepo = "afrusrsc/duplicate-cleaner"
+
+// 发布版本中的校验文件：SHA256SUMS 为 sha256sum 格式的各平台程序和 VERSION 的校验和，SHA256SUMS.sig 为对它的 Ed25519 签名，
+// VERSION 的内容为版本号(如 v1.2.0)，经签名的校验和确认，防止旧版本被冒充为最新版本用于回退攻击
+const (
+	updateSums    = "SHA256SUMS"
+	updateSig     = "SHA256SUMS.sig"
+	updateVersion = "VERSION"
+)
+
+// updateKey 发布签名的 Ed25519 公钥(base64)，构建发布版本时用
+// -ldflags "-X duplicate-cleaner/cmd.updateKey=..." 写入，自行编译的程序为空，不能自动更新
+var updateKey = ""
+
+// maxUpdateSize 下载的程序文件的大小上限
+const maxUpdateSize = 256 << 20
+
+// selfUpdate 下载最新发布版本中本平台的程序，校验签名和校验和后原子地替换当前程序
+func selfUpdate(cfg *Config) error {
+	key, err := updatePublicKey()
+	if err != nil {
+		return err
+	}
+	assets, tag, err := releaseAssets(cfg.updateURL)
+	if err != nil {
+		return fmt.Errorf("获取发布版本失败: %v", err)
+	}
+	sums, err := download(assets[updateSums], 1<<20)
+	if err != nil {
+		return fmt.Errorf("下载 %s 失败: %v", updateSums, err)
+	}
+	sig, err := download(assets[updateSig], 1<<10)
+	if err != nil {
+		return fmt.Errorf("下载 %s 失败: %v", updateSig, err)
+	}
+	if !ed25519.Verify(key, sums, decodeSignature(sig)) {
+		return fmt.Errorf("%s 的签名无效，已放弃更新", updateSums)
+	}
+	ver, err := releaseVersion(assets[updateVersion], sums)
+	if err != nil {
+		return err
+	}
+	if tag != "" && tag != ver {
+		return fmt.Errorf("发布版本的标签 %s 与签名的版本 %s 不符，已放弃更新", tag, ver)
+	}
+	tag = ver
+	cur := currentBuild().Version
+	c, ok := compareVersions(ver, cur)
+	switch {
+	case ok && c == 0:
+		fmt.Println("已是最新版本 " + ver)
+		return nil
+	case (!ok || c < 0) && !cfg.downgrade:
+		if !ok {
+			return fmt.Errorf("无法比较当前版本 %s 与发布版本 %s，确认要安装时请加 -allow-downgrade", cur, ver)
+		}
+		return fmt.Errorf("发布版本 %s 比当前版本 %s 旧，已放弃更新，确认要回退时请加 -allow-downgrade", ver, cur)
+	}
+	name := updateAssetName()
+	want, ok := lookupSum(sums, name)
+	if !ok {
+		return fmt.Errorf("发布版本中没有本平台(%s/%s)的程序 %s", runtime.GOOS, runtime.GOARCH, name)
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return err
+	}
+	if cur, err := fileSHA256(exe); err == nil && cur == want {
+		fmt.Println("已是最新版本 " + tag)
+		return nil
+	}
+	info, err := os.Stat(exe)
+	if err != nil {
+		return err
+	}
+	// 临时文件与程序位于同一目录，才能原子地改名替换
+	tmp, err := os.CreateTemp(filepath.Dir(exe), "."+filepath.Base(exe)+".update-*")
+	if err != nil {
+		return fmt.Errorf("无法在程序所在目录创建临时文件: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	if err := downloadTo(tmp, assets[name], want); err != nil {
+		tmp.Close()
+		return fmt.Errorf("下载 %s 失败: %v", name, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()); err != nil {
+		return err
+	}
+	if err := replaceExecutable(tmp.Name(), exe); err != nil {
+		return fmt.Errorf("替换程序 %s 失败: %v", exe, err)
+	}
+	fmt.Printf("已从 %s 更新到 %s: %s\n", currentBuild().Version, tag, exe)
+	return nil
+}
+
+// updatePublicKey 内置的发布签名公钥
+func updatePublicKey() (ed25519.PublicKey, error) {
+	if updateKey == "" {
+		return nil, errors.New("此程序未内置发布签名公钥(可能是自行编译的)，无法校验更新，请手动下载新版本")
+	}
+	key, err := base64.StdEncoding.DecodeString(updateKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, errors.New("内置的发布签名公钥无效")
+	}
+	return key, nil
+}
+
+// releaseAssets 返回发布版本中各文件的下载地址和版本标签。base 为空时查询 GitHub 上的最新版本，
+// 否则 base 为镜像目录的 https 地址，其中直接存放各文件，此时没有版本标签
+func releaseAssets(base string) (map[string]string, string, error) {
+	if base != "" {
+		base = strings.TrimRight(base, "/")
+		assets := map[string]string{}
+		for _, name := range []string{updateSums, updateSig, updateVersion, updateAssetName()} {
+			assets[name] = base + "/" + name
+		}
+		return assets, "", nil
+	}
+	data, err := download("https://api.github.com/repos/"+updateRepo+"/releases/latest", 1<<20)
+	if err != nil {
+		return nil, "", err
+	}
+	release := struct {
+		Tag    string `json:"tag_name"`
+		Assets []struct {
+			Name string `json:"name"`
+			URL  string `json:"browser_download_url"`
+		} `json:"assets"`
+	}{}
+	if err := json.Unmarshal(data, &release); err != nil {
+		return nil, "", err
+	}
+	assets := map[string]string{}
+	for _, a := range release.Assets {
+		assets[a.Name] = a.URL
+	}
+	if assets[updateSums] == "" || assets[updateSig] == "" || assets[updateVersion] == "" {
+		return nil, "", fmt.Errorf("版本 %s 缺少 %s、%s 或 %s", release.Tag, updateSums, updateSig, updateVersion)
+	}
+	return assets, release.Tag, nil
+}
+
+// updateAssetName 本平台的程序在发布版本中的文件名
+func updateAssetName() string {
+	name := fmt.Sprintf("duplicate-cleaner_%s_%s", runtime.GOOS, runtime.GOARCH)
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// decodeSignature 签名可以是 64 字节的原始签名，也可以是其 base64 文本
+func decodeSignature(sig []byte) []byte {
+	if len(sig) == ed25519.SignatureSize {
+		return sig
+	}
+	if b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig))); err == nil {
+		return b
+	}
+	return sig
+}
+
+// lookupSum 在 sha256sum 格式的校验和中查找文件，文件名前可以有表示二进制模式的 *
+func lookupSum(sums []byte, name string) (string, bool) {
+	scanner := bufio.NewScanner(bytes.NewReader(sums))
+	for scanner.Scan() {
+		sum, file, ok := strings.Cut(strings.TrimSpace(scanner.Text()), " ")
+		if ok && strings.TrimLeft(strings.TrimSpace(file), "*") == name {
+			return strings.ToLower(sum), true
+		}
+	}
+	return "", false
+}
+
+// releaseVersion 下载 VERSION，按已验证签名的校验和核对后返回其中的版本号
+func releaseVersion(url string, sums []byte) (string, error) {
+	want, ok := lookupSum(sums, updateVersion)
+	if !ok {
+		return "", fmt.Errorf("%s 中没有 %s 的校验和，无法确认发布版本的版本号", updateSums, updateVersion)
+	}
+	data, err := download(url, 256)
+	if err != nil {
+		return "", fmt.Errorf("下载 %s 失败: %v", updateVersion, err)
+	}
+	h := sha256.Sum256(data)
+	if hex.EncodeToString(h[:]) != want {
+		return "", fmt.Errorf("%s 的校验和不符，已放弃更新", updateVersion)
+	}
+	ver := strings.TrimSpace(string(data))
+	if _, ok := parseVersion(ver); !ok {
+		return "", fmt.Errorf("%s 中的版本号 %q 无效", updateVersion, ver)
+	}
+	return ver, nil
+}
+
+// semver 解析后的版本号
+type semver struct {
+	nums [3]int
+	pre  []string // 预发布标识，如 rc.1
+}
+
+// parseVersion 解析 v1.2.3、v1.2.3-rc.1 形式的版本号，忽略 + 之后的构建信息
+func parseVersion(v string) (semver, bool) {
+	s := semver{}
+	v, _, _ = strings.Cut(v, "+")
+	if !strings.HasPrefix(v, "v") {
+		return s, false
+	}
+	core, pre, hasPre := strings.Cut(v[1:], "-")
+	parts := strings.Split(core, ".")
+	if len(parts) != 3 {
+		return s, false
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return s, false
+		}
+		s.nums[i] = n
+	}
+	if hasPre {
+		if pre == "" {
+			return s, false
+		}
+		s.pre = strings.Split(pre, ".")
+	}
+	return s, true
+}
+
+// compareVersions 按语义化版本的规则比较 a 和 b，返回 -1、0 或 1，任一无法解析时返回 false
+func compareVersions(a, b string) (int, bool) {
+	x, ok := parseVersion(a)
+	y, ok2 := parseVersion(b)
+	if !ok || !ok2 {
+		return 0, false
+	}
+	for i := range x.nums {
+		if c := cmp.Compare(x.nums[i], y.nums[i]); c != 0 {
+			return c, true
+		}
+	}
+	// 没有预发布标识的版本较新，否则逐项比较，数字按大小比较且小于非数字
+	switch {
+	case len(x.pre) == 0 && len(y.pre) == 0:
+		return 0, true
+	case len(x.pre) == 0:
+		return 1, true
+	case len(y.pre) == 0:
+		return -1, true
+	}
+	for i := 0; i < len(x.pre) && i < len(y.pre); i++ {
+		m, errM := strconv.Atoi(x.pre[i])
+		n, errN := strconv.Atoi(y.pre[i])
+		c := 0
+		switch {
+		case errM == nil && errN == nil:
+			c = cmp.Compare(m, n)
+		case errM == nil:
+			c = -1
+		case errN == nil:
+			c = 1
+		default:
+			c = strings.Compare(x.pre[i], y.pre[i])
+		}
+		if c != 0 {
+			return c, true
+		}
+	}
+	return cmp.Compare(len(x.pre), len(y.pre)), true
+}
+
+// updateClient 只允许 https 的下载客户端，重定向到其他协议时同样拒绝
+func updateClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout: timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if req.URL.Scheme != "https" {
+				return fmt.Errorf("拒绝重定向到非 https 地址 %s", req.URL.Redacted())
+			}
+			if len(via) >= 10 {
+				return errors.New("重定向次数过多")
+			}
+			return nil
+		},
+	}
+}
+
+// checkHTTPS 下载地址必须是 https
+func checkHTTPS(url string) error {
+	if url == "" {
+		return errors.New("发布版本中没有该文件")
+	}
+	if !strings.HasPrefix(strings.ToLower(url), "https://") {
+		return fmt.Errorf("拒绝从非 https 地址 %s 下载", url)
+	}
+	return nil
+}
+
+// download 下载不超过 limit 字节的小文件
+func download(url string, limit int64) ([]byte, error) {
+	if err := checkHTTPS(url); err != nil {
+		return nil, err
+	}
+	resp, err := updateClient(30 * time.Second).Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return nil, errors.New(resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, errors.New("文件过大")
+	}
+	return data, nil
+}
+
+// downloadTo 下载程序文件并核对 SHA-256 校验和
+func downloadTo(w io.Writer, url string, want string) error {
+	if err := checkHTTPS(url); err != nil {
+		return err
+	}
+	resp, err := updateClient(10 * time.Minute).Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return errors.New(resp.Status)
+	}
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(w, h), io.LimitReader(resp.Body, maxUpdateSize+1))
+	if err != nil {
+		return err
+	}
+	if n > maxUpdateSize {
+		return errors.New("文件过大")
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != want {
+		return fmt.Errorf("校验和不符(期望 %s，实际 %s)，已放弃更新", want, got)
+	}
+	return nil
+}
+
+// fileSHA256 计算文件的 SHA-256 校验和
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,30 @@
+//go:build windows
+
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package cmd
+
+import "os"
+
+// replaceExecutable 正在运行的程序不能被覆盖，但可以改名：先把它改名为 .old，再把新程序改名到原位置，
+// 失败时恢复原程序；.old 在下次更新时删除
+func replaceExecutable(tmp, exe string) error {
+	old := exe + ".old"
+	os.Remove(old)
+	if err := os.Rename(exe, old); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, exe); err != nil {
+		os.Rename(old, exe)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package cmd
+
+import (
+	"duplicate-cleaner/cmd/tui"
+	"duplicate-cleaner/duplicate"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+)
+
+const sessionVersion = 1
+
+// reviewSession -session 保存的审阅进度
+type reviewSession struct {
+	Version  int       `json:"version"`
+	Saved    time.Time `json:"saved"`
+	Lists    []string  `json:"lists"`            // 审阅的清单，绝对路径
+	Cursor   string    `json:"cursor,omitempty"` // 光标所在的组，以组中第一个文件的路径标识
+	Reviewed []string  `json:"reviewed"`         // 已审阅的组
+	Marked   []string  `json:"marked"`           // 标记为删除的文件
+}
+
+// absPaths 转换为绝对路径，用于比较会话对应的清单
+func absPaths(files []string) []string {
+	abs := make([]string, 0, len(files))
+	for _, f := range files {
+		if a, err := filepath.Abs(f); err == nil {
+			f = a
+		}
+		abs = append(abs, f)
+	}
+	return abs
+}
+
+// loadSession 读取审阅进度，文件不存在时返回空的进度；会话是为其他清单保存的时返回错误
+func loadSession(path string, lists []string) (*tui.Session, error) {
+	s := &tui.Session{Marked: map[string]bool{}, Reviewed: map[string]bool{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	rs := reviewSession{}
+	if err := json.Unmarshal(data, &rs); err != nil {
+		return nil, fmt.Errorf("无效的会话文件 %s: %v", path, err)
+	}
+	if err := duplicate.CheckVersion("会话文件", rs.Version, sessionVersion, sessionVersion); err != nil {
+		return nil, err
+	}
+	if !slices.Equal(rs.Lists, absPaths(lists)) {
+		return nil, fmt.Errorf("会话文件 %s 是审阅清单 %v 时保存的，与本次的清单不同", path, rs.Lists)
+	}
+	for _, p := range rs.Marked {
+		s.Marked[p] = true
+	}
+	for _, k := range rs.Reviewed {
+		s.Reviewed[k] = true
+	}
+	s.Cursor = rs.Cursor
+	return s, nil
+}
+
+// saveSession 以原子方式保存审阅进度
+func saveSession(path string, lists []string, s *tui.Session) error {
+	rs := reviewSession{Version: sessionVersion, Saved: time.Now(), Lists: absPaths(lists), Cursor: s.Cursor,
+		Reviewed: []string{}, Marked: []string{}}
+	for k, ok := range s.Reviewed {
+		if ok {
+			rs.Reviewed = append(rs.Reviewed, k)
+		}
+	}
+	for p, ok := range s.Marked {
+		if ok {
+			rs.Marked = append(rs.Marked, p)
+		}
+	}
+	sort.Strings(rs.Reviewed)
+	sort.Strings(rs.Marked)
+	f, err := createAtomic(path)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(rs); err != nil {
+		f.Abort()
+		return err
+	}
+	return f.Commit()
+}
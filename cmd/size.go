@@ -0,0 +1,77 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// byteSize 支持 K、M、G、T 单位(按1024进位)的字节数参数
+type byteSize int64
+
+func (b *byteSize) String() string {
+	return formatSize(int64(*b))
+}
+
+func (b *byteSize) Set(s string) error {
+	n, err := parseSize(s)
+	if err != nil {
+		return err
+	}
+	*b = byteSize(n)
+	return nil
+}
+
+// parseSize 解析如 512、10K、2.5G、1TB 形式的大小
+func parseSize(s string) (int64, error) {
+	str := strings.ToUpper(strings.TrimSpace(s))
+	str = strings.TrimSuffix(strings.TrimSuffix(str, "IB"), "B")
+	unit := int64(1)
+	if n := len(str); n > 0 {
+		switch str[n-1] {
+		case 'K':
+			unit = 1 << 10
+		case 'M':
+			unit = 1 << 20
+		case 'G':
+			unit = 1 << 30
+		case 'T':
+			unit = 1 << 40
+		}
+		if unit > 1 {
+			str = str[:n-1]
+		}
+	}
+	v, err := strconv.ParseFloat(strings.TrimSpace(str), 64)
+	// NaN、Inf 和超出 int64 的值转换后会变成负数或 0，后者表示不限制，必须拒绝
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) || v < 0 || v*float64(unit) >= math.MaxInt64 {
+		return 0, fmt.Errorf("无效的大小: %s", s)
+	}
+	return int64(v * float64(unit)), nil
+}
+
+// formatSize 将字节数格式化为易读的形式
+func formatSize(n int64) string {
+	const units = "KMGT"
+	if n < 1024 {
+		return fmt.Sprintf("%dB", n)
+	}
+	v := float64(n)
+	i := -1
+	for v >= 1024 && i < len(units)-1 {
+		v /= 1024
+		i++
+	}
+	return fmt.Sprintf("%.1f%cB", v, units[i])
+}
@@ -0,0 +1,135 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package cmd
+
+import (
+	"duplicate-cleaner/duplicate"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// 清单的拆分方式
+const (
+	splitRoot      = "root"
+	splitExt       = "ext"
+	splitSizeClass = "sizeclass"
+)
+
+var splitKinds = []string{splitRoot, splitExt, splitSizeClass}
+
+// sizeClasses 按组内单个文件大小划分的档位，name 为拆分后文件名中的键
+var sizeClasses = []struct {
+	name  string
+	limit int64 // 不含上限，0 表示不限
+}{
+	{"0-1M", 1 << 20},
+	{"1M-100M", 100 << 20},
+	{"100M-1G", 1 << 30},
+	{"1G+", 0},
+}
+
+// splitList 按拆分方式将清单分为多份，组内文件涉及多个键时整组出现在每一份中
+func splitList(by string, roots []rootInfo, l duplicate.GroupList) map[string]duplicate.GroupList {
+	paths := make([]string, 0, len(roots))
+	for _, r := range roots {
+		paths = append(paths, r.Path)
+	}
+	// 嵌套的扫描路径按最长的匹配
+	sort.Slice(paths, func(i, j int) bool { return len(paths[i]) > len(paths[j]) })
+
+	parts := map[string]duplicate.GroupList{}
+	for _, g := range l {
+		keys := map[string]bool{}
+		switch by {
+		case splitSizeClass:
+			keys[sizeClass(g.Size)] = true
+		default:
+			for _, f := range g.Files {
+				if by == splitRoot {
+					keys[splitRootKey(f.Path, paths)] = true
+				} else {
+					keys[splitExtKey(f.Path)] = true
+				}
+			}
+		}
+		for k := range keys {
+			parts[k] = append(parts[k], g)
+		}
+	}
+	return parts
+}
+
+// sizeClass 返回文件大小所在的档位
+func sizeClass(size int64) string {
+	for _, c := range sizeClasses {
+		if c.limit == 0 || size < c.limit {
+			return c.name
+		}
+	}
+	return ""
+}
+
+// splitRootKey 返回文件所在扫描路径的文件名形式，不在任何扫描路径下时为 other
+func splitRootKey(path string, roots []string) string {
+	for _, r := range roots {
+		if duplicate.IsWithin(path, r) || strings.HasPrefix(path, r+duplicate.ArchiveSep) {
+			name := strings.Map(func(c rune) rune {
+				if c == '/' || c == '\\' || c == ':' {
+					return '_'
+				}
+				return c
+			}, r)
+			if name = strings.Trim(name, "_"); name == "" {
+				return "root"
+			}
+			return name
+		}
+	}
+	return "other"
+}
+
+// splitExtKey 返回小写的扩展名，无扩展名时为 noext
+func splitExtKey(path string) string {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+	if ext == "" {
+		return "noext"
+	}
+	return ext
+}
+
+// splitFileName 在清单文件名的扩展名(含压缩扩展名)前插入键，如 list.txt.gz -> list.<键>.txt.gz
+func splitFileName(out string, key string) string {
+	ext := filepath.Ext(out)
+	if e := strings.ToLower(ext); e == ".gz" || e == ".zst" {
+		ext = filepath.Ext(strings.TrimSuffix(out, ext)) + ext
+	}
+	return strings.TrimSuffix(out, ext) + "." + key + ext
+}
+
+// writeSplit 按拆分方式输出多份清单，各份不在屏幕上显示
+func writeSplit(out string, format string, by string, meta *listMeta, l duplicate.GroupList) error {
+	parts := splitList(by, meta.Roots, l)
+	keys := make([]string, 0, len(parts))
+	for k := range parts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		f := splitFileName(out, k)
+		if err := writeListFile(f, format, meta, parts[k], false); err != nil {
+			return fmt.Errorf("输出拆分清单 %s 失败: %v", f, err)
+		}
+		fmt.Printf("拆分清单: %s (%d 组)\n", f, len(parts[k]))
+	}
+	return nil
+}
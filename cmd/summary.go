@@ -0,0 +1,76 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package cmd
+
+import (
+	"duplicate-cleaner/duplicate"
+	"sort"
+	"sync"
+	"time"
+)
+
+// summary 一次扫描的汇总，用于摘要邮件等通知
+type summary struct {
+	m sync.Mutex
+
+	Roots    []string
+	Started  time.Time
+	Duration time.Duration
+	Files    int   // 扫描到的文件数
+	Bytes    int64 // 扫描到的文件总大小
+	Groups   int   // 重复的组数
+	Dups     int   // 可清理的重复副本数
+	Wasted   int64 // 可释放的空间
+	Partial  bool
+	Report   string // 完整清单的位置
+	Latin    bool   // 为含西里尔字母、假名等的路径附上拉丁转写
+	URI      bool   // HTML 摘要中的路径改为 file:// 链接
+	Top      duplicate.GroupList
+}
+
+func newSummary(roots []rootInfo) *summary {
+	s := &summary{Started: time.Now()}
+	for _, r := range roots {
+		s.Roots = append(s.Roots, r.Path)
+	}
+	return s
+}
+
+// Attach 挂接到扫描选项的回调上统计扫描到的文件，原有回调仍会被调用
+func (s *summary) Attach(opts *duplicate.Options) {
+	onScanned := opts.OnFileScanned
+	opts.OnFileScanned = func(f duplicate.FileInfo) {
+		s.m.Lock()
+		s.Files += 1
+		s.Bytes += f.Size
+		s.m.Unlock()
+		if onScanned != nil {
+			onScanned(f)
+		}
+	}
+}
+
+// Finish 根据重复清单补全汇总，top 为按可释放空间排在前面的组数
+func (s *summary) Finish(l duplicate.GroupList, top int) {
+	s.Duration = time.Since(s.Started).Round(time.Second)
+	s.Groups = len(l)
+	for _, g := range l {
+		s.Dups += len(g.Files) - 1
+		s.Wasted += int64(len(g.Files)-1) * g.Size
+	}
+	s.Top = append(duplicate.GroupList{}, l...)
+	sort.SliceStable(s.Top, func(i, j int) bool {
+		return int64(len(s.Top[i].Files)-1)*s.Top[i].Size > int64(len(s.Top[j].Files)-1)*s.Top[j].Size
+	})
+	if len(s.Top) > top {
+		s.Top = s.Top[:top]
+	}
+}
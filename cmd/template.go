@@ -0,0 +1,81 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package cmd
+
+import (
+	"duplicate-cleaner/duplicate"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// templateFuncs 模板中可用的函数
+var templateFuncs = template.FuncMap{
+	"sh":   shellQuote,    // 按 POSIX shell 的规则加引号
+	"ps":   psQuote,       // 按 PowerShell 的规则加引号
+	"size": formatSize,    // 易读的大小，如 1.5MB
+	"base": filepath.Base, // 文件名
+	"dir":  filepath.Dir,  // 所在目录
+	"join": strings.Join,  // 用分隔符连接字符串列表
+	"add":  func(a, b int) int { return a + b },
+	"json": func(v any) (string, error) { // JSON 编码，字符串会加上引号
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// templateData 执行模板时的数据，字段与 JSON 清单相同，另有汇总信息
+type templateData struct {
+	listMeta
+	Version int
+	Groups  duplicate.GroupList
+	Files   int   // 各组文件数之和
+	Wasted  int64 // 每组只保留一个文件时可释放的空间
+}
+
+// listTemplate 解析 -template，值中含 {{ 时视为模板内容，否则为模板文件，未指定时返回 nil
+func (cfg *Config) listTemplate() (*template.Template, error) {
+	if cfg.template == "" {
+		return nil, nil
+	}
+	text := cfg.template
+	if !strings.Contains(text, "{{") {
+		b, err := os.ReadFile(text)
+		if err != nil {
+			return nil, fmt.Errorf("无法读取模板文件: %v", err)
+		}
+		text = string(b)
+	}
+	t, err := template.New("list").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("无效的 -template: %v", err)
+	}
+	return t, nil
+}
+
+// writeTemplate 按模板写出清单
+func writeTemplate(w io.Writer, t *template.Template, meta *listMeta, l duplicate.GroupList) error {
+	data := templateData{listMeta: *meta, Version: listVersion, Groups: l}
+	for _, g := range l {
+		data.Files += len(g.Files)
+		if len(g.Files) > 1 {
+			data.Wasted += g.Size * int64(len(g.Files)-1)
+		}
+	}
+	if err := t.Execute(w, data); err != nil {
+		return fmt.Errorf("执行模板失败: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+//go:build !plan9
+
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package tui
+
+import (
+	"duplicate-cleaner/duplicate"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// prompt 底部的单行输入框
+type prompt struct {
+	label  string
+	value  string
+	submit func(string)
+}
+
+// input 处理输入框中的按键，回车提交，Esc 取消
+func (m *model) input(msg tea.KeyMsg) {
+	p := m.prompt
+	switch msg.Type {
+	case tea.KeyEnter:
+		m.prompt = nil
+		p.submit(strings.TrimSpace(p.value))
+	case tea.KeyEsc, tea.KeyCtrlC:
+		m.prompt = nil
+	case tea.KeyBackspace:
+		if r := []rune(p.value); len(r) > 0 {
+			p.value = string(r[:len(r)-1])
+		}
+	case tea.KeyCtrlU:
+		p.value = ""
+	case tea.KeySpace:
+		p.value += " "
+	case tea.KeyRunes:
+		p.value += string(msg.Runes)
+	}
+}
+
+// invert 反选组内的文件，反选后整组都被标记时不执行
+func (m *model) invert(g duplicate.Group) {
+	if m.unmarked(g) == len(g.Files) {
+		m.message = "反选后将删除整组文件，每组至少保留一个文件"
+		return
+	}
+	for _, f := range g.Files {
+		if m.marked[f.Path] {
+			delete(m.marked, f.Path)
+		} else {
+			m.marked[f.Path] = true
+		}
+	}
+}
+
+// applyPolicy 按保留策略标记所有未审阅的组，处理过的组视为已审阅
+func (m *model) applyPolicy(name string) {
+	if name == "" {
+		name = duplicate.KeepFirstName
+	}
+	p, err := duplicate.NewKeepPolicy(name)
+	if err != nil {
+		m.message = err.Error()
+		return
+	}
+	n := 0
+	for _, g := range m.groups {
+		key := groupKey(g)
+		if len(g.Files) == 0 || m.reviewed[key] {
+			continue
+		}
+		keep := p.Keep(g.Files)
+		if !slices.Contains(keep, true) {
+			keep[0] = true
+		}
+		for i, f := range g.Files {
+			if keep[i] {
+				delete(m.marked, f.Path)
+			} else {
+				m.marked[f.Path] = true
+			}
+		}
+		m.reviewed[key] = true
+		n += 1
+	}
+	m.message = fmt.Sprintf("已按 %s 策略处理剩余的 %d 组", name, n)
+}
+
+// markUnder 标记位于 dir 下的全部文件，会使组内不剩未标记的文件时跳过该文件
+func (m *model) markUnder(dir string) {
+	if dir == "" {
+		return
+	}
+	prefix := filepath.Clean(dir)
+	if !strings.HasSuffix(prefix, string(filepath.Separator)) {
+		prefix += string(filepath.Separator)
+	}
+	files, groups, skipped := 0, 0, 0
+	for _, g := range m.groups {
+		hit := false
+		for _, f := range g.Files {
+			if m.marked[f.Path] || !strings.HasPrefix(f.Path, prefix) {
+				continue
+			}
+			if m.unmarked(g) <= 1 {
+				skipped += 1
+				continue
+			}
+			m.marked[f.Path] = true
+			files += 1
+			hit = true
+		}
+		if hit {
+			groups += 1
+		}
+	}
+	m.message = fmt.Sprintf("在 %d 组中标记了 %s 下的 %d 个文件", groups, dir, files)
+	if skipped > 0 {
+		m.message += fmt.Sprintf("，%d 组的副本全在该目录下，各保留了一个", skipped)
+	}
+}
+
+// bulkKey 处理批量操作的按键，返回是否已处理
+func (m *model) bulkKey(k string, g duplicate.Group) bool {
+	switch k {
+	case "i":
+		m.invert(g)
+	case "P":
+		m.prompt = &prompt{
+			label:  fmt.Sprintf("对剩余未审阅的组应用保留策略(%s): ", strings.Join(duplicate.KeepPolicies, " | ")),
+			value:  duplicate.KeepFirstName,
+			submit: m.applyPolicy,
+		}
+	case "D":
+		f := g.Files[0]
+		if m.inGroup {
+			f = g.Files[m.file]
+		}
+		m.prompt = &prompt{label: "标记此目录下的全部副本: ", value: filepath.Dir(f.Path), submit: m.markUnder}
+	default:
+		return false
+	}
+	return true
+}
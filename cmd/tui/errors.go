@@ -0,0 +1,16 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package tui
+
+import "errors"
+
+// ErrCanceled 用户退出而没有确认清理
+var ErrCanceled = errors.New("已退出，未清理任何文件")
@@ -0,0 +1,18 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package tui
+
+// Session 审阅进度，Run 返回时更新为退出时的状态，由调用方保存，下次传入即可继续审阅
+type Session struct {
+	Marked   map[string]bool // 标记为删除的文件
+	Reviewed map[string]bool // 已审阅的组，以组中第一个文件的路径标识
+	Cursor   string          // 光标所在的组
+}
@@ -0,0 +1,449 @@
+//go:build !plan9
+
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+// Package tui 全屏浏览重复文件清单，逐组标记要删除的文件，确认后交由调用方清理
+package tui
+
+import (
+	"duplicate-cleaner/duplicate"
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mattn/go-runewidth"
+)
+
+// 排序方式
+const (
+	sortList   = iota // 清单中的顺序
+	sortWasted        // 按可释放空间从大到小
+)
+
+// groupKey 组的标识，同一文件只会出现在一个组中
+func groupKey(g duplicate.Group) string {
+	if len(g.Files) == 0 {
+		return ""
+	}
+	return g.Files[0].Path
+}
+
+// model 界面状态
+type model struct {
+	groups   duplicate.GroupList
+	order    []int           // 按当前排序方式排列的组下标
+	marked   map[string]bool // 标记为删除的文件
+	reviewed map[string]bool // 已审阅的组
+	sortBy   int
+	save     func(*Session) error // 保存审阅进度，为 nil 时不支持
+
+	group   int  // 当前组在 order 中的位置
+	file    int  // 组内光标，仅在 inGroup 时有效
+	inGroup bool // 是否在查看组内的文件
+	offset  int  // 滚动位置
+
+	width, height int
+	message       string  // 底部的提示，下一次按键后清除
+	confirm       bool    // 正在确认清理
+	prompt        *prompt // 正在输入批量操作的参数，见 bulk.go
+	done          bool    // 已确认清理
+}
+
+// Run 显示全屏界面，返回用户标记并确认删除的文件，未确认就退出时返回 ErrCanceled。
+// 每组至少保留一个未标记的文件。s 不为空时从中恢复审阅进度，返回时更新；
+// save 不为空时可随时按 w 保存进度
+func Run(l duplicate.GroupList, s *Session, save func(*Session) error) (duplicate.FileInfos, error) {
+	if s == nil {
+		s = &Session{}
+	}
+	if s.Marked == nil {
+		s.Marked = map[string]bool{}
+	}
+	if s.Reviewed == nil {
+		s.Reviewed = map[string]bool{}
+	}
+	m := &model{groups: l, marked: s.Marked, reviewed: s.Reviewed, sortBy: sortWasted, save: save}
+	// 清单变化后可能整组都被标记，这样的组取消标记
+	for _, g := range l {
+		if m.unmarked(g) == 0 {
+			m.unmarkGroup(g)
+		}
+	}
+	m.sort()
+	for i, g := range m.order {
+		if groupKey(l[g]) == s.Cursor {
+			m.group = i
+		}
+	}
+	_, err := tea.NewProgram(m, tea.WithAltScreen()).Run()
+	m.session(s)
+	if err != nil {
+		return nil, err
+	}
+	if !m.done {
+		return nil, ErrCanceled
+	}
+	return m.deletions(), nil
+}
+
+// session 将当前状态写入 s
+func (m *model) session(s *Session) {
+	s.Marked, s.Reviewed, s.Cursor = m.marked, m.reviewed, ""
+	if len(m.groups) > 0 {
+		s.Cursor = groupKey(m.current())
+	}
+}
+
+func (m *model) Init() tea.Cmd {
+	return nil
+}
+
+// wasted 删除组中除一个以外的文件后可释放的空间
+func wasted(g duplicate.Group) int64 {
+	if len(g.Files) < 2 {
+		return 0
+	}
+	return g.Size * int64(len(g.Files)-1)
+}
+
+// sort 按当前排序方式重排组，光标仍指向原来的组
+func (m *model) sort() {
+	cur := -1
+	if m.group < len(m.order) {
+		cur = m.order[m.group]
+	}
+	m.order = make([]int, len(m.groups))
+	for i := range m.order {
+		m.order[i] = i
+	}
+	if m.sortBy == sortWasted {
+		sort.SliceStable(m.order, func(i, j int) bool {
+			return wasted(m.groups[m.order[i]]) > wasted(m.groups[m.order[j]])
+		})
+	}
+	for i, g := range m.order {
+		if g == cur {
+			m.group = i
+		}
+	}
+}
+
+// current 当前组
+func (m *model) current() duplicate.Group {
+	return m.groups[m.order[m.group]]
+}
+
+// unmarked 组中未标记的文件数
+func (m *model) unmarked(g duplicate.Group) int {
+	n := 0
+	for _, f := range g.Files {
+		if !m.marked[f.Path] {
+			n += 1
+		}
+	}
+	return n
+}
+
+// deletions 按清单中的顺序返回标记的文件
+func (m *model) deletions() duplicate.FileInfos {
+	files := duplicate.FileInfos{}
+	for _, g := range m.groups {
+		for _, f := range g.Files {
+			if m.marked[f.Path] {
+				files = append(files, f)
+			}
+		}
+	}
+	return files
+}
+
+// total 标记的文件数和大小
+func (m *model) total() (int, int64) {
+	n, size := 0, int64(0)
+	for _, f := range m.deletions() {
+		n += 1
+		size += f.Size
+	}
+	return n, size
+}
+
+// toggle 切换文件的标记，不允许标记组中最后一个未标记的文件
+func (m *model) toggle(g duplicate.Group, f duplicate.FileInfo) {
+	if m.marked[f.Path] {
+		delete(m.marked, f.Path)
+		return
+	}
+	if m.unmarked(g) <= 1 {
+		m.message = "每组至少保留一个文件"
+		return
+	}
+	m.marked[f.Path] = true
+}
+
+// keepOnly 保留组中指定的文件，标记其余文件
+func (m *model) keepOnly(g duplicate.Group, keep int) {
+	for i, f := range g.Files {
+		if i == keep {
+			delete(m.marked, f.Path)
+		} else {
+			m.marked[f.Path] = true
+		}
+	}
+}
+
+// unmarkGroup 取消组中全部文件的标记
+func (m *model) unmarkGroup(g duplicate.Group) {
+	for _, f := range g.Files {
+		delete(m.marked, f.Path)
+	}
+}
+
+// nextUnreviewed 当前组之后第一个未审阅的组在 order 中的位置，到末尾后从头查找，都已审阅时返回 -1
+func (m *model) nextUnreviewed() int {
+	for i := 1; i <= len(m.order); i++ {
+		j := (m.group + i) % len(m.order)
+		if !m.reviewed[groupKey(m.groups[m.order[j]])] {
+			return j
+		}
+	}
+	return -1
+}
+
+func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+	case tea.KeyMsg:
+		m.message = ""
+		if m.prompt != nil {
+			m.input(msg)
+			return m, nil
+		}
+		if m.confirm {
+			m.confirm = false
+			if msg.String() == "y" {
+				m.done = true
+				return m, tea.Quit
+			}
+			return m, nil
+		}
+		return m, m.key(msg.String())
+	}
+	return m, nil
+}
+
+// key 处理按键
+func (m *model) key(k string) tea.Cmd {
+	if len(m.groups) == 0 {
+		if k == "q" || k == "ctrl+c" {
+			return tea.Quit
+		}
+		return nil
+	}
+	g := m.current()
+	n, cur := len(m.order), m.group
+	if m.inGroup {
+		n, cur = len(g.Files), m.file
+	}
+	page := max(m.rows()-1, 1)
+	if m.bulkKey(k, g) {
+		return nil
+	}
+	switch k {
+	case "q", "ctrl+c":
+		return tea.Quit
+	case "up", "k":
+		cur -= 1
+	case "down", "j":
+		cur += 1
+	case "pgup":
+		cur -= page
+	case "pgdown":
+		cur += page
+	case "home", "g":
+		cur = 0
+	case "end", "G":
+		cur = n - 1
+	case "enter", "right", "l":
+		if !m.inGroup {
+			m.inGroup, m.file, m.offset = true, 0, 0
+			return nil
+		}
+	case "esc", "left", "h", "backspace":
+		if m.inGroup {
+			m.reviewed[groupKey(g)] = true
+			m.inGroup, m.offset = false, 0
+			return nil
+		}
+	case " ":
+		if m.inGroup {
+			m.toggle(g, g.Files[m.file])
+		}
+	case "f":
+		m.keepOnly(g, 0)
+		m.reviewed[groupKey(g)] = true
+	case "K":
+		if m.inGroup {
+			m.keepOnly(g, m.file)
+			m.reviewed[groupKey(g)] = true
+		}
+	case "u":
+		m.unmarkGroup(g)
+		delete(m.reviewed, groupKey(g))
+	case "n":
+		m.reviewed[groupKey(g)] = true
+		next := m.nextUnreviewed()
+		if next < 0 {
+			m.message = "全部组都已审阅"
+			return nil
+		}
+		m.group, m.file, m.offset = next, 0, 0
+		return nil
+	case "w":
+		if m.save == nil {
+			m.message = "未指定 -session，无法保存审阅进度"
+			return nil
+		}
+		s := &Session{}
+		m.session(s)
+		if err := m.save(s); err != nil {
+			m.message = "保存审阅进度失败: " + err.Error()
+		} else {
+			m.message = "已保存审阅进度"
+		}
+		return nil
+	case "s":
+		m.sortBy = 1 - m.sortBy
+		m.sort()
+		return nil
+	case "x":
+		if files, _ := m.total(); files == 0 {
+			m.message = "还没有标记要删除的文件"
+		} else {
+			m.confirm = true
+		}
+		return nil
+	}
+	cur = min(max(cur, 0), n-1)
+	if m.inGroup {
+		m.file = cur
+	} else {
+		m.group = cur
+	}
+	return nil
+}
+
+// reviewedCount 已审阅的组数
+func (m *model) reviewedCount() int {
+	n := 0
+	for _, g := range m.groups {
+		if m.reviewed[groupKey(g)] {
+			n += 1
+		}
+	}
+	return n
+}
+
+// rows 列表区域的行数
+func (m *model) rows() int {
+	if m.height == 0 {
+		return 20
+	}
+	return max(m.height-4, 1)
+}
+
+func (m *model) View() string {
+	b := &strings.Builder{}
+	files, size := m.total()
+	sortName := "清单顺序"
+	if m.sortBy == sortWasted {
+		sortName = "可释放空间"
+	}
+	fmt.Fprintf(b, "%d 组重复文件，已审阅 %d 组，已标记 %d 个文件 %s，排序: %s\n", len(m.groups), m.reviewedCount(), files, formatSize(size), sortName)
+	var lines []string
+	cur := m.group
+	if m.inGroup {
+		g := m.current()
+		cur = m.file
+		fmt.Fprintf(b, "第 %d 组 %s × %d  %s\n", m.group+1, formatSize(g.Size), len(g.Files), g.Hash)
+		for _, f := range g.Files {
+			mark := "[ ]"
+			if m.marked[f.Path] {
+				mark = "[x]"
+			}
+			lines = append(lines, mark+" "+f.Path)
+		}
+	} else {
+		b.WriteString("    已标记     可释放  文件\n")
+		for _, i := range m.order {
+			g := m.groups[i]
+			done := " "
+			if m.reviewed[groupKey(g)] {
+				done = "✓"
+			}
+			lines = append(lines, fmt.Sprintf("%s %3d/%-3d %9s  %s", done, len(g.Files)-m.unmarked(g), len(g.Files), formatSize(wasted(g)), groupKey(g)))
+		}
+	}
+	rows := m.rows()
+	if cur < m.offset {
+		m.offset = cur
+	}
+	if cur >= m.offset+rows {
+		m.offset = cur - rows + 1
+	}
+	for i := m.offset; i < len(lines) && i < m.offset+rows; i++ {
+		prefix := "  "
+		if i == cur {
+			prefix = "> "
+		}
+		b.WriteString(m.truncate(prefix+lines[i]) + "\n")
+	}
+	for i := len(lines) - m.offset; i < rows; i++ {
+		b.WriteString("\n")
+	}
+	switch {
+	case m.prompt != nil:
+		b.WriteString(m.truncate(m.prompt.label + m.prompt.value + "█"))
+	case m.confirm:
+		fmt.Fprintf(b, "将清理 %d 个文件，释放 %s，确认吗? (y/n)", files, formatSize(size))
+	case m.message != "":
+		b.WriteString(m.message)
+	case m.inGroup:
+		b.WriteString(m.truncate("↑↓ 移动  空格 标记  K 只保留此文件  f 保留第一个  u 取消本组标记  n 下一个未审阅的组  i 反选  D 标记目录  P 批量应用策略  ← 返回  w 保存进度  x 清理  q 退出"))
+	default:
+		b.WriteString(m.truncate("↑↓ 移动  → 查看组  f 保留第一个  u 取消本组标记  n 下一个未审阅的组  i 反选  D 标记目录  P 批量应用策略  s 切换排序  w 保存进度  x 清理  q 退出"))
+	}
+	return b.String()
+}
+
+// truncate 截断超出屏幕宽度的行
+func (m *model) truncate(s string) string {
+	if m.width == 0 {
+		return s
+	}
+	return runewidth.Truncate(s, m.width, "…")
+}
+
+// formatSize 将字节数格式化为易读的形式，与清单中的一致
+func formatSize(n int64) string {
+	const units = "KMGT"
+	if n < 1024 {
+		return fmt.Sprintf("%dB", n)
+	}
+	v := float64(n)
+	i := -1
+	for v >= 1024 && i < len(units)-1 {
+		v /= 1024
+		i++
+	}
+	return fmt.Sprintf("%.1f%cB", v, units[i])
+}
@@ -0,0 +1,23 @@
+//go:build plan9
+
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package tui
+
+import (
+	"duplicate-cleaner/duplicate"
+	"errors"
+)
+
+// Run 该平台的终端不支持全屏界面
+func Run(l duplicate.GroupList, s *Session, save func(*Session) error) (duplicate.FileInfos, error) {
+	return nil, errors.New("该平台不支持全屏界面，请改用 -interactive")
+}
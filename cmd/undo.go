@@ -0,0 +1,48 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package cmd
+
+import (
+	"duplicate-cleaner/duplicate"
+	"fmt"
+)
+
+// undoSuffix 撤销日志与清单(或删除计划)放在一起，每次清理都追加记录，不会自动删除
+const undoSuffix = ".undo"
+
+// restore 按 -restore 指定的撤销日志还原移到回收站或隔离目录的文件
+func restore(cfg *Config) error {
+	entries, err := duplicate.ReadUndoLog(cfg.restore)
+	if err != nil {
+		return err
+	}
+	lk, err := acquireLock(lockClean, cleanRoots(undoPaths(entries)))
+	if err != nil {
+		return err
+	}
+	defer lk.Release()
+	n, err := duplicate.Restore(entries, func(e duplicate.UndoEntry) {
+		if cfg.verbose {
+			fmt.Printf("已还原\t%s\n", e.Path)
+		}
+	})
+	fmt.Printf("成功还原 %d 个文件\n", n)
+	return err
+}
+
+// undoPaths 撤销日志中各文件的原路径
+func undoPaths(entries []duplicate.UndoEntry) []string {
+	paths := make([]string, 0, len(entries))
+	for _, e := range entries {
+		paths = append(paths, e.Path)
+	}
+	return paths
+}
@@ -0,0 +1,123 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package cmd
+
+import (
+	"duplicate-cleaner/duplicate"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+// 构建发布版本时用 -ldflags "-X duplicate-cleaner/cmd.version=v1.2.0 -X ..." 写入，
+// 为空时取 Go 工具链记录的模块版本和版本控制信息，此时日期为提交时间
+var (
+	version   = ""
+	commit    = ""
+	buildDate = ""
+)
+
+// buildInfo 程序的版本和构建信息
+type buildInfo struct {
+	Version  string
+	Commit   string
+	Date     string
+	Modified bool // 构建时工作区有未提交的修改
+	Go       string
+	Platform string
+}
+
+// currentBuild 当前程序的构建信息
+var currentBuild = sync.OnceValue(func() buildInfo {
+	b := buildInfo{Version: version, Commit: commit, Date: buildDate, Go: runtime.Version(), Platform: runtime.GOOS + "/" + runtime.GOARCH}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if b.Version == "" && info.Main.Version != "(devel)" {
+			b.Version = info.Main.Version
+		}
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				if b.Commit == "" {
+					b.Commit = s.Value
+				}
+			case "vcs.time":
+				if b.Date == "" {
+					b.Date = s.Value
+				}
+			case "vcs.modified":
+				b.Modified = b.Modified || s.Value == "true"
+			}
+		}
+	}
+	if b.Version == "" {
+		b.Version = "devel"
+	}
+	return b
+})
+
+// String 一行的版本信息，写入清单头部和审计日志，如
+// duplicate-cleaner v1.2.0 (commit 1a2b3c4d5e6f, 2025-06-01T08:00:00Z, go1.24.3 linux/amd64)
+func (b buildInfo) String() string {
+	parts := []string{}
+	if b.Commit != "" {
+		c := b.Commit
+		if len(c) > 12 {
+			c = c[:12]
+		}
+		if b.Modified {
+			c += "-dirty"
+		}
+		parts = append(parts, "commit "+c)
+	}
+	if b.Date != "" {
+		parts = append(parts, b.Date)
+	}
+	parts = append(parts, b.Go+" "+b.Platform)
+	return fmt.Sprintf("duplicate-cleaner %s (%s)", b.Version, strings.Join(parts, ", "))
+}
+
+// printVersion 输出版本、构建信息和本平台可用的功能
+func printVersion() {
+	b := currentBuild()
+	fmt.Printf("duplicate-cleaner %s\n", b.Version)
+	commit := b.Commit
+	if commit == "" {
+		commit = "未知"
+	} else if b.Modified {
+		commit += " (含未提交的修改)"
+	}
+	date := b.Date
+	if date == "" {
+		date = "未知"
+	}
+	fmt.Printf("提交:     %s\n", commit)
+	fmt.Printf("日期:     %s\n", date)
+	fmt.Printf("Go:       %s %s\n", b.Go, b.Platform)
+	fmt.Println("功能:")
+	hashes := []string{}
+	for _, h := range []string{"md5", "sha1", "sha256", "sha512", "blake3", "xxh64", "xxh128"} {
+		hashes = append(hashes, fmt.Sprintf("%s(%s)", h, duplicate.Accel(h)))
+	}
+	fmt.Printf("  Hash算法: %s\n", strings.Join(hashes, " "))
+	fmt.Println("  归档和镜像: zip tar iso")
+	fmt.Printf("  跳过正在写入的文件(-skip-open): %s\n", supported(runtime.GOOS == "linux" || runtime.GOOS == "windows"))
+	fmt.Printf("  按挂载信息检测绑定挂载: %s\n", supported(runtime.GOOS == "linux"))
+	fmt.Printf("  自动更新(-self-update): %s\n", supported(updateKey != ""))
+}
+
+func supported(ok bool) string {
+	if ok {
+		return "支持"
+	}
+	return "不支持"
+}
@@ -0,0 +1,38 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package cmd
+
+import (
+	"duplicate-cleaner/duplicate"
+	"fmt"
+)
+
+// printVolatile 汇总 -tolerant 下扫描期间消失或被修改的文件，这些文件未计入清单
+func printVolatile(files []duplicate.VolatileFile) {
+	if len(files) == 0 {
+		return
+	}
+	fmt.Printf("易变文件: 以下 %d 个文件在扫描期间消失或被修改，未计入清单，可在文件稳定后重新扫描\n", len(files))
+	for _, f := range files {
+		fmt.Printf("  %s\t%s\n", f.Path, f.Reason)
+	}
+}
+
+// printOpen 汇总 -skip-open 下一直被其他进程写入的文件，这些文件未计入清单
+func printOpen(files []string) {
+	if len(files) == 0 {
+		return
+	}
+	fmt.Printf("正在写入的文件: 以下 %d 个文件一直被其他进程写入，未计算Hash值，也未计入清单，可在写入完成后重新扫描\n", len(files))
+	for _, f := range files {
+		fmt.Printf("  %s\n", f)
+	}
+}
@@ -0,0 +1,101 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 支持的 webhook 类型
+const (
+	webhookSlack = "slack"
+	webhookTeams = "teams"
+)
+
+// webhookType 未指定类型时按地址判断
+func webhookType(url string, typ string) string {
+	if typ != "" {
+		return typ
+	}
+	if strings.Contains(url, "office.com") || strings.Contains(url, "logic.azure.com") {
+		return webhookTeams
+	}
+	return webhookSlack
+}
+
+// webhookText 摘要的文本，粗体使用 Slack 的 *...* 写法
+func webhookText(s *summary, report string) string {
+	host, _ := os.Hostname()
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "*%s* 重复文件扫描完成", host)
+	if s.Partial {
+		b.WriteString("(未完成，结果不完整)")
+	}
+	fmt.Fprintf(b, "\n扫描路径: %s", strings.Join(s.Roots, ", "))
+	fmt.Fprintf(b, "\n扫描文件: %d 个，共 %s，用时 %v", s.Files, formatSize(s.Bytes), s.Duration)
+	fmt.Fprintf(b, "\n重复文件: %d 组，可清理 %d 个，*可释放 %s*", s.Groups, s.Dups, formatSize(s.Wasted))
+	if report != "" {
+		fmt.Fprintf(b, "\n完整清单: %s", report)
+	}
+	return b.String()
+}
+
+// webhookPayload 按 webhook 类型生成消息
+func webhookPayload(typ string, s *summary, report string) any {
+	text := webhookText(s, report)
+	if typ != webhookTeams {
+		return map[string]any{"text": text}
+	}
+	card := map[string]any{
+		"@type":    "MessageCard",
+		"@context": "https://schema.org/extensions",
+		"summary":  "重复文件扫描摘要",
+		// Teams 的粗体为 **...**，且单个换行不会分段
+		"text": strings.NewReplacer("*", "**", "\n", "\n\n").Replace(text),
+	}
+	if strings.HasPrefix(report, "http://") || strings.HasPrefix(report, "https://") {
+		card["potentialAction"] = []any{map[string]any{
+			"@type":   "OpenUri",
+			"name":    "查看完整清单",
+			"targets": []any{map[string]string{"os": "default", "uri": report}},
+		}}
+	}
+	return card
+}
+
+// postWebhook 将扫描摘要发送到 Slack 或 Teams 的 incoming webhook
+func postWebhook(cfg *Config, s *summary) error {
+	report := s.Report
+	if cfg.reportURL != "" && report != "" {
+		report = strings.TrimRight(cfg.reportURL, "/") + "/" + filepath.Base(report)
+	}
+	body, err := json.Marshal(webhookPayload(webhookType(cfg.webhook, cfg.webhookType), s, report))
+	if err != nil {
+		return err
+	}
+	client := http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Post(cfg.webhook, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("发送 webhook 失败: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("发送 webhook 失败: %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package cmd
+
+import (
+	"duplicate-cleaner/duplicate"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// workPath 检查点、清理日志等工作文件的位置，未指定 -workdir 时放在 file 旁边
+func (cfg *Config) workPath(file string) string {
+	if cfg.workdir == "" {
+		return file
+	}
+	return filepath.Join(cfg.workdir, filepath.Base(file))
+}
+
+// checkFreeSpace 检查各文件所在目录的可用空间不少于 -min-free，无法检测时跳过
+func (cfg *Config) checkFreeSpace(files ...string) error {
+	if cfg.minFree <= 0 {
+		return nil
+	}
+	if cfg.workdir != "" {
+		if err := os.MkdirAll(cfg.workdir, 0755); err != nil {
+			return err
+		}
+	}
+	for _, f := range files {
+		if f == "" {
+			continue
+		}
+		dir := filepath.Dir(f)
+		if abs, err := filepath.Abs(dir); err == nil {
+			dir = abs
+		}
+		free, err := duplicate.FreeSpace(dir)
+		if err != nil {
+			if cfg.verbose {
+				fmt.Printf("无法检测 %s 的可用空间: %v\n", dir, err)
+			}
+			continue
+		}
+		if free < uint64(cfg.minFree) {
+			return fmt.Errorf("%s 所在磁盘可用空间只有 %s，低于 -min-free %s，请用 -workdir 或 -o 指定其他磁盘",
+				dir, formatSize(int64(free)), formatSize(int64(cfg.minFree)))
+		}
+	}
+	return nil
+}
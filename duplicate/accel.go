@@ -0,0 +1,88 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import (
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/klauspost/cpuid/v2"
+)
+
+// AccelDisabled 是否已通过 GODEBUG=cpu.all=off 禁止标准库使用 CPU 扩展指令
+func AccelDisabled() bool {
+	for _, opt := range strings.Split(os.Getenv("GODEBUG"), ",") {
+		if strings.TrimSpace(opt) == "cpu.all=off" {
+			return true
+		}
+	}
+	return false
+}
+
+// Accel 返回当前 CPU 上指定Hash算法实际使用的实现。
+// 标准库会按 CPU 特性自动选用 SHA 扩展指令或 SIMD 实现，这里按相同的条件推断
+func Accel(hashName string) string {
+	hashName = HashName(hashName)
+	if AccelDisabled() {
+		return "通用实现(已禁用加速)"
+	}
+	c := cpuid.CPU
+	// BLAKE3 使用自带的 SIMD 实现，支持 AVX-512、AVX2 和 SSE4.1
+	if hashName == "blake3" {
+		switch {
+		case runtime.GOARCH != "amd64" || os.Getenv("BLAKE3_PUREGO") != "":
+		case c.Supports(cpuid.AVX512F, cpuid.AVX512VL):
+			return "AVX-512"
+		case c.Supports(cpuid.AVX2):
+			return "AVX2"
+		case c.Supports(cpuid.SSE4):
+			return "SSE4.1"
+		}
+		return "通用实现"
+	}
+	// XXH3 在 amd64 上按 CPU 特性选用 AVX-512、AVX2 或 SSE2 实现
+	if hashName == "xxh128" {
+		switch {
+		case runtime.GOARCH != "amd64":
+		case c.Supports(cpuid.AVX512F):
+			return "AVX-512"
+		case c.Supports(cpuid.AVX2):
+			return "AVX2"
+		case c.Supports(cpuid.SSE2):
+			return "SSE2"
+		}
+		return "通用实现"
+	}
+	switch runtime.GOARCH {
+	case "amd64":
+		switch {
+		case hashName == "sha1" && c.Supports(cpuid.SHA, cpuid.SSE4):
+			return "SHA-NI"
+		case hashName == "sha256" && c.Supports(cpuid.SHA, cpuid.SSE4, cpuid.SSSE3):
+			return "SHA-NI"
+		case (hashName == "sha1" || hashName == "sha256") && c.Supports(cpuid.AVX2, cpuid.BMI1, cpuid.BMI2):
+			return "AVX2"
+		case hashName == "sha512" && c.Supports(cpuid.AVX2):
+			return "AVX2"
+		}
+	case "arm64":
+		switch {
+		case hashName == "sha1" && c.Supports(cpuid.SHA1):
+			return "ARMv8 SHA1"
+		case hashName == "sha256" && c.Supports(cpuid.SHA2):
+			return "ARMv8 SHA2"
+		case hashName == "sha512" && c.Supports(cpuid.SHA512):
+			return "ARMv8.2 SHA512"
+		}
+	}
+	return "通用实现"
+}
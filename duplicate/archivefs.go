@@ -0,0 +1,249 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ArchiveSep 归档路径与归档内路径之间的分隔符，如 /data/a.zip!/dir/file
+const ArchiveSep = "!/"
+
+// errArchiveReadOnly 归档内的文件不能清理
+var errArchiveReadOnly = errors.New("归档内的文件只读")
+
+// archiveEntry 归档内的文件
+type archiveEntry struct {
+	Name    string
+	Size    int64
+	ModTime time.Time
+}
+
+// archiveFS 以只读方式挂载的归档文件
+type archiveFS interface {
+	Entries() []archiveEntry
+	Open(name string) (io.ReadCloser, error)
+	Close() error
+}
+
+// IsArchivePath 判断路径是否指向归档内的文件
+func IsArchivePath(path string) bool {
+	_, _, ok := splitArchivePath(path)
+	return ok
+}
+
+// splitArchivePath 将归档内文件的路径拆分为归档路径和归档内路径
+func splitArchivePath(path string) (string, string, bool) {
+	i := strings.Index(path, ArchiveSep)
+	for i >= 0 {
+		if isArchiveName(path[:i]) {
+			return path[:i], path[i+len(ArchiveSep):], true
+		}
+		j := strings.Index(path[i+len(ArchiveSep):], ArchiveSep)
+		if j < 0 {
+			break
+		}
+		i += len(ArchiveSep) + j
+	}
+	return "", "", false
+}
+
+// isArchiveName 根据扩展名判断是否为支持的归档
+func isArchiveName(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".zip", ".tar", ".iso":
+		return true
+	}
+	return false
+}
+
+// mountArchive 挂载归档文件，目前支持 zip、未压缩的 tar 和 ISO 9660 光盘镜像
+func mountArchive(path string) (archiveFS, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".zip":
+		return mountZip(path)
+	case ".tar":
+		return mountTar(path)
+	case ".iso":
+		return mountISO(path)
+	case ".vhd", ".vhdx", ".vmdk", ".qcow2", ".img":
+		// 虚拟磁盘内是分区表和 NTFS、ext4 等文件系统，无法直接解析
+		return nil, fmt.Errorf("不支持直接读取虚拟磁盘镜像 %s，请先以只读方式挂载(如 Windows 的 Mount-DiskImage -Access ReadOnly、Linux 的 guestmount --ro)，再将挂载点作为目录扫描", path)
+	}
+	return nil, fmt.Errorf("不支持的归档格式: %s", path)
+}
+
+// zipFS zip 归档
+type zipFS struct {
+	r     *zip.ReadCloser
+	files map[string]*zip.File
+}
+
+func mountZip(path string) (*zipFS, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	z := &zipFS{r: r, files: map[string]*zip.File{}}
+	for _, f := range r.File {
+		if f.Mode().IsRegular() {
+			z.files[f.Name] = f
+		}
+	}
+	return z, nil
+}
+
+func (z *zipFS) Entries() []archiveEntry {
+	entries := []archiveEntry{}
+	for _, f := range z.r.File {
+		if f.Mode().IsRegular() {
+			entries = append(entries, archiveEntry{Name: f.Name, Size: int64(f.UncompressedSize64), ModTime: f.Modified})
+		}
+	}
+	return entries
+}
+
+func (z *zipFS) Open(name string) (io.ReadCloser, error) {
+	f, ok := z.files[name]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return f.Open()
+}
+
+func (z *zipFS) Close() error {
+	return z.r.Close()
+}
+
+// tarFS 未压缩的 tar 归档，挂载时记录各文件数据的偏移量以便随机读取
+type tarFS struct {
+	f       *os.File
+	entries []archiveEntry
+	offsets map[string]int64
+	sizes   map[string]int64
+}
+
+// countingReader 记录已读取的字节数
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func mountTar(file string) (*tarFS, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	t := &tarFS{f: f, offsets: map[string]int64{}, sizes: map[string]int64{}}
+	cr := &countingReader{r: f}
+	tr := tar.NewReader(cr)
+	for {
+		h, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		if h.Typeflag != tar.TypeReg {
+			continue
+		}
+		// Next 返回时已读完头部，数据紧随其后
+		name := path.Clean(h.Name)
+		t.offsets[name] = cr.n
+		t.sizes[name] = h.Size
+		t.entries = append(t.entries, archiveEntry{Name: name, Size: h.Size, ModTime: h.ModTime})
+	}
+	return t, nil
+}
+
+func (t *tarFS) Entries() []archiveEntry {
+	return t.entries
+}
+
+func (t *tarFS) Open(name string) (io.ReadCloser, error) {
+	off, ok := t.offsets[name]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return io.NopCloser(io.NewSectionReader(t.f, off, t.sizes[name])), nil
+}
+
+func (t *tarFS) Close() error {
+	return t.f.Close()
+}
+
+// mountArchives 挂载归档并将其中的文件加入候选
+func (s *Scanner) mountArchives() ([]*FileInfo, error) {
+	var files []*FileInfo
+	for _, a := range s.opts.Archives {
+		abs := CanonicalPath(a)
+		if _, ok := s.mounts[abs]; ok {
+			continue
+		}
+		m, err := mountArchive(abs)
+		if err != nil {
+			return nil, newPathError("挂载归档", abs, err)
+		}
+		s.mounts[abs] = m
+		for _, e := range m.Entries() {
+			if e.Size <= 0 {
+				continue
+			}
+			f := &FileInfo{Path: abs + ArchiveSep + e.Name, Size: e.Size, ModTime: e.ModTime}
+			if ok, err := s.detectType(f); err != nil || !ok {
+				if err != nil {
+					s.opts.onError(newPathError("检测类型", f.Path, err))
+				}
+				continue
+			}
+			files = append(files, f)
+			if s.opts.OnFileScanned != nil {
+				s.opts.OnFileScanned(*f)
+			}
+		}
+	}
+	return files, nil
+}
+
+// unmountArchives 卸载所有已挂载的归档
+func (s *Scanner) unmountArchives() {
+	for k, m := range s.mounts {
+		m.Close()
+		delete(s.mounts, k)
+	}
+}
+
+// open 打开普通文件或已挂载归档内的文件
+func (s *Scanner) open(path string) (io.ReadCloser, error) {
+	if a, name, ok := splitArchivePath(path); ok {
+		if m, ok := s.mounts[a]; ok {
+			return m.Open(name)
+		}
+	}
+	return s.opts.FS.Open(path)
+}
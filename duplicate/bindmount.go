@@ -0,0 +1,143 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// SharedRoot 通过绑定挂载、同一子卷的多次挂载等方式与其他扫描路径指向同一份数据的扫描路径
+type SharedRoot struct {
+	Root string // 被跳过的扫描路径
+	Same string // Root 中的数据也能通过该路径访问到，位于另一个扫描路径之下或就是该扫描路径
+}
+
+// mountEntry 挂载信息中的一项
+type mountEntry struct {
+	dev   string // 设备号 major:minor
+	root  string // 挂载的是文件系统中的哪个目录，绑定挂载和子卷挂载时不是 /
+	point string // 挂载点
+}
+
+// rootSource 扫描路径实际对应的数据：所在的设备和在该设备文件系统中的路径
+type rootSource struct {
+	dev  string
+	path string
+}
+
+// SharedRoots 检测扫描路径之间的绑定挂载等：它们的路径不同，但实际是同一份数据，
+// 在两者之间找到的“重复文件”其实是同一个文件，删除其中一个就等于删除了全部。
+// roots 应为 MergeRoots 合并后的路径；一个路径的数据包含在另一个路径中时跳过前者，
+// 两者完全相同时跳过靠后的；返回保留的路径(保持原有顺序)和被跳过的路径
+func SharedRoots(roots []string) ([]string, []SharedRoot) {
+	return sharedRoots(roots, readMounts())
+}
+
+// sharedRoots 同 SharedRoots，mounts 为系统的挂载信息，无法获取时为空，此时只检测指向同一目录的路径
+func sharedRoots(roots []string, mounts []mountEntry) ([]string, []SharedRoot) {
+	srcs := make([]*rootSource, len(roots))
+	infos := make([]os.FileInfo, len(roots))
+	for i, r := range roots {
+		srcs[i] = sourceOf(r, mounts)
+		infos[i], _ = os.Stat(r)
+	}
+	skipped := map[int]bool{}
+	shared := []SharedRoot{}
+	for i := range roots {
+		for j := range roots {
+			if i == j || skipped[j] {
+				continue
+			}
+			same := ""
+			switch {
+			case srcs[i] != nil && srcs[j] != nil && srcs[i].dev == srcs[j].dev && IsWithin(srcs[i].path, srcs[j].path):
+				// 两者相同时只跳过靠后的
+				if srcs[i].path == srcs[j].path && i < j {
+					continue
+				}
+				rel, _ := filepath.Rel(srcs[j].path, srcs[i].path)
+				same = filepath.Join(roots[j], rel)
+			case infos[i] != nil && infos[j] != nil && os.SameFile(infos[i], infos[j]) && i > j:
+				same = roots[j]
+			}
+			if same != "" {
+				skipped[i] = true
+				shared = append(shared, SharedRoot{Root: roots[i], Same: same})
+				break
+			}
+		}
+	}
+	kept := []string{}
+	for i, r := range roots {
+		if !skipped[i] {
+			kept = append(kept, r)
+		}
+	}
+	return kept, shared
+}
+
+// sourceOf 根据挂载信息找出路径所在的挂载(挂载点最长的，同一挂载点叠加挂载时取最后的)，无法确定时返回 nil
+func sourceOf(path string, mounts []mountEntry) *rootSource {
+	var best *mountEntry
+	for i := range mounts {
+		m := &mounts[i]
+		if IsWithin(path, m.point) && (best == nil || len(m.point) >= len(best.point)) {
+			best = m
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	rel, err := filepath.Rel(best.point, path)
+	if err != nil {
+		return nil
+	}
+	return &rootSource{dev: best.dev, path: filepath.Join(best.root, rel)}
+}
+
+// parseMountInfo 解析 Linux 的 /proc/self/mountinfo
+func parseMountInfo(r io.Reader) []mountEntry {
+	mounts := []mountEntry{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		// 36 35 98:0 /mnt1 /mnt/parent rw,noatime master:1 - ext3 /dev/root rw
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 {
+			continue
+		}
+		mounts = append(mounts, mountEntry{dev: fields[2], root: unescapeMount(fields[3]), point: unescapeMount(fields[4])})
+	}
+	return mounts
+}
+
+// unescapeMount 还原挂载信息中以 \040 等八进制转义的空格、制表符、换行和反斜杠
+func unescapeMount(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	b := strings.Builder{}
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+4 <= len(s) {
+			if n, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(n))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,18 @@
+//go:build !linux
+
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+// readMounts 其他平台没有可用的挂载信息，只检测指向同一目录的扫描路径
+func readMounts() []mountEntry {
+	return nil
+}
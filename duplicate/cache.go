@@ -0,0 +1,238 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// cacheBatch 累积这么多个新条目后写入一次，避免每个文件都提交一次事务；Prune 每次事务也最多删除这么多条目
+const cacheBatch = 1000
+
+// cacheVersion 缓存文件的格式版本，记录在 meta 桶中，条目的编码改变时递增
+const cacheVersion = 1
+
+// cacheMeta 存放格式版本等元信息的桶，名称不会与算法名冲突
+var (
+	cacheMeta       = []byte("_meta")
+	cacheVersionKey = []byte("version")
+)
+
+// HashCache 持久化的Hash值缓存，存放在 bbolt 数据库中，按路径记录文件的大小、修改时间和Hash值。
+// 与 HashIndex 不同，它不需要整体载入内存，适合反复扫描同一个大型存储，只重新计算有变化的文件。
+// 每种算法的Hash值各存一处，只有本次算法的条目会被使用；可被并发调用
+type HashCache struct {
+	m       sync.Mutex
+	db      *bolt.DB
+	bucket  []byte
+	pending map[string][]byte // 尚未写入的条目
+}
+
+// OpenHashCache 打开或创建缓存文件，hashName 为本次使用的算法。
+// 同一缓存文件同时只能被一个进程打开
+func OpenHashCache(path string, hashName string) (*HashCache, error) {
+	db, err := bolt.Open(path, 0644, &bolt.Options{Timeout: time.Second})
+	if errors.Is(err, bolt.ErrTimeout) {
+		return nil, fmt.Errorf("缓存文件 %s 正被其他进程使用", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	c := &HashCache{db: db, bucket: []byte(HashName(hashName)), pending: map[string][]byte{}}
+	err = db.Update(func(tx *bolt.Tx) error {
+		meta, err := tx.CreateBucketIfNotExists(cacheMeta)
+		if err != nil {
+			return err
+		}
+		// 新建的缓存文件写入当前版本，已有的检查版本是否兼容
+		if v := meta.Get(cacheVersionKey); v != nil {
+			if len(v) != 8 {
+				return fmt.Errorf("%w: 缓存文件 %s 的版本信息无效", ErrVersion, path)
+			}
+			if err := CheckVersion("缓存文件", int(binary.BigEndian.Uint64(v)), cacheVersion, cacheVersion); err != nil {
+				return err
+			}
+		} else if err := meta.Put(cacheVersionKey, binary.BigEndian.AppendUint64(nil, cacheVersion)); err != nil {
+			return err
+		}
+		_, err = tx.CreateBucketIfNotExists(c.bucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// encodeCacheEntry 条目的值：大小和修改时间(Unix 纳秒)各 8 字节，其后是Hash值
+func encodeCacheEntry(f FileInfo) []byte {
+	v := make([]byte, 16, 16+len(f.Hash))
+	binary.BigEndian.PutUint64(v, uint64(f.Size))
+	binary.BigEndian.PutUint64(v[8:], uint64(f.ModTime.UnixNano()))
+	return append(v, f.Hash...)
+}
+
+// decodeCacheEntry 解析条目的值，格式不符时返回 false
+func decodeCacheEntry(v []byte) (int64, int64, string, bool) {
+	if len(v) <= 16 {
+		return 0, 0, "", false
+	}
+	return int64(binary.BigEndian.Uint64(v)), int64(binary.BigEndian.Uint64(v[8:])), string(v[16:]), true
+}
+
+// Lookup 文件的大小和修改时间与缓存一致时返回记录的Hash值
+func (c *HashCache) Lookup(f FileInfo) (string, bool) {
+	c.m.Lock()
+	v, ok := c.pending[f.Path]
+	c.m.Unlock()
+	if !ok {
+		c.db.View(func(tx *bolt.Tx) error {
+			v = bytes.Clone(tx.Bucket(c.bucket).Get([]byte(f.Path)))
+			return nil
+		})
+	}
+	size, mtime, hash, ok := decodeCacheEntry(v)
+	if !ok || size != f.Size || mtime != f.ModTime.UnixNano() {
+		return "", false
+	}
+	return hash, true
+}
+
+// Add 记录文件的Hash值，替换该路径原有的条目；与缓存中一致时不重复写入
+func (c *HashCache) Add(f FileInfo) error {
+	if f.Hash == "" {
+		return nil
+	}
+	if h, ok := c.Lookup(f); ok && h == f.Hash {
+		return nil
+	}
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.pending[f.Path] = encodeCacheEntry(f)
+	if len(c.pending) < cacheBatch {
+		return nil
+	}
+	return c.flush()
+}
+
+// flush 写入累积的条目，调用时需持有锁
+func (c *HashCache) flush() error {
+	if len(c.pending) == 0 {
+		return nil
+	}
+	err := c.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(c.bucket)
+		for p, v := range c.pending {
+			if err := b.Put([]byte(p), v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	c.pending = map[string][]byte{}
+	return err
+}
+
+// Prune 删除位于 roots 之下、已不存在或大小和修改时间已改变的文件的条目(所有算法)，返回删除的条目数。
+// roots 应为解析后的真实路径，用于完整扫描之后清理，避免缓存无限增长。
+// 先在只读事务中找出要删除的条目，再分批删除，获取文件信息期间不会长时间占用写事务
+func (c *HashCache) Prune(roots []string) (int, error) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	if err := c.flush(); err != nil {
+		return 0, err
+	}
+	stale := map[string][][]byte{}
+	err := c.db.View(func(tx *bolt.Tx) error {
+		return tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+			if bytes.Equal(name, cacheMeta) {
+				return nil
+			}
+			return b.ForEach(func(k, v []byte) error {
+				if IsArchivePath(string(k)) || !withinAny(string(k), roots) {
+					return nil
+				}
+				size, mtime, _, ok := decodeCacheEntry(v)
+				info, err := os.Lstat(string(k))
+				if !ok || err != nil || info.Size() != size || info.ModTime().UnixNano() != mtime {
+					stale[string(name)] = append(stale[string(name)], bytes.Clone(k))
+				}
+				return nil
+			})
+		})
+	})
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for name, keys := range stale {
+		for len(keys) > 0 {
+			batch := keys[:min(len(keys), cacheBatch)]
+			keys = keys[len(batch):]
+			err := c.db.Update(func(tx *bolt.Tx) error {
+				b := tx.Bucket([]byte(name))
+				if b == nil {
+					return nil
+				}
+				for _, k := range batch {
+					if err := b.Delete(k); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				return n, err
+			}
+			n += len(batch)
+		}
+	}
+	return n, nil
+}
+
+// Len 本次算法的条目数
+func (c *HashCache) Len() int {
+	c.m.Lock()
+	defer c.m.Unlock()
+	n := 0
+	c.db.View(func(tx *bolt.Tx) error {
+		n = tx.Bucket(c.bucket).Stats().KeyN
+		return nil
+	})
+	return n
+}
+
+// Close 写入累积的条目并关闭缓存文件
+func (c *HashCache) Close() error {
+	c.m.Lock()
+	defer c.m.Unlock()
+	err := c.flush()
+	return errors.Join(err, c.db.Close())
+}
+
+// withinAny 路径是否位于任一目录之下或就是该目录
+func withinAny(path string, dirs []string) bool {
+	for _, d := range dirs {
+		if IsWithin(path, d) {
+			return true
+		}
+	}
+	return false
+}
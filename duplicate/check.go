@@ -0,0 +1,133 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+)
+
+// CheckResult 单个文件的预检结果，Err 为空表示通过
+type CheckResult struct {
+	Path string
+	Size int64 // 文件当前的大小，无法获取时为0
+	Err  error
+}
+
+// Check 只读地预检待清理的文件：存在、是普通文件、可删除、不受保护，
+// 且大小和Hash值(清单中有记录时)与扫描时一致，不会删除任何文件
+func (c *Cleaner) Check(files []FileInfo) []CheckResult {
+	return c.checkAll(files, true)
+}
+
+// DryRun 同 Check，但不读取文件内容核对Hash值，用于快速预览清理的效果
+func (c *Cleaner) DryRun(files []FileInfo) []CheckResult {
+	return c.checkAll(files, false)
+}
+
+// checkAll 逐个预检文件，content 为 false 时不核对Hash值
+func (c *Cleaner) checkAll(files []FileInfo, content bool) []CheckResult {
+	results := make([]CheckResult, 0, len(files))
+	bar := newTracker(c.opts.Progress, c.opts.OnProgress, StageCheck, "预检文件", int64(len(files)), 0)
+	defer bar.Close()
+	for _, f := range files {
+		size, err := c.check(f, content)
+		if err != nil {
+			err = newPathError("预检", f.Path, err)
+		}
+		results = append(results, CheckResult{Path: f.Path, Size: size, Err: err})
+		bar.Add(1, 0)
+	}
+	return results
+}
+
+// check 预检单个文件，返回文件当前的大小
+func (c *Cleaner) check(f FileInfo, content bool) (int64, error) {
+	if IsArchivePath(f.Path) {
+		return 0, errArchiveReadOnly
+	}
+	if err := c.protected(f.Path); err != nil {
+		return 0, err
+	}
+	info, err := c.opts.FS.Lstat(f.Path)
+	if err != nil {
+		return 0, err
+	}
+	if !info.Mode().IsRegular() {
+		return info.Size(), errors.New("不是普通文件")
+	}
+	if isOSFS(c.opts.FS) {
+		if err := checkDeletable(f.Path, info); err != nil {
+			return info.Size(), err
+		}
+	}
+	if !content {
+		f.Hash = ""
+	}
+	return info.Size(), c.verifyContent(f, info)
+}
+
+// Verify 只读地确认文件仍是普通文件，且大小和Hash值(有记录时)与清单一致，
+// 不检查能否删除，用于删除前确认同组保留的副本仍然完好
+func (c *Cleaner) Verify(f FileInfo) error {
+	if IsArchivePath(f.Path) {
+		return errArchiveReadOnly
+	}
+	info, err := c.opts.FS.Lstat(f.Path)
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return errors.New("不是普通文件")
+	}
+	return c.verifyContent(f, info)
+}
+
+// verifyContent 比较文件的大小和Hash值与记录是否一致
+func (c *Cleaner) verifyContent(f FileInfo, info fs.FileInfo) error {
+	if f.Size > 0 && info.Size() != f.Size {
+		return fmt.Errorf("%w: 大小为 %d 字节，清单中为 %d 字节", ErrHashMismatch, info.Size(), f.Size)
+	}
+	if f.Hash == "" {
+		return nil
+	}
+	names := hashByLength(f.Hash)
+	if len(names) == 0 {
+		return fmt.Errorf("无法识别的Hash值: %s", f.Hash)
+	}
+	sums, err := hashFileMulti(c.opts.FS, f.Path, names)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(sums, f.Hash) {
+		return fmt.Errorf("%w: 当前为 %s，清单中为 %s", ErrHashMismatch, sums[0], f.Hash)
+	}
+	return nil
+}
+
+// hashByLength 根据十六进制Hash值的长度推断可能的算法，md5 与 xxh128、sha256 与 blake3 长度相同
+func hashByLength(hashValue string) []string {
+	switch len(hashValue) {
+	case 16:
+		return []string{"xxh64"}
+	case 32:
+		return []string{"md5", "xxh128"}
+	case 40:
+		return []string{"sha1"}
+	case 64:
+		return []string{"sha256", "blake3"}
+	case 128:
+		return []string{"sha512"}
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// CleanOptions 清理选项
+type CleanOptions struct {
+	Progress      bool           // 是否显示进度条
+	OnProgress    func(Progress) // 各阶段开始、结束时和进行中每秒最多一次报告进度，为空时不报告
+	IgnoreMissing bool           // 文件已不存在时视为已清理，用于中断后继续清理
+	RelativeLinks bool           // Symlink 使用相对路径的符号链接
+	AllowRisky    bool           // 允许清理位于应用程序管理的目录(见 RiskyLocations)中的文件
+	Trash         bool           // 移到回收站而不是直接删除，只支持本机文件系统
+
+	// 移到该目录而不是直接删除，按原绝对路径的目录结构存放，原位置记录在其中的 QuarantineManifest 里，
+	// 优先于 Trash，只支持本机文件系统
+	Quarantine string
+
+	Undo      *UndoLog   // 记录每个清理的文件及其去向，可用 Restore 还原，可为空
+	Protected []string   // 额外受保护的路径，DefaultProtected 中的目录总是受保护
+	FS        FileSystem // 访问的文件系统，为空时使用 OSFS
+
+	OnCleaned func(path string) // 每成功清理一个文件后调用，可为空
+}
+
+// errTrashUnsupported 无法移到回收站
+var errTrashUnsupported = errors.New("该平台或位置不支持回收站")
+
+// Cleaner 重复文件清理器
+type Cleaner struct {
+	opts       CleanOptions
+	quarantine *quarantine // 清理期间打开的隔离目录
+}
+
+// NewCleaner 创建清理器
+func NewCleaner(opts CleanOptions) *Cleaner {
+	if opts.FS == nil {
+		opts.FS = OSFS{}
+	}
+	return &Cleaner{opts: opts}
+}
+
+// Clean 逐个清理指定的文件，返回成功清理的数量
+func (c *Cleaner) Clean(files []string) (int, error) {
+	if len(files) == 0 {
+		return 0, nil
+	}
+	if c.opts.Quarantine != "" {
+		if !isOSFS(c.opts.FS) {
+			return 0, errors.New("隔离目录只支持本机文件系统")
+		}
+		q, err := openQuarantine(c.opts.Quarantine)
+		if err != nil {
+			return 0, fmt.Errorf("无法打开隔离目录: %w", err)
+		}
+		c.quarantine = q
+		defer func() {
+			q.Close()
+			c.quarantine = nil
+		}()
+	}
+	n := 0
+	errs := []error{}
+	bar := newTracker(c.opts.Progress, c.opts.OnProgress, StageClean, "清理文件", int64(len(files)), 0)
+	defer bar.Close()
+	for _, file := range files {
+		var err error
+		if IsArchivePath(file) {
+			err = errArchiveReadOnly
+		} else if err = c.protected(file); err == nil {
+			err = c.removeLogged(file)
+		}
+		bar.Add(1, 0)
+		if err != nil && !(c.opts.IgnoreMissing && errors.Is(err, os.ErrNotExist)) {
+			errs = append(errs, newPathError("清理文件", file, err))
+			continue
+		}
+		n += 1
+		if c.opts.OnCleaned != nil {
+			c.opts.OnCleaned(file)
+		}
+	}
+	return n, errors.Join(errs...)
+}
+
+// removeLogged 清理文件，设置了 Undo 时记录到撤销日志
+func (c *Cleaner) removeLogged(file string) error {
+	if c.opts.Undo == nil {
+		_, _, err := c.remove(file)
+		return err
+	}
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return err
+	}
+	info, err := c.opts.FS.Lstat(abs)
+	if err != nil {
+		return err
+	}
+	action, dest, err := c.remove(abs)
+	if err != nil {
+		return err
+	}
+	if err := c.opts.Undo.add(abs, info.Size(), action, dest); err != nil {
+		return fmt.Errorf("已清理，但写入撤销日志失败: %w", err)
+	}
+	return nil
+}
+
+// remove 删除文件，启用 Quarantine 时移到隔离目录，启用 Trash 时移到回收站，返回清理方式和去向
+func (c *Cleaner) remove(file string) (string, string, error) {
+	if c.quarantine != nil {
+		dest, err := c.quarantine.move(file)
+		return ActionQuarantine, dest, err
+	}
+	if !c.opts.Trash {
+		return ActionDelete, "", c.opts.FS.Remove(file)
+	}
+	if !isOSFS(c.opts.FS) {
+		return ActionTrash, "", errTrashUnsupported
+	}
+	dest, err := moveToTrash(file)
+	return ActionTrash, dest, err
+}
+
+// protected 位于受保护路径中的文件返回 ErrProtectedPath，未允许时位于应用程序管理的目录中的文件返回 ErrRiskyPath
+func (c *Cleaner) protected(file string) error {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return err
+	}
+	if IsProtected(abs, DefaultProtected) || IsProtected(abs, c.opts.Protected) {
+		return fmt.Errorf("%w: 不会清理系统目录或指定保护目录中的文件", ErrProtectedPath)
+	}
+	if app := RiskyApp(abs); app != "" && !c.opts.AllowRisky {
+		return fmt.Errorf("%w: 位于 %s 管理的目录中，清理可能损坏其数据", ErrRiskyPath, app)
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+//go:build unix
+
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import (
+	"io/fs"
+	"path/filepath"
+
+	"golang.org/x/sys/unix"
+)
+
+// checkDeletable 删除文件需要对所在目录有写和执行权限
+func checkDeletable(path string, info fs.FileInfo) error {
+	if err := unix.Access(filepath.Dir(path), unix.W_OK|unix.X_OK); err != nil {
+		return &fs.PathError{Op: "access", Path: filepath.Dir(path), Err: fs.ErrPermission}
+	}
+	return nil
+}
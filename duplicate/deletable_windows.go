@@ -0,0 +1,23 @@
+//go:build windows
+
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import "io/fs"
+
+// checkDeletable 只读文件不能直接删除
+func checkDeletable(path string, info fs.FileInfo) error {
+	if info.Mode().Perm()&0200 == 0 {
+		return &fs.PathError{Op: "remove", Path: path, Err: fs.ErrPermission}
+	}
+	return nil
+}
@@ -0,0 +1,376 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// describeScan 查找 PDF、HTML 标题时读取的开头和结尾长度
+const describeScan = 64 << 10
+
+var (
+	pdfTitle  = regexp.MustCompile(`/Title\s*(\((?:\\.|[^\\)])*\)|<[0-9A-Fa-f\s]*>)`)
+	htmlTitle = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	dcTitle   = regexp.MustCompile(`(?s)<dc:title[^>]*>(.*?)</dc:title>`)
+)
+
+// Describe 提取文件的简要元数据：图片尺寸、音视频时长或文档标题，
+// 用于在清单中帮助判断文件内容，无法识别的格式返回空字符串
+func Describe(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	head := make([]byte, sniffSize)
+	n, err := f.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	head = head[:n]
+	size := info.Size()
+	_, format := DetectType(head)
+	switch format {
+	case "jpeg", "png", "gif":
+		c, _, err := image.DecodeConfig(io.NewSectionReader(f, 0, size))
+		if err != nil {
+			return "", nil
+		}
+		return fmt.Sprintf("图片 %dx%d", c.Width, c.Height), nil
+	case "bmp":
+		if len(head) < 26 {
+			return "", nil
+		}
+		w := int32(binary.LittleEndian.Uint32(head[18:]))
+		h := int32(binary.LittleEndian.Uint32(head[22:]))
+		return fmt.Sprintf("图片 %dx%d", w, abs32(h)), nil
+	case "mp4", "mov", "m4a":
+		d, err := mp4Duration(f, size)
+		if err != nil || d <= 0 {
+			return "", nil
+		}
+		return "时长 " + formatDuration(d), nil
+	case "wav":
+		d, err := wavDuration(f, size)
+		if err != nil || d <= 0 {
+			return "", nil
+		}
+		return "时长 " + formatDuration(d), nil
+	case "pdf":
+		if t := pdfInfoTitle(f, size); t != "" {
+			return "标题 " + t, nil
+		}
+		return "", nil
+	case "ooxml", "odf", "epub":
+		if t := zipTitle(f, size); t != "" {
+			return "标题 " + t, nil
+		}
+		return "", nil
+	}
+	if lower := bytes.ToLower(head); bytes.Contains(lower, []byte("<html")) || bytes.Contains(lower, []byte("<!doctype html")) {
+		buf := make([]byte, min(size, describeScan))
+		n, _ := f.ReadAt(buf, 0)
+		if m := htmlTitle.FindSubmatch(buf[:n]); m != nil {
+			if t := cleanTitle(html.UnescapeString(string(m[1]))); t != "" {
+				return "标题 " + t, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// Describe 为每组选一个可直接读取的文件(不在归档内)提取元数据，记录到 Group.Meta，
+// 返回提取失败的文件的错误
+func (l GroupList) Describe() error {
+	errs := []error{}
+	for i := range l {
+		for _, f := range l[i].Files {
+			if IsArchivePath(f.Path) {
+				continue
+			}
+			meta, err := Describe(f.Path)
+			if err != nil {
+				errs = append(errs, newPathError("提取元数据", f.Path, err))
+				continue
+			}
+			l[i].Meta = meta
+			break
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Preview 返回文本文件的前 lines 行，用于帮助辨认文件，过长的行会被截断；
+// 文件开头含 NUL 或不是合法 UTF-8 时视为二进制文件，返回空字符串
+func Preview(path string, lines int) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	buf := make([]byte, 4096)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	buf = bytes.TrimPrefix(buf[:n], []byte("\xef\xbb\xbf"))
+	if bytes.IndexByte(buf, 0) >= 0 {
+		return "", nil
+	}
+	// 读满时末尾可能截断了多字节字符
+	if n == len(buf) {
+		for i := 0; i < utf8.UTFMax && len(buf) > 0 && !utf8.Valid(buf); i++ {
+			buf = buf[:len(buf)-1]
+		}
+	}
+	if !utf8.Valid(buf) {
+		return "", nil
+	}
+	out := []string{}
+	for _, line := range strings.SplitN(string(buf), "\n", lines+1) {
+		if len(out) == lines {
+			break
+		}
+		line = strings.TrimRight(line, "\r")
+		if r := []rune(line); len(r) > 120 {
+			line = string(r[:120]) + "…"
+		}
+		out = append(out, line)
+	}
+	return strings.TrimRight(strings.Join(out, "\n"), "\n"), nil
+}
+
+// Preview 为每组选一个可直接读取的文本文件，将前 lines 行记录到 Group.Preview，
+// 返回读取失败的文件的错误
+func (l GroupList) Preview(lines int) error {
+	errs := []error{}
+	for i := range l {
+		for _, f := range l[i].Files {
+			if IsArchivePath(f.Path) {
+				continue
+			}
+			preview, err := Preview(f.Path, lines)
+			if err != nil {
+				errs = append(errs, newPathError("读取预览", f.Path, err))
+				continue
+			}
+			l[i].Preview = preview
+			break
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// mp4Duration 读取 moov/mvhd 中的时间刻度和时长
+func mp4Duration(r io.ReaderAt, size int64) (time.Duration, error) {
+	moov, moovSize, err := findBox(r, 0, size, "moov")
+	if err != nil {
+		return 0, err
+	}
+	mvhd, _, err := findBox(r, moov, moov+moovSize, "mvhd")
+	if err != nil {
+		return 0, err
+	}
+	buf := make([]byte, 32)
+	if _, err := r.ReadAt(buf, mvhd); err != nil {
+		return 0, err
+	}
+	var scale, dur uint64
+	if buf[0] == 1 {
+		scale = uint64(binary.BigEndian.Uint32(buf[20:]))
+		dur = binary.BigEndian.Uint64(buf[24:])
+	} else {
+		scale = uint64(binary.BigEndian.Uint32(buf[12:]))
+		dur = uint64(binary.BigEndian.Uint32(buf[16:]))
+	}
+	if scale == 0 {
+		return 0, errors.New("无效的时间刻度")
+	}
+	return time.Duration(float64(dur) / float64(scale) * float64(time.Second)), nil
+}
+
+// findBox 在 [start, end) 范围内查找指定类型的 box，返回其内容的起始位置和长度
+func findBox(r io.ReaderAt, start, end int64, name string) (int64, int64, error) {
+	hdr := make([]byte, 16)
+	for pos := start; pos+8 <= end; {
+		if _, err := r.ReadAt(hdr[:8], pos); err != nil {
+			return 0, 0, err
+		}
+		size := int64(binary.BigEndian.Uint32(hdr))
+		body := pos + 8
+		switch size {
+		case 0:
+			size = end - pos
+		case 1:
+			if _, err := r.ReadAt(hdr[8:16], pos+8); err != nil {
+				return 0, 0, err
+			}
+			size = int64(binary.BigEndian.Uint64(hdr[8:]))
+			body = pos + 16
+		}
+		if size < body-pos {
+			return 0, 0, errors.New("无效的 box 长度")
+		}
+		if string(hdr[4:8]) == name {
+			return body, pos + size - body, nil
+		}
+		pos += size
+	}
+	return 0, 0, fmt.Errorf("未找到 %s", name)
+}
+
+// wavDuration 按 fmt 块的字节率和 data 块的长度计算时长
+func wavDuration(r io.ReaderAt, size int64) (time.Duration, error) {
+	var byteRate, data uint32
+	hdr := make([]byte, 16)
+	for pos := int64(12); pos+8 <= size && (byteRate == 0 || data == 0); {
+		if _, err := r.ReadAt(hdr[:8], pos); err != nil {
+			return 0, err
+		}
+		n := binary.LittleEndian.Uint32(hdr[4:])
+		switch string(hdr[:4]) {
+		case "fmt ":
+			if _, err := r.ReadAt(hdr[:16], pos+8); err != nil {
+				return 0, err
+			}
+			byteRate = binary.LittleEndian.Uint32(hdr[8:])
+		case "data":
+			data = n
+		}
+		pos += 8 + int64(n) + int64(n&1)
+	}
+	if byteRate == 0 {
+		return 0, errors.New("缺少 fmt 块")
+	}
+	return time.Duration(float64(data) / float64(byteRate) * float64(time.Second)), nil
+}
+
+// pdfInfoTitle 在文件开头和结尾查找文档信息字典中的 /Title
+func pdfInfoTitle(r io.ReaderAt, size int64) string {
+	n := min(size, describeScan)
+	buf := make([]byte, n)
+	for _, off := range []int64{size - n, 0} {
+		m, _ := r.ReadAt(buf, off)
+		if t := pdfTitle.FindSubmatch(buf[:m]); t != nil {
+			return cleanTitle(pdfString(t[1]))
+		}
+	}
+	return ""
+}
+
+// pdfString 解码 PDF 的字面量字符串或十六进制字符串，支持 UTF-16BE
+func pdfString(s []byte) string {
+	var b []byte
+	if s[0] == '<' {
+		digits := strings.Join(strings.Fields(string(s[1:len(s)-1])), "")
+		if len(digits)%2 == 1 {
+			digits += "0"
+		}
+		b, _ = hex.DecodeString(digits)
+	} else {
+		raw := s[1 : len(s)-1]
+		for i := 0; i < len(raw); i++ {
+			if raw[i] == '\\' && i+1 < len(raw) {
+				i++
+				switch raw[i] {
+				case 'n':
+					b = append(b, '\n')
+				case 'r':
+					b = append(b, '\r')
+				case 't':
+					b = append(b, '\t')
+				default:
+					b = append(b, raw[i])
+				}
+				continue
+			}
+			b = append(b, raw[i])
+		}
+	}
+	if len(b) >= 2 && b[0] == 0xfe && b[1] == 0xff {
+		u := make([]uint16, (len(b)-2)/2)
+		for i := range u {
+			u[i] = binary.BigEndian.Uint16(b[2+2*i:])
+		}
+		return string(utf16.Decode(u))
+	}
+	return string(b)
+}
+
+// zipTitle 读取 Office(docProps/core.xml)、OpenDocument(meta.xml) 或 EPUB(.opf) 中的 dc:title
+func zipTitle(r io.ReaderAt, size int64) string {
+	z, err := zip.NewReader(r, size)
+	if err != nil {
+		return ""
+	}
+	for _, zf := range z.File {
+		if zf.Name != "docProps/core.xml" && zf.Name != "meta.xml" && !strings.HasSuffix(zf.Name, ".opf") {
+			continue
+		}
+		rc, err := zf.Open()
+		if err != nil {
+			continue
+		}
+		data, _ := io.ReadAll(io.LimitReader(rc, describeScan))
+		rc.Close()
+		if m := dcTitle.FindSubmatch(data); m != nil {
+			return cleanTitle(html.UnescapeString(string(m[1])))
+		}
+	}
+	return ""
+}
+
+// cleanTitle 合并空白字符，过长的标题截断
+func cleanTitle(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > 80 {
+		s = string(r[:80]) + "…"
+	}
+	return s
+}
+
+// formatDuration 格式化为 m:ss 或 h:mm:ss
+func formatDuration(d time.Duration) string {
+	sec := int64(d.Round(time.Second) / time.Second)
+	if sec >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", sec/3600, sec/60%60, sec%60)
+	}
+	return fmt.Sprintf("%d:%02d", sec/60, sec%60)
+}
+
+func abs32(v int32) int32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
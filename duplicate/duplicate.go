@@ -12,6 +12,7 @@ license that can be found in the LICENSE file.
 package duplicate
 
 import (
+	"context"
 	"crypto/md5"
 	"crypto/sha1"
 	"crypto/sha256"
@@ -22,92 +23,241 @@ import (
 	"hash"
 	"io"
 	"io/fs"
-	"log"
-	"os"
 	"path/filepath"
+	"sort"
 	"strings"
-	"sync"
+	"time"
 
-	"github.com/schollz/progressbar/v3"
+	"github.com/cespare/xxhash/v2"
+	"github.com/zeebo/blake3"
+	"github.com/zeebo/xxh3"
 )
 
 // 单个文件信息
 type FileInfo struct {
-	Path string
-	Size int64
-	Hash string
+	Path  string `json:"path"`
+	Size  int64  `json:"size"`
+	Hash  string `json:"hash"`
+	Type  string `json:"type,omitempty"`  // 根据文件内容判断的类别，未启用类型检测时为空
+	Owner string `json:"owner,omitempty"` // 文件所有者，未启用时为空
+	URI   string `json:"uri,omitempty"`   // file:// 形式的路径，仅用于输出，见 FileURI
+
+	ModTime   time.Time `json:"-"` // 扫描时的修改时间
+	Signature string    `json:"-"` // 快速签名，仅在 Options.Signatures 时计算
 }
 
 type FileInfos []FileInfo
 
+// Group 一组内容相同的文件
+type Group struct {
+	Hash     string    `json:"hash"`
+	Size     int64     `json:"size"`
+	Strategy string    `json:"strategy,omitempty"` // 启用自适应策略时该组使用的比较策略
+	Meta     string    `json:"meta,omitempty"`     // 代表文件的元数据摘要，见 GroupList.Describe
+	Preview  string    `json:"preview,omitempty"`  // 文本文件开头几行的内容，见 GroupList.Preview
+	Skip     bool      `json:"skip,omitempty"`     // 清单中标记为整组跳过清理，读取清单时去掉
+	Files    FileInfos `json:"files"`
+}
+
+// GroupList 按顺序排列的各组重复文件，Scanner 的结果和清单都使用这种形式
+type GroupList []Group
+
+// DupList 按Hash值索引的重复文件，List 返回的形式，可用 Groups 转为 GroupList
 type DupList map[string]FileInfos
 
-// List 获取重复文件的列表
-func List(dirs []string, hashName string, n int) (DupList, error) {
-	fs, err := walkDirs(dirs)
-	if err != nil {
-		return nil, err
+// Map 转为按Hash值索引的 DupList，Hash值相同的组合并，组的策略、元数据等信息不保留
+func (l GroupList) Map() DupList {
+	d := DupList{}
+	for _, g := range l {
+		d[g.Hash] = append(d[g.Hash], g.Files...)
 	}
-	fs = groupBySize(fs)
-	err = calcHashs(fs, hashName, n)
-	lst := groupByHash(fs)
-	return lst, err
+	return d
 }
 
-// Clean 删除重复的文件
-func Clean(files []string) (int, error) {
-	if len(files) == 0 {
-		return 0, nil
+// Groups 转为按文件大小从大到小、再按Hash值排列的 GroupList
+func (d DupList) Groups() GroupList {
+	l := GroupList{}
+	for h, files := range d {
+		g := Group{Hash: h, Files: files}
+		if len(files) > 0 {
+			g.Size = files[0].Size
+		}
+		l = append(l, g)
 	}
-	n := 0
-	errs := []error{}
-	bar := progressbar.Default(int64(len(files)), "清理文件")
-	defer bar.Close()
-	for _, file := range files {
-		err := os.Remove(file)
-		bar.Add(1)
-		if err != nil {
-			errs = append(errs, fmt.Errorf("文件%s清理失败: %v", file, err))
-		} else {
-			n += 1
+	sort.Slice(l, func(i, j int) bool {
+		if l[i].Size != l[j].Size {
+			return l[i].Size > l[j].Size
+		}
+		return l[i].Hash < l[j].Hash
+	})
+	return l
+}
+
+// MinCopies 返回至少有 n 个文件的组
+func (l GroupList) MinCopies(n int) GroupList {
+	lst := GroupList{}
+	for _, g := range l {
+		if len(g.Files) >= n {
+			lst = append(lst, g)
 		}
 	}
-	return n, errors.Join(errs...)
+	return lst
+}
+
+// URIs 为每个文件记录 file:// 形式的路径(见 FileURI)，JSON 格式的输出中会一并写出
+func (l GroupList) URIs() {
+	for _, g := range l {
+		for i := range g.Files {
+			g.Files[i].URI = FileURI(g.Files[i].Path)
+		}
+	}
+}
+
+// List 获取重复文件的列表，需要各组的顺序和其他信息时使用 Scanner.List
+func List(dirs []string, hashName string, n int) (DupList, error) {
+	l, err := NewScanner(dirs, Options{Hash: hashName, Count: n, Progress: true}).List()
+	return l.Map(), err
+}
+
+// Clean 删除重复的文件
+func Clean(files []string) (int, error) {
+	return NewCleaner(CleanOptions{Progress: true}).Clean(files)
 }
 
-// walkDirs 遍历指定目录获取文件信息
-func walkDirs(dirs []string) ([]*FileInfo, error) {
+// walkDirs 遍历扫描路径获取文件信息，扫描路径可以是目录或单个文件，ctx 取消时中止遍历
+func (s *Scanner) walkDirs(ctx context.Context) ([]*FileInfo, error) {
+	dirs, opts := s.dirs, &s.opts
 	if len(dirs) == 0 {
-		return nil, errors.Join(errors.New("目录未指定"))
+		return nil, errors.Join(errors.New("扫描路径未指定"))
 	}
 	var files []*FileInfo
-	bar := progressbar.Default(-1, "遍历文件")
+	var total int64
+	bar := newTracker(opts.Progress, opts.OnProgress, StageWalk, "遍历文件", -1, 0)
 	defer bar.Close()
-	for _, dir := range dirs {
-		absDir, err := filepath.Abs(dir)
-		if err != nil {
-			log.Printf("无法获取绝对路径: %v", err)
-			continue
-		}
-		err = filepath.Walk(absDir, func(path string, info fs.FileInfo, err error) error {
-			bar.Add(1)
-			// 跳过无法访问的目录
+	// 嵌套的扫描路径只遍历最外层，避免同一文件被当作自身的重复
+	// 路径已解析为真实路径，且遍历时不跟随符号链接，经不同链接指向的同一文件只会出现一次；
+	// 跟随指向目录的符号链接时，同一目录也只会进入一次
+	// 非本机文件系统的路径不在本机上解析
+	canonical := CanonicalPath
+	if !isOSFS(opts.FS) {
+		canonical = filepath.Clean
+	}
+	roots, _ := mergeRoots(dirs, canonical)
+	sortRootsByPriority(roots, opts.Priorities)
+	limit := newRateLimiter(opts.WalkQPS, opts.Clock)
+	var follow *linkFollower
+	if opts.FollowSymlinks {
+		follow = newLinkFollower(opts.FS)
+	}
+	var bound *fsBoundary
+	if opts.OneFileSystem && isOSFS(opts.FS) {
+		bound = newFSBoundary()
+	}
+	var ignores *ignoreTree
+	if len(opts.IgnoreFiles) > 0 {
+		ignores = newIgnoreTree(opts.FS, opts.IgnoreFiles)
+	}
+	for _, absDir := range roots {
+		err := walkTree(opts.FS, absDir, limit, follow, func(path string, info fs.FileInfo, depth int, err error) error {
+			bar.Add(1, 0)
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			// 跳过无法访问的目录(包括路径过长等)
 			if err != nil {
+				opts.onError(newPathError("遍历", path, err))
 				return filepath.SkipDir
 			}
+			s.stats.MaxDepth = max(s.stats.MaxDepth, depth)
 			// 跳过代码库
 			if info.IsDir() && (strings.EqualFold(filepath.Base(path), ".git") || strings.EqualFold(filepath.Base(path), ".svn")) {
 				return filepath.SkipDir
 			}
-			//跳过特殊文件
+			// 跳过排除的目录，扫描路径本身总会被扫描
+			if info.IsDir() && depth > 0 && matchExclude(path, opts.Exclude) {
+				return filepath.SkipDir
+			}
+			if bound != nil && info.IsDir() {
+				if depth == 0 {
+					bound.setRoot(path, info)
+				} else if bound.crosses(path, info) {
+					s.stats.Mounts = append(s.stats.Mounts, path)
+					return filepath.SkipDir
+				}
+			}
+			// 达到层数上限的目录不再进入
+			if info.IsDir() && opts.MaxDepth > 0 && depth >= opts.MaxDepth {
+				return filepath.SkipDir
+			}
+			if depth > 0 && opts.SkipHidden && isHidden(path, info) {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if depth > 0 && ignores != nil && ignores.ignored(absDir, path, info.IsDir()) {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if info.IsDir() {
+				s.stats.Dirs += 1
+				if ignores != nil {
+					if err := ignores.load(path, limit); err != nil {
+						opts.onError(err)
+					}
+				}
+				return nil
+			}
+			//跳过特殊文件，直接指定为扫描路径的给出提示
 			if !info.Mode().IsRegular() {
+				if depth == 0 {
+					opts.onError(newPathError("遍历", path, errors.New("不是目录或普通文件")))
+				}
+				return nil
+			}
+			if depth > 0 && (matchExclude(path, opts.Skip) || (len(opts.Include) > 0 && !matchExclude(path, opts.Include))) {
+				return nil
+			}
+			if depth > 0 && (info.Size() < opts.MinSize || (opts.MaxSize > 0 && info.Size() > opts.MaxSize)) {
+				return nil
+			}
+			if depth > 0 && len(opts.Exts) > 0 && !matchExt(path, opts.Exts) {
+				return nil
+			}
+			if depth > 0 && ((!opts.ModifiedBefore.IsZero() && !info.ModTime().Before(opts.ModifiedBefore)) ||
+				(!opts.ModifiedAfter.IsZero() && !info.ModTime().After(opts.ModifiedAfter))) {
 				return nil
 			}
 			if info.Size() > 0 {
-				files = append(files, &FileInfo{
-					Path: path,
-					Size: info.Size(),
-				})
+				f := &FileInfo{
+					Path:    path,
+					Size:    info.Size(),
+					ModTime: info.ModTime(),
+				}
+				if opts.Owners {
+					f.Owner = fileOwner(path, info)
+				}
+				// 直接指定为扫描路径的文件总是加入候选，类别只用于记录
+				if ok, err := s.detectType(f); err != nil || (!ok && depth > 0) {
+					if err != nil {
+						opts.onError(newPathError("检测类型", path, err))
+					}
+					return nil
+				}
+				files = append(files, f)
+				bar.Add(0, f.Size)
+				total += f.Size
+				if opts.MaxFiles > 0 && len(files) > opts.MaxFiles {
+					return fmt.Errorf("%w: 文件数超过 %d，已中止扫描，请确认扫描路径是否正确", ErrLimitExceeded, opts.MaxFiles)
+				}
+				if opts.MaxBytes > 0 && total > opts.MaxBytes {
+					return fmt.Errorf("%w: 文件总大小超过 %d 字节，已中止扫描，请确认扫描路径是否正确", ErrLimitExceeded, opts.MaxBytes)
+				}
+				if opts.OnFileScanned != nil {
+					opts.OnFileScanned(*f)
+				}
 			}
 			return nil
 		})
@@ -128,15 +278,69 @@ func newHash(hashName string) hash.Hash {
 		h = sha256.New()
 	case "sha512":
 		h = sha512.New()
+	case "blake3":
+		h = blake3.New()
+	case "xxh64":
+		h = xxhash.New()
+	case "xxh128":
+		h = xxh128{xxh3.New()}
 	default:
 		h = md5.New()
 	}
 	return h
 }
 
+// xxh128 输出 128 位结果的 XXH3，xxh3.Hasher 的 Sum 只给出 64 位
+type xxh128 struct {
+	*xxh3.Hasher
+}
+
+func (h xxh128) Size() int { return 16 }
+
+func (h xxh128) Sum(b []byte) []byte {
+	sum := h.Sum128().Bytes()
+	return append(b, sum[:]...)
+}
+
+// HashFile 用指定算法计算普通文件的完整Hash值
+func HashFile(path string, hashName string) (string, error) {
+	return hashFile(OSFS{}, path, hashName)
+}
+
+func hashFile(fsys FileSystem, path string, hashName string) (string, error) {
+	sums, err := hashFileMulti(fsys, path, []string{hashName})
+	if err != nil {
+		return "", err
+	}
+	return sums[0], nil
+}
+
+// hashFileMulti 读取一次文件，同时计算多种算法的Hash值
+func hashFileMulti(fsys FileSystem, path string, hashNames []string) ([]string, error) {
+	f, err := fsys.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	hs := make([]hash.Hash, len(hashNames))
+	ws := make([]io.Writer, len(hashNames))
+	for i, name := range hashNames {
+		hs[i] = newHash(name)
+		ws[i] = hs[i]
+	}
+	if _, err := io.Copy(io.MultiWriter(ws...), f); err != nil {
+		return nil, err
+	}
+	sums := make([]string, len(hs))
+	for i, h := range hs {
+		sums[i] = hex.EncodeToString(h.Sum(nil))
+	}
+	return sums, nil
+}
+
 // calcHash 计算文件的Hash值
-func calcHash(file string, h hash.Hash) (string, error) {
-	f, err := os.Open(file)
+func (s *Scanner) calcHash(file string, h hash.Hash) (string, error) {
+	f, err := s.open(file)
 	if err != nil {
 		return "", errors.Join(err)
 	}
@@ -148,89 +352,56 @@ func calcHash(file string, h hash.Hash) (string, error) {
 	return hex.EncodeToString(h.Sum(nil)), nil
 }
 
-// calcHashs 并行计算多个文件的Hash值
-func calcHashs(files []*FileInfo, hashName string, n int) error {
+// groupByHash 按Hash值进行分组，并删除文件数少于 minCopies 的组
+func groupByHash(files []*FileInfo, minCopies int) GroupList {
 	if len(files) == 0 {
 		return nil
 	}
-	g := sync.WaitGroup{}
-	c := make(chan struct{}, n)
-	m := sync.Mutex{}
-	errs := []error{}
-	bar := progressbar.Default(int64(len(files)), "计算Hash值")
-	defer bar.Close()
-	for _, file := range files {
-		g.Add(1)
-		go func(f *FileInfo) {
-			defer g.Done()
-			c <- struct{}{}
-			defer func() { <-c }()
-			// hash.Hash接口不是并发安全的，要在协程内实例化
-			h := newHash(hashName)
-			hashValue, err := calcHash(f.Path, h)
-			bar.Add(1)
-			if err != nil {
-				m.Lock()
-				errs = append(errs, fmt.Errorf("计算文件 %s 的Hash值失败: %v", f.Path, err))
-				m.Unlock()
-			} else {
-				f.Hash = hashValue
-			}
-		}(file)
-	}
-	g.Wait()
-	return errors.Join(errs...)
-}
-
-// groupByHash 按Hash值进行分组，并删除Hash值唯一的记录
-func groupByHash(files []*FileInfo) DupList {
-	if len(files) == 0 {
-		return nil
-	}
-	group := DupList{}
-	counts := map[string]int{}
-	bar := progressbar.Default(-1, "按Hash值分组")
-	defer bar.Close()
+	group := map[string]FileInfos{}
 	for _, file := range files {
 		if file.Hash != "" {
 			group[file.Hash] = append(group[file.Hash], *file)
-			counts[file.Hash] += 1
-			bar.Add(1)
 		}
 	}
-	bar.Clear()
-	bar.Describe("剔除孤立组")
-	for k, v := range counts {
-		if v == 1 {
-			delete(group, k)
-			bar.Add(1)
+	lst := GroupList{}
+	for k, v := range group {
+		if len(v) >= minCopies {
+			lst = append(lst, Group{Hash: k, Size: v[0].Size, Files: v})
 		}
 	}
-	return group
+	sort.Slice(lst, func(i, j int) bool { return lst[i].Hash < lst[j].Hash })
+	return lst
 }
 
-// groupBySize 按大小进行分组，并删除大小唯一的记录
-func groupBySize(files []*FileInfo) []*FileInfo {
+// groupBySize 按大小进行分组，并删除文件数少于 minCopies 的组，大文件的组排在前面。
+// byExt 为 true 时先按扩展名分桶，扩展名不同的文件不会进入同一组
+func groupBySize(files []*FileInfo, byExt bool, minCopies int) [][]*FileInfo {
 	if len(files) == 0 {
 		return nil
 	}
-	group := map[int64][]*FileInfo{}
-	newFiles := []*FileInfo{}
-	bar := progressbar.Default(-1, "按大小分组")
-	defer bar.Close()
+	type key struct {
+		size int64
+		ext  string
+	}
+	group := map[key][]*FileInfo{}
 	for _, file := range files {
-		group[file.Size] = append(group[file.Size], file)
-		bar.Add(1)
+		k := key{size: file.Size}
+		if byExt {
+			k.ext = strings.ToLower(filepath.Ext(file.Path))
+		}
+		group[k] = append(group[k], file)
 	}
-	bar.Clear()
-	bar.Describe("剔除孤立组")
-	for k, v := range group {
-		if len(v) == 1 {
-			delete(group, k)
-			bar.Add(1)
-		} else {
-			newFiles = append(newFiles, v...)
+	groups := [][]*FileInfo{}
+	for _, v := range group {
+		if len(v) >= minCopies {
+			groups = append(groups, v)
 		}
 	}
-	return newFiles
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i][0].Size != groups[j][0].Size {
+			return groups[i][0].Size > groups[j][0].Size
+		}
+		return groups[i][0].Path < groups[j][0].Path
+	})
+	return groups
 }
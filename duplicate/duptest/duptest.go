@@ -0,0 +1,164 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+// Package duptest 提供内存文件系统和手动推进的时钟，
+// 便于嵌入 duplicate 包的程序在不访问真实磁盘、结果可重复的条件下进行测试。
+//
+//	clock := duptest.NewClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+//	fsys := duptest.NewFS(clock)
+//	fsys.WriteFile("/data/a.txt", []byte("hello"))
+//	fsys.WriteFile("/data/b.txt", []byte("hello"))
+//	l, err := duplicate.NewScanner([]string{"/data"}, duptest.Options(fsys)).List()
+package duptest
+
+import (
+	"duplicate-cleaner/duplicate"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing/fstest"
+	"time"
+)
+
+// Clock 手动推进的时钟，Sleep 不会真正等待，只是把时间向后推
+type Clock struct {
+	m   sync.Mutex
+	now time.Time
+}
+
+// NewClock 创建从 t 开始的时钟
+func NewClock(t time.Time) *Clock {
+	return &Clock{now: t}
+}
+
+func (c *Clock) Now() time.Time {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.now
+}
+
+func (c *Clock) Sleep(d time.Duration) {
+	c.Advance(d)
+}
+
+// Advance 将时间向后推 d
+func (c *Clock) Advance(d time.Duration) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// FS 内存文件系统，实现 duplicate.FileSystem，可并发使用。
+// 路径为本机格式的绝对路径，上级目录无需创建，写入文件时自动存在
+type FS struct {
+	m     sync.RWMutex
+	files fstest.MapFS
+	clock duplicate.Clock
+}
+
+// NewFS 创建空的内存文件系统，文件的修改时间取自 clock，clock 为空时使用系统时钟
+func NewFS(clock duplicate.Clock) *FS {
+	if clock == nil {
+		clock = duplicate.SystemClock{}
+	}
+	return &FS{files: fstest.MapFS{}, clock: clock}
+}
+
+// key 将本机路径转换为 MapFS 使用的相对路径，根目录为 "."
+func key(name string) string {
+	name = filepath.ToSlash(strings.TrimPrefix(name, filepath.VolumeName(name)))
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if name == "" {
+		return "."
+	}
+	return name
+}
+
+// WriteFile 写入文件，已存在时覆盖，修改时间为当前时钟
+func (f *FS) WriteFile(name string, data []byte) {
+	f.m.Lock()
+	defer f.m.Unlock()
+	f.files[key(name)] = &fstest.MapFile{Data: append([]byte{}, data...), Mode: 0644, ModTime: f.clock.Now()}
+}
+
+// Mkdir 创建空目录
+func (f *FS) Mkdir(name string) {
+	f.m.Lock()
+	defer f.m.Unlock()
+	f.files[key(name)] = &fstest.MapFile{Mode: fs.ModeDir | 0755, ModTime: f.clock.Now()}
+}
+
+// Exists 文件或目录是否存在
+func (f *FS) Exists(name string) bool {
+	_, err := f.Lstat(name)
+	return err == nil
+}
+
+// Open 实现 duplicate.FileSystem
+func (f *FS) Open(name string) (fs.File, error) {
+	f.m.RLock()
+	defer f.m.RUnlock()
+	file, err := f.files.Open(key(name))
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: unwrap(err)}
+	}
+	return file, nil
+}
+
+// Lstat 实现 duplicate.FileSystem
+func (f *FS) Lstat(name string) (fs.FileInfo, error) {
+	f.m.RLock()
+	defer f.m.RUnlock()
+	info, err := f.files.Stat(key(name))
+	if err != nil {
+		return nil, &fs.PathError{Op: "lstat", Path: name, Err: unwrap(err)}
+	}
+	return info, nil
+}
+
+// Remove 实现 duplicate.FileSystem，只能删除文件和空目录
+func (f *FS) Remove(name string) error {
+	f.m.Lock()
+	defer f.m.Unlock()
+	k := key(name)
+	info, err := f.files.Stat(k)
+	if err != nil {
+		return &fs.PathError{Op: "remove", Path: name, Err: unwrap(err)}
+	}
+	if info.IsDir() {
+		for p := range f.files {
+			if strings.HasPrefix(p, k+"/") {
+				return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrExist}
+			}
+		}
+	}
+	delete(f.files, k)
+	return nil
+}
+
+// unwrap 取出 MapFS 返回的 PathError 中的错误，以便换成本机路径
+func unwrap(err error) error {
+	if pe, ok := err.(*fs.PathError); ok {
+		return pe.Err
+	}
+	return err
+}
+
+// Options 返回使用 fsys 及其时钟、只有一个并发的扫描选项，各回调的调用顺序和结果都是固定的
+func Options(fsys *FS) duplicate.Options {
+	return duplicate.Options{Count: 1, FS: fsys, Clock: fsys.clock}
+}
+
+// CleanOptions 返回使用 fsys 的清理选项
+func CleanOptions(fsys *FS) duplicate.CleanOptions {
+	return duplicate.CleanOptions{FS: fsys}
+}
@@ -0,0 +1,172 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate_test
+
+import (
+	"bytes"
+	"duplicate-cleaner/duplicate"
+	"duplicate-cleaner/duplicate/duptest"
+	"errors"
+	"io/fs"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// newData 创建含一组重复文件(a.txt、sub/b.txt)和一个唯一文件的内存文件系统
+func newData() (*duptest.Clock, *duptest.FS) {
+	clock := duptest.NewClock(start)
+	fsys := duptest.NewFS(clock)
+	fsys.WriteFile("/data/a.txt", []byte("hello"))
+	fsys.WriteFile("/data/sub/b.txt", []byte("hello"))
+	fsys.WriteFile("/data/c.txt", []byte("world"))
+	return clock, fsys
+}
+
+func TestScan(t *testing.T) {
+	_, fsys := newData()
+	l, err := duplicate.NewScanner([]string{"/data"}, duptest.Options(fsys)).List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(l) != 1 || len(l[0].Files) != 2 {
+		t.Fatalf("应有 1 组 2 个重复文件，实际为 %+v", l)
+	}
+	for _, f := range l[0].Files {
+		if f.Size != 5 || !f.ModTime.Equal(start) {
+			t.Errorf("%s 的大小或修改时间不对: %d %v", f.Path, f.Size, f.ModTime)
+		}
+	}
+	if d := l.Map(); len(d[l[0].Hash]) != 2 {
+		t.Errorf("转换为 DupList 后应有 2 个文件，实际为 %v", d)
+	}
+}
+
+func TestWalkQPS(t *testing.T) {
+	clock, fsys := newData()
+	opts := duptest.Options(fsys)
+	if _, err := duplicate.NewScanner([]string{"/data"}, opts).List(); err != nil {
+		t.Fatal(err)
+	}
+	if !clock.Now().Equal(start) {
+		t.Fatalf("不限速时不应等待，时钟走到了 %v", clock.Now())
+	}
+	opts.WalkQPS = 1
+	began := time.Now()
+	if _, err := duplicate.NewScanner([]string{"/data"}, opts).List(); err != nil {
+		t.Fatal(err)
+	}
+	// 至少有：扫描路径和子目录的信息、两次读取目录、三个文件的信息，首次请求无需等待
+	if waited := clock.Now().Sub(start); waited < 6*time.Second {
+		t.Errorf("每秒 1 次请求时应至少等待 6 秒，实际为 %v", waited)
+	}
+	if real := time.Since(began); real > time.Second {
+		t.Errorf("限速等待应通过时钟进行，实际耗时 %v", real)
+	}
+}
+
+func TestClean(t *testing.T) {
+	_, fsys := newData()
+	cleaned := []string{}
+	opts := duptest.CleanOptions(fsys)
+	opts.OnCleaned = func(path string) { cleaned = append(cleaned, path) }
+	n, err := duplicate.NewCleaner(opts).Clean([]string{"/data/sub/b.txt"})
+	if err != nil || n != 1 {
+		t.Fatalf("应清理 1 个文件，实际为 %d: %v", n, err)
+	}
+	if fsys.Exists("/data/sub/b.txt") || !fsys.Exists("/data/a.txt") {
+		t.Error("只有 b.txt 应被删除")
+	}
+	if len(cleaned) != 1 || cleaned[0] != "/data/sub/b.txt" {
+		t.Errorf("OnCleaned 收到 %v", cleaned)
+	}
+	l, err := duplicate.NewScanner([]string{"/data"}, duptest.Options(fsys)).List()
+	if err != nil || len(l) != 0 {
+		t.Errorf("清理后不应再有重复文件: %v %v", l, err)
+	}
+}
+
+func TestCleanMissing(t *testing.T) {
+	_, fsys := newData()
+	n, err := duplicate.NewCleaner(duptest.CleanOptions(fsys)).Clean([]string{"/data/missing.txt", "/data/c.txt"})
+	if n != 1 || !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("不存在的文件应报错且不影响其他文件，实际清理 %d 个: %v", n, err)
+	}
+	var pe *duplicate.PathError
+	if !errors.As(err, &pe) || pe.Path != "/data/missing.txt" {
+		t.Errorf("错误应指明出错的文件: %v", err)
+	}
+	opts := duptest.CleanOptions(fsys)
+	opts.IgnoreMissing = true
+	if n, err := duplicate.NewCleaner(opts).Clean([]string{"/data/missing.txt"}); n != 1 || err != nil {
+		t.Errorf("IgnoreMissing 时不存在的文件视为已清理，实际为 %d: %v", n, err)
+	}
+}
+
+func TestScanError(t *testing.T) {
+	_, fsys := newData()
+	errs := []error{}
+	opts := duptest.Options(fsys)
+	opts.OnError = func(err error) { errs = append(errs, err) }
+	l, err := duplicate.NewScanner([]string{"/data", "/nowhere"}, opts).List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(l) != 1 {
+		t.Errorf("无法访问的扫描路径不应影响其他路径: %v", l)
+	}
+	if len(errs) != 1 || !errors.Is(errs[0], fs.ErrNotExist) {
+		t.Errorf("应报告扫描路径不存在: %v", errs)
+	}
+}
+
+func TestAdaptiveStrategy(t *testing.T) {
+	fsys := duptest.NewFS(duptest.NewClock(start))
+	big := bytes.Repeat([]byte("x"), 100<<10)
+	// 三个相同的大文件；两个开头相同、结尾不同；一个开头就不同
+	for _, name := range []string{"a", "b", "c"} {
+		fsys.WriteFile("/big/"+name, big)
+	}
+	for i, name := range []string{"d", "e"} {
+		data := bytes.Clone(big)
+		data[len(data)-1] = byte('0' + i)
+		fsys.WriteFile("/big/"+name, data)
+	}
+	data := bytes.Clone(big)
+	data[0] = 'y'
+	fsys.WriteFile("/big/f", data)
+	fsys.WriteFile("/small/a", []byte("hello"))
+	fsys.WriteFile("/small/b", []byte("hello"))
+	opts := duptest.Options(fsys)
+	opts.Adaptive = true
+	l, err := duplicate.NewScanner([]string{"/big", "/small"}, opts).List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[int64]string{int64(len(big)): duplicate.StrategyQuick, 5: duplicate.StrategyFull}
+	if len(l) != 2 {
+		t.Fatalf("应找到 2 组重复文件: %v", l)
+	}
+	for _, g := range l {
+		if g.Strategy != want[g.Size] {
+			t.Errorf("大小为 %d 的组应使用 %s，实际为 %s", g.Size, want[g.Size], g.Strategy)
+		}
+		if g.Size == int64(len(big)) && len(g.Files) != 3 {
+			t.Errorf("只有内容完全相同的 3 个大文件应列为重复: %v", g.Files)
+		}
+	}
+	opts.Prefilter = true
+	l, err = duplicate.NewScanner([]string{"/big"}, opts).List()
+	if err != nil || len(l) != 1 || l[0].Strategy != duplicate.StrategyFull || len(l[0].Files) != 3 {
+		t.Errorf("已预筛时应直接计算完整Hash值: %v %v", l, err)
+	}
+}
@@ -0,0 +1,82 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+)
+
+// 错误分类，可通过 errors.Is 判断
+var (
+	ErrPermission    = errors.New("权限不足")
+	ErrNotFound      = errors.New("文件不存在")
+	ErrHashMismatch  = errors.New("Hash值不匹配")
+	ErrProtectedPath = errors.New("受保护的路径")
+	ErrRiskyPath     = errors.New("应用程序管理的路径")
+	ErrLimitExceeded = errors.New("超出扫描上限")
+	ErrVersion       = errors.New("文件版本不兼容")
+
+	ErrCloneUnsupported = errors.New("文件系统不支持克隆") // 见 Cleaner.Reflink
+)
+
+// CheckVersion 检查持久化文件(索引、轨迹、清单等)的版本，oldest 到 current 之间的旧版本
+// 由读取方自动迁移；更新的版本提示升级程序，更旧的版本提示重新生成
+func CheckVersion(kind string, v, oldest, current int) error {
+	switch {
+	case v > current:
+		return fmt.Errorf("%w: %s为 v%d，由更新版本的 duplicate-cleaner 生成，本程序最高支持 v%d，请升级后再使用", ErrVersion, kind, v, current)
+	case v < oldest:
+		return fmt.Errorf("%w: %s为 v%d，已不再支持(最低 v%d)，请重新生成", ErrVersion, kind, v, oldest)
+	}
+	return nil
+}
+
+// PathError 针对单个路径的操作错误，同时包装错误分类和原始错误
+type PathError struct {
+	Op   string // 出错的操作
+	Path string // 出错的路径
+	Kind error  // 错误分类，无法归类时为 nil
+	Err  error  // 原始错误
+}
+
+func (e *PathError) Error() string {
+	return fmt.Sprintf("%s %s 失败: %v", e.Op, e.Path, e.Err)
+}
+
+func (e *PathError) Unwrap() []error {
+	if e.Kind == nil {
+		return []error{e.Err}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// newPathError 创建 PathError 并根据原始错误自动归类
+func newPathError(op, path string, err error) *PathError {
+	return &PathError{Op: op, Path: path, Kind: classify(err), Err: err}
+}
+
+// classify 将原始错误归入已知分类
+func classify(err error) error {
+	for _, kind := range []error{ErrPermission, ErrNotFound, ErrHashMismatch, ErrProtectedPath, ErrRiskyPath} {
+		if errors.Is(err, kind) {
+			return kind
+		}
+	}
+	switch {
+	case errors.Is(err, fs.ErrPermission):
+		return ErrPermission
+	case errors.Is(err, fs.ErrNotExist):
+		return ErrNotFound
+	}
+	return nil
+}
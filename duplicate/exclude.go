@@ -0,0 +1,143 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Presets 预置的排除目录，这些目录由应用程序管理，在其中去重会破坏应用的数据。
+// 不含 / 的模式匹配任意位置的目录名，含 / 的模式匹配路径末尾的若干级目录，以 / 开头的模式匹配完整路径，
+// 各级均可使用 filepath.Match 的通配符，不区分大小写
+var Presets = map[string][]string{
+	// 开发工具的依赖和缓存
+	"dev": {
+		"node_modules", "bower_components", ".npm", ".yarn/cache", ".pnpm-store",
+		".m2/repository", ".gradle/caches", ".ivy2/cache",
+		".cache/pip", "AppData/Local/pip/Cache", "Library/Caches/pip", "__pycache__", ".venv", ".tox",
+		".cargo/registry", ".rustup", "go/pkg/mod", ".nuget/packages", ".cache/go-build",
+	},
+	// 浏览器缓存和配置、游戏库、邮件存储
+	"home": {
+		".mozilla", ".cache/mozilla", ".config/google-chrome", ".cache/google-chrome",
+		".config/chromium", ".cache/chromium", ".config/microsoft-edge",
+		"Library/Caches", "Library/Application Support/Google/Chrome", "Library/Application Support/Firefox",
+		"AppData/Local/Google/Chrome", "AppData/Local/Microsoft/Edge", "AppData/Roaming/Mozilla", "AppData/Local/Mozilla",
+		"steamapps", ".steam", ".local/share/Steam", "Library/Application Support/Steam",
+		"Library/Mail", ".thunderbird", "AppData/Roaming/Thunderbird", ".local/share/evolution", "Outlook Files",
+	},
+	// 服务器上的容器、数据库和包管理器数据，以及 NAS 的系统目录
+	"server": {
+		"/var/lib/docker", "/var/lib/containerd", "/var/lib/containers", "/var/lib/mysql", "/var/lib/postgresql",
+		"/var/lib/mongodb", "/var/lib/apt", "/var/lib/dpkg", "/var/lib/rpm", "/var/cache",
+		"lost+found", "@eaDir", "#recycle", ".snapshot", ".zfs",
+	},
+}
+
+// PresetNames 返回所有预置排除的名称
+func PresetNames() []string {
+	names := make([]string, 0, len(Presets))
+	for name := range Presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PresetExcludes 合并指定的预置排除
+func PresetExcludes(names []string) ([]string, error) {
+	patterns := []string{}
+	for _, name := range names {
+		p, ok := Presets[name]
+		if !ok {
+			return nil, fmt.Errorf("不支持的预置排除: %s，可选: %s", name, strings.Join(PresetNames(), " | "))
+		}
+		patterns = append(patterns, p...)
+	}
+	return patterns, nil
+}
+
+// matchExclude 路径是否匹配任一排除模式
+func matchExclude(path string, patterns []string) bool {
+	elems := splitPath(path)
+	for _, p := range patterns {
+		if matchPattern(elems, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckPattern 检查排除模式的写法，模式的写法见 Presets
+func CheckPattern(pattern string) error {
+	pe := splitPath(pattern)
+	if len(pe) == 0 {
+		return errors.New("模式为空")
+	}
+	for _, e := range pe {
+		if _, err := filepath.Match(e, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matchPattern 由 splitPath 拆分的路径是否匹配模式，模式的写法见 Presets
+func matchPattern(elems []string, pattern string) bool {
+	abs := strings.HasPrefix(filepath.ToSlash(pattern), "/")
+	pe := splitPath(pattern)
+	if len(pe) == 0 || len(pe) > len(elems) || (abs && len(pe) != len(elems)) {
+		return false
+	}
+	tail := elems[len(elems)-len(pe):]
+	for i := range pe {
+		if ok, _ := filepath.Match(pe[i], tail[i]); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// splitPath 将路径拆分为转为小写的各级名称，忽略卷名
+func splitPath(path string) []string {
+	path = strings.ToLower(filepath.ToSlash(strings.TrimPrefix(path, filepath.VolumeName(path))))
+	elems := []string{}
+	for _, e := range strings.Split(path, "/") {
+		if e != "" {
+			elems = append(elems, e)
+		}
+	}
+	return elems
+}
+
+// matchExt 文件的扩展名是否为其中之一，扩展名可带 .，不区分大小写
+func matchExt(path string, exts []string) bool {
+	ext := strings.TrimPrefix(filepath.Ext(path), ".")
+	if ext == "" {
+		return false
+	}
+	for _, e := range exts {
+		if strings.EqualFold(ext, strings.TrimPrefix(e, ".")) {
+			return true
+		}
+	}
+	return false
+}
+
+// isHidden 是否为隐藏的文件或目录：名称以 . 开头，或在 Windows 上带有隐藏属性
+func isHidden(path string, info fs.FileInfo) bool {
+	return strings.HasPrefix(filepath.Base(path), ".") || hiddenAttr(info)
+}
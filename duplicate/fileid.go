@@ -0,0 +1,103 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import "io/fs"
+
+// fileKey 文件在本机上的唯一标识：所在设备和 inode 号(Windows 上为卷序列号和文件索引)
+type fileKey struct {
+	dev uint64
+	ino uint64
+}
+
+// linkFollower 遍历时跟随指向目录的符号链接，记录已进入的目录，避免链接成环时无限遍历，
+// 也避免经不同路径到达的同一目录被重复遍历，其中的文件被当作自身的重复
+type linkFollower struct {
+	fsys    FileSystem
+	visited map[fileKey]bool
+}
+
+func newLinkFollower(fsys FileSystem) *linkFollower {
+	return &linkFollower{fsys: fsys, visited: map[fileKey]bool{}}
+}
+
+// resolve 符号链接指向目录时返回该目录的信息，否则原样返回；
+// 无法获取目标目录的标识时不跟随，以免链接成环时无法察觉
+func (l *linkFollower) resolve(path string, info fs.FileInfo, limit *rateLimiter) fs.FileInfo {
+	if info.Mode()&fs.ModeSymlink == 0 || !isOSFS(l.fsys) {
+		return info
+	}
+	limit.wait()
+	f, err := l.fsys.Open(path)
+	if err != nil {
+		return info
+	}
+	defer f.Close()
+	target, err := f.Stat()
+	if err != nil || !target.IsDir() {
+		return info
+	}
+	if _, ok := fileKeyOf(path, target); !ok {
+		return info
+	}
+	return target
+}
+
+// entered 目录是否已进入过，只查询不记录
+func (l *linkFollower) entered(path string, info fs.FileInfo) bool {
+	key, ok := fileKeyOf(path, info)
+	return ok && l.visited[key]
+}
+
+// enter 记录进入的目录，已进入过时返回 false；无法获取目录的标识时总是返回 true
+func (l *linkFollower) enter(path string, info fs.FileInfo) bool {
+	key, ok := fileKeyOf(path, info)
+	if !ok {
+		return true
+	}
+	if l.visited[key] {
+		return false
+	}
+	l.visited[key] = true
+	return true
+}
+
+// mergeHardlinks 同一文件的多个硬链接只保留遍历时最先遇到的一个参与比较：它们的内容本就是同一份，
+// 无需重复计算，删除其中一个也释放不了空间。只有大小相同的文件才可能是同一文件，因此在按大小分组后检查，
+// 去掉后不足 MinCopies 个文件的组不再保留
+func (s *Scanner) mergeHardlinks(groups [][]*FileInfo) [][]*FileInfo {
+	if !isOSFS(s.opts.FS) {
+		return groups
+	}
+	res := [][]*FileInfo{}
+	for _, g := range groups {
+		seen := map[fileKey]bool{}
+		kept := []*FileInfo{}
+		for _, f := range g {
+			if !IsArchivePath(f.Path) {
+				if info, err := s.opts.FS.Lstat(f.Path); err == nil {
+					if key, ok := fileKeyOf(f.Path, info); ok {
+						if seen[key] {
+							s.stats.Hardlinks += 1
+							continue
+						}
+						seen[key] = true
+					}
+				}
+			}
+			kept = append(kept, f)
+		}
+		if len(kept) >= s.opts.MinCopies {
+			res = append(res, kept)
+		}
+	}
+	return res
+}
@@ -0,0 +1,37 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import (
+	"io/fs"
+
+	"golang.org/x/sys/windows"
+)
+
+// fileKeyOf 按卷序列号和文件索引得到文件的标识，需要打开文件，info 不含这些信息
+func fileKeyOf(path string, info fs.FileInfo) (fileKey, bool) {
+	p, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return fileKey{}, false
+	}
+	// 打开目录需要 FILE_FLAG_BACKUP_SEMANTICS
+	h, err := windows.CreateFile(p, 0, windows.FILE_SHARE_READ|windows.FILE_SHARE_WRITE|windows.FILE_SHARE_DELETE,
+		nil, windows.OPEN_EXISTING, windows.FILE_FLAG_BACKUP_SEMANTICS, 0)
+	if err != nil {
+		return fileKey{}, false
+	}
+	defer windows.CloseHandle(h)
+	var d windows.ByHandleFileInformation
+	if err := windows.GetFileInformationByHandle(h, &d); err != nil {
+		return fileKey{}, false
+	}
+	return fileKey{dev: uint64(d.VolumeSerialNumber), ino: uint64(d.FileIndexHigh)<<32 | uint64(d.FileIndexLow)}, true
+}
@@ -0,0 +1,174 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import (
+	"bytes"
+	"io"
+)
+
+// 文件类别
+const (
+	TypeImage    = "image"
+	TypeVideo    = "video"
+	TypeAudio    = "audio"
+	TypeDocument = "document"
+	TypeArchive  = "archive"
+	TypeOther    = "other"
+)
+
+// FileTypes 支持过滤的文件类别
+var FileTypes = []string{TypeImage, TypeVideo, TypeAudio, TypeDocument, TypeArchive, TypeOther}
+
+// sniffSize 判断类型时读取的文件头长度，tar 的标识位于第257字节
+const sniffSize = 512
+
+// magic 魔数规则，data 出现在 offset 处即匹配
+type magic struct {
+	offset int
+	data   string
+	kind   string
+	format string
+}
+
+// magics 按顺序匹配，更具体的规则放在前面
+var magics = []magic{
+	{0, "\xff\xd8\xff", TypeImage, "jpeg"},
+	{0, "\x89PNG\r\n\x1a\n", TypeImage, "png"},
+	{0, "GIF87a", TypeImage, "gif"},
+	{0, "GIF89a", TypeImage, "gif"},
+	{0, "II*\x00", TypeImage, "tiff"},
+	{0, "MM\x00*", TypeImage, "tiff"},
+	{0, "8BPS", TypeImage, "psd"},
+	{0, "\x00\x00\x01\x00", TypeImage, "ico"},
+	{0, "BM", TypeImage, "bmp"},
+	{0, "\x1a\x45\xdf\xa3", TypeVideo, "mkv"},
+	{0, "FLV\x01", TypeVideo, "flv"},
+	{0, "\x30\x26\xb2\x75\x8e\x66\xcf\x11", TypeVideo, "asf"},
+	{0, "\x00\x00\x01\xba", TypeVideo, "mpeg"},
+	{0, "ID3", TypeAudio, "mp3"},
+	{0, "fLaC", TypeAudio, "flac"},
+	{0, "OggS", TypeAudio, "ogg"},
+	{0, "MThd", TypeAudio, "midi"},
+	{0, "#!AMR", TypeAudio, "amr"},
+	{0, "%PDF-", TypeDocument, "pdf"},
+	{0, "\xd0\xcf\x11\xe0\xa1\xb1\x1a\xe1", TypeDocument, "ole"},
+	{0, "{\\rtf", TypeDocument, "rtf"},
+	{0, "Rar!\x1a\x07", TypeArchive, "rar"},
+	{0, "7z\xbc\xaf\x27\x1c", TypeArchive, "7z"},
+	{0, "\x1f\x8b", TypeArchive, "gzip"},
+	{0, "BZh", TypeArchive, "bzip2"},
+	{0, "\xfd7zXZ\x00", TypeArchive, "xz"},
+	{0, "\x28\xb5\x2f\xfd", TypeArchive, "zstd"},
+	{0, "MSCF", TypeArchive, "cab"},
+	{257, "ustar", TypeArchive, "tar"},
+}
+
+// ftypBrands MP4 系列容器的品牌
+var ftypBrands = map[string][2]string{
+	"heic": {TypeImage, "heic"},
+	"heix": {TypeImage, "heic"},
+	"mif1": {TypeImage, "heif"},
+	"avif": {TypeImage, "avif"},
+	"M4A ": {TypeAudio, "m4a"},
+	"M4B ": {TypeAudio, "m4a"},
+	"qt  ": {TypeVideo, "mov"},
+}
+
+// riffForms RIFF 和 IFF 容器第8字节起的格式标识
+var riffForms = map[string][2]string{
+	"WEBP": {TypeImage, "webp"},
+	"AVI ": {TypeVideo, "avi"},
+	"WAVE": {TypeAudio, "wav"},
+	"AIFF": {TypeAudio, "aiff"},
+}
+
+// DetectType 根据文件头的魔数判断文件类别和具体格式，无法识别时返回 TypeOther
+func DetectType(head []byte) (string, string) {
+	if len(head) >= 12 && (string(head[:4]) == "RIFF" || string(head[:4]) == "FORM") {
+		if t, ok := riffForms[string(head[8:12])]; ok {
+			return t[0], t[1]
+		}
+	}
+	// MP4 系列: 第4字节起为 ftyp，随后是品牌
+	if len(head) >= 12 && string(head[4:8]) == "ftyp" {
+		if t, ok := ftypBrands[string(head[8:12])]; ok {
+			return t[0], t[1]
+		}
+		return TypeVideo, "mp4"
+	}
+	// zip 可能是 Office、OpenDocument 或 EPUB 文档
+	if bytes.HasPrefix(head, []byte("PK\x03\x04")) {
+		switch {
+		case bytes.Contains(head, []byte("[Content_Types].xml")), bytes.Contains(head, []byte("word/")),
+			bytes.Contains(head, []byte("xl/")), bytes.Contains(head, []byte("ppt/")):
+			return TypeDocument, "ooxml"
+		case bytes.Contains(head, []byte("application/vnd.oasis.opendocument")):
+			return TypeDocument, "odf"
+		case bytes.Contains(head, []byte("application/epub+zip")):
+			return TypeDocument, "epub"
+		}
+		return TypeArchive, "zip"
+	}
+	for _, m := range magics {
+		end := m.offset + len(m.data)
+		if len(head) >= end && string(head[m.offset:end]) == m.data {
+			return m.kind, m.format
+		}
+	}
+	// MPEG 音频帧和 ADTS 的同步字
+	if len(head) >= 2 && head[0] == 0xff && head[1]&0xe0 == 0xe0 {
+		if head[1]&0x06 == 0 {
+			return TypeAudio, "aac"
+		}
+		return TypeAudio, "mp3"
+	}
+	// MPEG-TS 每188字节一个同步字节
+	if len(head) > 188 && head[0] == 0x47 && head[188] == 0x47 {
+		return TypeVideo, "mpegts"
+	}
+	return TypeOther, ""
+}
+
+// sniffType 读取文件头并判断文件类别
+func sniffType(r io.Reader) (string, error) {
+	head := make([]byte, sniffSize)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	t, _ := DetectType(head[:n])
+	return t, nil
+}
+
+// detectType 按需判断文件类别并检查是否在要扫描的类别中
+func (s *Scanner) detectType(f *FileInfo) (bool, error) {
+	if !s.opts.DetectTypes && len(s.opts.Types) == 0 {
+		return true, nil
+	}
+	r, err := s.open(f.Path)
+	if err != nil {
+		return false, err
+	}
+	defer r.Close()
+	if f.Type, err = sniffType(r); err != nil {
+		return false, err
+	}
+	if len(s.opts.Types) == 0 {
+		return true, nil
+	}
+	for _, t := range s.opts.Types {
+		if t == f.Type {
+			return true, nil
+		}
+	}
+	return false, nil
+}
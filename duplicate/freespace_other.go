@@ -0,0 +1,20 @@
+//go:build !linux && !darwin && !freebsd && !windows
+
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import "errors"
+
+// FreeSpace 当前平台不支持检测可用空间
+func FreeSpace(path string) (uint64, error) {
+	return 0, errors.New("当前平台不支持检测可用空间")
+}
@@ -0,0 +1,24 @@
+//go:build linux || darwin || freebsd
+
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import "golang.org/x/sys/unix"
+
+// FreeSpace 返回路径所在文件系统中当前用户可用的空间
+func FreeSpace(path string) (uint64, error) {
+	st := unix.Statfs_t{}
+	if err := unix.Statfs(path, &st); err != nil {
+		return 0, err
+	}
+	return uint64(st.Bavail) * uint64(st.Bsize), nil
+}
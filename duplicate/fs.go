@@ -0,0 +1,51 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import (
+	"io/fs"
+	"os"
+	"time"
+)
+
+// FileSystem 扫描和清理时访问的文件系统，路径为本机格式的绝对路径。
+// 默认为本机文件系统 OSFS，嵌入方可替换为内存实现(见 duptest 包)，在测试中不依赖真实磁盘。
+// 归档挂载、元数据提取和删除前的权限预检只支持本机文件系统
+type FileSystem interface {
+	Open(name string) (fs.File, error) // 打开文件或目录，目录应实现 fs.ReadDirFile
+	Lstat(name string) (fs.FileInfo, error)
+	Remove(name string) error
+}
+
+// Clock 时间来源，限速等待通过它进行，测试时可替换为手动推进的时钟
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+}
+
+// OSFS 本机文件系统
+type OSFS struct{}
+
+func (OSFS) Open(name string) (fs.File, error)      { return os.Open(name) }
+func (OSFS) Lstat(name string) (fs.FileInfo, error) { return os.Lstat(name) }
+func (OSFS) Remove(name string) error               { return os.Remove(name) }
+
+// SystemClock 系统时钟
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time        { return time.Now() }
+func (SystemClock) Sleep(d time.Duration) { time.Sleep(d) }
+
+// isOSFS 是否为本机文件系统
+func isOSFS(fsys FileSystem) bool {
+	_, ok := fsys.(OSFS)
+	return ok
+}
@@ -0,0 +1,47 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+// FSInfo 文件系统信息
+type FSInfo struct {
+	Type    string // 文件系统类型，如 ext4、nfs、fuse，无法识别时为 unknown
+	Network bool   // 是否为网络或用户态文件系统(NFS、SMB、FUSE 等)，其修改时间和 inode 可能不可靠
+}
+
+// networkFS 网络或用户态文件系统
+var networkFS = map[string]bool{
+	"nfs":     true,
+	"smb":     true,
+	"smb2":    true,
+	"smbfs":   true,
+	"cifs":    true,
+	"fuse":    true,
+	"ceph":    true,
+	"9p":      true,
+	"afs":     true,
+	"afpfs":   true,
+	"coda":    true,
+	"ncp":     true,
+	"webdav":  true,
+	"macfuse": true,
+	"osxfuse": true,
+	"fusefs":  true,
+	"remote":  true,
+}
+
+// DetectFS 检测路径所在的文件系统
+func DetectFS(path string) (FSInfo, error) {
+	t, err := fsType(path)
+	if err != nil {
+		return FSInfo{Type: "unknown"}, err
+	}
+	return FSInfo{Type: t, Network: networkFS[t]}, nil
+}
@@ -0,0 +1,33 @@
+//go:build darwin || freebsd
+
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import (
+	"syscall"
+)
+
+// fsType 通过 statfs 获取文件系统类型
+func fsType(path string) (string, error) {
+	st := syscall.Statfs_t{}
+	if err := syscall.Statfs(path, &st); err != nil {
+		return "", err
+	}
+	b := make([]byte, 0, len(st.Fstypename))
+	for _, c := range st.Fstypename {
+		if c == 0 {
+			break
+		}
+		b = append(b, byte(c))
+	}
+	return string(b), nil
+}
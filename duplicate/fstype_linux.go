@@ -0,0 +1,54 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// linuxFS statfs 返回的文件系统魔数
+var linuxFS = map[uint32]string{
+	0xEF53:     "ext4",
+	0x58465342: "xfs",
+	0x9123683E: "btrfs",
+	0x2FC12FC1: "zfs",
+	0xF2F52010: "f2fs",
+	0x01021994: "tmpfs",
+	0x794C7630: "overlay",
+	0x73717368: "squashfs",
+	0x9660:     "iso9660",
+	0x4D44:     "vfat",
+	0x2011BAB0: "exfat",
+	0x5346544E: "ntfs",
+	0x6969:     "nfs",
+	0x517B:     "smb",
+	0xFF534D42: "cifs",
+	0xFE534D42: "smb2",
+	0x65735546: "fuse",
+	0x00C36400: "ceph",
+	0x01021997: "9p",
+	0x5346414F: "afs",
+	0x73757245: "coda",
+	0x564C:     "ncp",
+}
+
+// fsType 通过 statfs 获取文件系统类型
+func fsType(path string) (string, error) {
+	st := syscall.Statfs_t{}
+	if err := syscall.Statfs(path, &st); err != nil {
+		return "", err
+	}
+	if t, ok := linuxFS[uint32(st.Type)]; ok {
+		return t, nil
+	}
+	return fmt.Sprintf("0x%x", uint32(st.Type)), nil
+}
@@ -0,0 +1,18 @@
+//go:build !linux && !darwin && !freebsd && !windows
+
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+// fsType 当前平台不支持检测文件系统类型
+func fsType(path string) (string, error) {
+	return "unknown", nil
+}
@@ -0,0 +1,39 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import (
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/sys/windows"
+)
+
+// fsType 获取路径所在卷的文件系统类型，网络驱动器返回 remote
+func fsType(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	root := filepath.VolumeName(abs) + `\`
+	p, err := windows.UTF16PtrFromString(root)
+	if err != nil {
+		return "", err
+	}
+	if windows.GetDriveType(p) == windows.DRIVE_REMOTE {
+		return "remote", nil
+	}
+	name := make([]uint16, windows.MAX_PATH+1)
+	if err := windows.GetVolumeInformation(p, nil, 0, nil, nil, nil, &name[0], uint32(len(name))); err != nil {
+		return "", err
+	}
+	return strings.ToLower(windows.UTF16ToString(name)), nil
+}
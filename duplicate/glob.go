@@ -0,0 +1,145 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ExpandRoots 展开扫描路径中的通配符，支持 * ? [...]、匹配任意层目录的 ** 和 {a,b}，
+// 通配符只匹配目录，不含通配符的路径原样保留。
+// 不同参数展开后可能重复或嵌套，需再经 MergeRoots 合并
+func ExpandRoots(patterns []string) ([]string, error) {
+	roots := []string{}
+	for _, pattern := range patterns {
+		if !hasGlob(pattern) {
+			roots = append(roots, pattern)
+			continue
+		}
+		matches := map[string]bool{}
+		for _, p := range expandBraces(pattern) {
+			if err := globDirs(filepath.Clean(p), matches); err != nil {
+				return nil, err
+			}
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("没有匹配的目录: %s", pattern)
+		}
+		sorted := make([]string, 0, len(matches))
+		for m := range matches {
+			sorted = append(sorted, m)
+		}
+		sort.Strings(sorted)
+		roots = append(roots, sorted...)
+	}
+	return roots, nil
+}
+
+// hasGlob 判断路径是否包含通配符
+func hasGlob(p string) bool {
+	return strings.ContainsAny(p, "*?[{")
+}
+
+// expandBraces 展开 {a,b} 形式的备选项，支持嵌套
+func expandBraces(p string) []string {
+	start := strings.IndexByte(p, '{')
+	if start < 0 {
+		return []string{p}
+	}
+	depth, end := 0, -1
+	alts := []string{}
+	last := start + 1
+	for i := start; i < len(p) && end < 0; i++ {
+		switch p[i] {
+		case '{':
+			depth += 1
+		case '}':
+			depth -= 1
+			if depth == 0 {
+				end = i
+			}
+		case ',':
+			if depth == 1 {
+				alts = append(alts, p[last:i])
+				last = i + 1
+			}
+		}
+	}
+	// 括号不配对时按字面处理
+	if end < 0 {
+		return []string{p}
+	}
+	alts = append(alts, p[last:end])
+	res := []string{}
+	for _, a := range alts {
+		res = append(res, expandBraces(p[:start]+a+p[end+1:])...)
+	}
+	return res
+}
+
+// globDirs 将匹配 pattern 的目录加入 matches，** 匹配零或多层目录
+func globDirs(pattern string, matches map[string]bool) error {
+	sep := string(filepath.Separator)
+	parts := strings.Split(pattern, sep)
+	i := 0
+	for i < len(parts) && parts[i] != "**" {
+		i += 1
+	}
+	if i == len(parts) {
+		found, err := filepath.Glob(pattern)
+		if err != nil {
+			return fmt.Errorf("无效的通配符 %s: %v", pattern, err)
+		}
+		for _, m := range found {
+			if info, err := os.Stat(m); err == nil && info.IsDir() {
+				matches[m] = true
+			}
+		}
+		return nil
+	}
+	base := strings.Join(parts[:i], sep)
+	switch {
+	case i == 0:
+		base = "."
+	case base == filepath.VolumeName(base):
+		// 从根目录开始，如 /** 或 C:\**
+		base += sep
+	}
+	rest := strings.Join(parts[i+1:], sep)
+	bases := map[string]bool{}
+	if err := globDirs(base, bases); err != nil {
+		return err
+	}
+	for b := range bases {
+		err := filepath.WalkDir(b, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return filepath.SkipDir
+			}
+			if !d.IsDir() {
+				return nil
+			}
+			if rest == "" {
+				matches[path] = true
+				return nil
+			}
+			return globDirs(filepath.Join(path, rest), matches)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
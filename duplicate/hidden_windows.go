@@ -0,0 +1,22 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// hiddenAttr 文件是否带有隐藏属性
+func hiddenAttr(info fs.FileInfo) bool {
+	attr, ok := info.Sys().(*syscall.Win32FileAttributeData)
+	return ok && attr.FileAttributes&syscall.FILE_ATTRIBUTE_HIDDEN != 0
+}
@@ -0,0 +1,166 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// DcIgnore 随数据存放的忽略文件名，写法同 .gitignore
+const DcIgnore = ".dcignore"
+
+// GitIgnores Git 仓库中的忽略文件，仓库根目录下的 .git/info/exclude 优先级低于同一目录的 .gitignore
+var GitIgnores = []string{".git/info/exclude", ".gitignore"}
+
+// ignoreRule 忽略文件中的一条规则
+type ignoreRule struct {
+	segs     []string // 按 / 拆分的模式，** 匹配零或多层目录
+	negate   bool     // 以 ! 开头，重新包含之前被忽略的路径
+	dirOnly  bool     // 以 / 结尾，只匹配目录
+	anchored bool     // 含 /，相对忽略文件所在目录匹配，否则匹配任意层级的名称
+}
+
+// parseIgnore 按 .gitignore 的写法解析忽略文件
+func parseIgnore(r io.Reader) ([]ignoreRule, error) {
+	rules := []ignoreRule{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		// 行尾的空格被忽略，以 \ 转义的除外
+		for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+			line = line[:len(line)-1]
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule := ignoreRule{}
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		rule.anchored = strings.Contains(line, "/")
+		for _, s := range strings.Split(strings.TrimPrefix(line, "/"), "/") {
+			if s != "" {
+				rule.segs = append(rule.segs, s)
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return rules, scanner.Err()
+}
+
+// match 规则是否匹配路径，rel 为相对忽略文件所在目录的各级名称
+func (r ignoreRule) match(rel []string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	if !r.anchored {
+		ok, _ := path.Match(r.segs[0], rel[len(rel)-1])
+		return ok
+	}
+	return matchSegs(r.segs, rel)
+}
+
+// matchSegs 各级名称是否匹配各级模式，** 匹配零或多层目录，结尾的 ** 只匹配其中的内容
+func matchSegs(pat, name []string) bool {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			pat = pat[1:]
+			if len(pat) == 0 {
+				return len(name) > 0
+			}
+			for i := range len(name) + 1 {
+				if matchSegs(pat, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pat[0], name[0]); !ok {
+			return false
+		}
+		pat, name = pat[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// ignoreTree 遍历中读取的忽略文件，按所在目录记录，深层目录中的规则优先，同一文件中靠后的规则优先
+type ignoreTree struct {
+	fsys  FileSystem
+	names []string
+	dirs  map[string][]ignoreRule
+}
+
+func newIgnoreTree(fsys FileSystem, names []string) *ignoreTree {
+	return &ignoreTree{fsys: fsys, names: names, dirs: map[string][]ignoreRule{}}
+}
+
+// load 读取目录中的忽略文件，不存在时忽略
+func (t *ignoreTree) load(dir string, limit *rateLimiter) error {
+	errs := []error{}
+	for _, name := range t.names {
+		limit.wait()
+		f, err := t.fsys.Open(filepath.Join(dir, name))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, newPathError("读取忽略文件", filepath.Join(dir, name), err))
+			continue
+		}
+		rules, err := parseIgnore(f)
+		f.Close()
+		if err != nil {
+			errs = append(errs, newPathError("读取忽略文件", filepath.Join(dir, name), err))
+			continue
+		}
+		t.dirs[dir] = append(t.dirs[dir], rules...)
+	}
+	return errors.Join(errs...)
+}
+
+// ignored 路径是否被 root 及其下各级目录中的忽略文件排除
+func (t *ignoreTree) ignored(root, p string, isDir bool) bool {
+	for dir := filepath.Dir(p); ; dir = filepath.Dir(dir) {
+		if rules := t.dirs[dir]; len(rules) > 0 {
+			rel, err := filepath.Rel(dir, p)
+			if err == nil {
+				elems := strings.Split(filepath.ToSlash(rel), "/")
+				for i := len(rules) - 1; i >= 0; i-- {
+					if rules[i].match(elems, isDir) {
+						return !rules[i].negate
+					}
+				}
+			}
+		}
+		if dir == root || dir == filepath.Dir(dir) {
+			return false
+		}
+	}
+}
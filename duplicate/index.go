@@ -0,0 +1,220 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Hash索引文件的版本，v2 起文件头记录保存时间，v3 起每个条目记录各自的Hash算法；
+// 读取旧版本时自动迁移，条目的算法取文件头中的算法，保存时总是写为当前版本
+const (
+	IndexVersion = 3
+	indexOldest  = 1
+)
+
+// indexHeader Hash索引文件头
+type indexHeader struct {
+	Version int       `json:"index"`
+	Hash    string    `json:"hash"`           // 当前使用的算法，新算出的Hash值使用此算法
+	Saved   time.Time `json:"saved,omitzero"` // v2 起记录
+}
+
+// IndexEntry 已算出Hash值的文件，大小和修改时间不变时可直接复用
+type IndexEntry struct {
+	Path    string `json:"path"`
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"mtime"`          // Unix 纳秒
+	Hash    string `json:"hash,omitempty"` // 大小唯一的文件未计算Hash值，只有签名
+	Algo    string `json:"algo,omitempty"` // Hash值使用的算法，v3 起记录，只有签名时为空
+	Sig     string `json:"sig,omitempty"`  // 快速签名，见 Signature
+}
+
+// HashIndex 按路径记录已算出的Hash值，用于中断后继续扫描时跳过已计算的文件。
+// 索引中可以同时有不同算法算出的条目，只有当前算法的Hash值能直接复用
+type HashIndex struct {
+	m       sync.Mutex
+	hash    string    // 当前算法
+	version int       // 读取时的文件版本
+	saved   time.Time // 上次保存的时间
+	entries map[string]IndexEntry
+}
+
+// NewHashIndex 创建指定Hash算法的空索引
+func NewHashIndex(hashName string) *HashIndex {
+	return &HashIndex{hash: HashName(hashName), version: IndexVersion, entries: map[string]IndexEntry{}}
+}
+
+// HashName 返回统一为小写的Hash算法名称，未指定时为 md5
+func HashName(hashName string) string {
+	if hashName == "" {
+		return "md5"
+	}
+	return strings.ToLower(hashName)
+}
+
+// LoadHashIndex 读取索引文件，旧版本的索引自动迁移为当前版本
+func LoadHashIndex(r io.Reader) (*HashIndex, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("索引文件为空")
+	}
+	header := indexHeader{}
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil {
+		return nil, fmt.Errorf("无效的索引文件头: %v", err)
+	}
+	if err := CheckVersion("索引文件", header.Version, indexOldest, IndexVersion); err != nil {
+		return nil, err
+	}
+	x := NewHashIndex(header.Hash)
+	x.version = header.Version
+	x.saved = header.Saved
+	for line := 2; scanner.Scan(); line++ {
+		e := IndexEntry{}
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("索引第 %d 行无效: %v", line, err)
+		}
+		switch {
+		case e.Hash == "":
+			e.Algo = ""
+		case e.Algo == "":
+			e.Algo = x.hash
+		default:
+			e.Algo = HashName(e.Algo)
+		}
+		x.entries[e.Path] = e
+	}
+	return x, scanner.Err()
+}
+
+// Hash 索引当前使用的Hash算法
+func (x *HashIndex) Hash() string {
+	return x.hash
+}
+
+// UseHash 切换当前算法，其他算法的条目仍然保留，但不再由 Lookup 返回，
+// 之后算出的Hash值按新算法记录并替换同一文件的旧条目
+func (x *HashIndex) UseHash(hashName string) {
+	x.m.Lock()
+	defer x.m.Unlock()
+	x.hash = HashName(hashName)
+}
+
+// Algos 按算法统计有Hash值的条目数
+func (x *HashIndex) Algos() map[string]int {
+	x.m.Lock()
+	defer x.m.Unlock()
+	n := map[string]int{}
+	for _, e := range x.entries {
+		if e.Hash != "" {
+			n[e.Algo] += 1
+		}
+	}
+	return n
+}
+
+// Version 读取时的文件版本，低于 IndexVersion 说明是自动迁移的旧版本索引，新建的索引为 IndexVersion
+func (x *HashIndex) Version() int {
+	return x.version
+}
+
+// Saved 上次保存的时间，新建或由 v1 迁移的索引为零值
+func (x *HashIndex) Saved() time.Time {
+	return x.saved
+}
+
+// Len 索引中的文件数
+func (x *HashIndex) Len() int {
+	x.m.Lock()
+	defer x.m.Unlock()
+	return len(x.entries)
+}
+
+// Lookup 文件大小和修改时间与索引一致、且是用当前算法算出时返回记录的Hash值
+func (x *HashIndex) Lookup(f FileInfo) (string, bool) {
+	e, ok := x.lookup(f)
+	if !ok || e.Algo != x.hash {
+		return "", false
+	}
+	return e.Hash, true
+}
+
+// LookupAny 与 Lookup 相同，但也返回其他算法算出的Hash值，以及所用的算法
+func (x *HashIndex) LookupAny(f FileInfo) (string, string, bool) {
+	e, ok := x.lookup(f)
+	if !ok {
+		return "", "", false
+	}
+	return e.Algo, e.Hash, true
+}
+
+// lookup 返回大小和修改时间未变、有Hash值的条目
+func (x *HashIndex) lookup(f FileInfo) (IndexEntry, bool) {
+	x.m.Lock()
+	defer x.m.Unlock()
+	e, ok := x.entries[f.Path]
+	if !ok || e.Hash == "" || e.Size != f.Size || e.ModTime != f.ModTime.UnixNano() {
+		return IndexEntry{}, false
+	}
+	return e, true
+}
+
+// Add 按当前算法记录文件的Hash值
+func (x *HashIndex) Add(f FileInfo) {
+	x.m.Lock()
+	defer x.m.Unlock()
+	e := IndexEntry{Path: f.Path, Size: f.Size, ModTime: f.ModTime.UnixNano(), Hash: f.Hash, Sig: f.Signature}
+	if e.Hash != "" {
+		e.Algo = x.hash
+	}
+	old, ok := x.entries[f.Path]
+	unchanged := ok && old.Size == e.Size && old.ModTime == e.ModTime
+	// 复用索引中的Hash值或换用其他算法重新计算时没有重新计算签名，保留原有的签名
+	if unchanged && e.Sig == "" && (old.Hash == e.Hash || old.Algo != e.Algo) {
+		e.Sig = old.Sig
+	}
+	x.entries[f.Path] = e
+}
+
+// Save 按路径顺序写出索引
+func (x *HashIndex) Save(w io.Writer) error {
+	x.m.Lock()
+	defer x.m.Unlock()
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	x.saved = time.Now().Truncate(time.Second)
+	if err := enc.Encode(indexHeader{Version: IndexVersion, Hash: x.hash, Saved: x.saved}); err != nil {
+		return err
+	}
+	paths := make([]string, 0, len(x.entries))
+	for p := range x.entries {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		if err := enc.Encode(x.entries[p]); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,126 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import (
+	"errors"
+	"io/fs"
+	"math/rand/v2"
+	"os"
+	"sort"
+)
+
+// IndexCheck 索引抽查的结果
+type IndexCheck struct {
+	Checked  int     // 抽查的条目数
+	OK       int     // 与文件一致
+	Missing  int     // 文件已不存在，已移除
+	Changed  int     // 大小或修改时间已变化，已移除
+	Repaired int     // 大小和修改时间未变但Hash值或签名不符，已按当前内容更正
+	Errs     []error // 无法读取的文件，相应条目保持不变
+}
+
+// Verify 随机抽查 n 个条目(n 不大于0或超过条目数时检查全部)，与实际文件比对存在性、大小、
+// 修改时间和Hash值(只有签名的条目比对签名)，移除过期的条目并更正不符的条目
+func (x *HashIndex) Verify(n int, progress bool) IndexCheck {
+	x.m.Lock()
+	paths := make([]string, 0, len(x.entries))
+	for p := range x.entries {
+		paths = append(paths, p)
+	}
+	x.m.Unlock()
+	sort.Strings(paths)
+	if n > 0 && n < len(paths) {
+		rand.Shuffle(len(paths), func(i, j int) { paths[i], paths[j] = paths[j], paths[i] })
+		paths = paths[:n]
+		sort.Strings(paths)
+	}
+	res := IndexCheck{}
+	bar := newTracker(progress, nil, StageVerifyIndex, "校验索引", int64(len(paths)), 0)
+	defer bar.Close()
+	for _, p := range paths {
+		res.Checked += 1
+		x.verifyEntry(p, &res)
+		bar.Add(1, 0)
+	}
+	return res
+}
+
+// verifyEntry 校验单个条目并按结果更新索引
+func (x *HashIndex) verifyEntry(path string, res *IndexCheck) {
+	x.m.Lock()
+	e := x.entries[path]
+	x.m.Unlock()
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		res.Missing += 1
+		x.remove(path)
+		return
+	}
+	if err != nil {
+		res.Errs = append(res.Errs, newPathError("校验索引", path, err))
+		return
+	}
+	if !info.Mode().IsRegular() || info.Size() != e.Size || info.ModTime().UnixNano() != e.ModTime {
+		res.Changed += 1
+		x.remove(path)
+		return
+	}
+	fixed := e
+	if e.Hash != "" {
+		if fixed.Hash, err = HashFile(path, e.Algo); err != nil {
+			res.Errs = append(res.Errs, newPathError("校验索引", path, err))
+			return
+		}
+	}
+	if e.Sig != "" {
+		if fixed.Sig, err = Signature(path); err != nil {
+			res.Errs = append(res.Errs, newPathError("校验索引", path, err))
+			return
+		}
+	}
+	if fixed == e {
+		res.OK += 1
+		return
+	}
+	res.Repaired += 1
+	x.m.Lock()
+	x.entries[path] = fixed
+	x.m.Unlock()
+}
+
+func (x *HashIndex) remove(path string) {
+	x.m.Lock()
+	defer x.m.Unlock()
+	delete(x.entries, path)
+}
+
+// Prune 移除位于 roots 之下、已不存在或大小和修改时间已改变的文件的条目，返回移除的条目数。
+// roots 应为解析后的真实路径，用于完整扫描之后清理增量扫描的索引；无法获取文件信息的条目予以保留
+func (x *HashIndex) Prune(roots []string) int {
+	x.m.Lock()
+	defer x.m.Unlock()
+	n := 0
+	for p, e := range x.entries {
+		if IsArchivePath(p) || !withinAny(p, roots) {
+			continue
+		}
+		info, err := os.Lstat(p)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil || !info.Mode().IsRegular() || info.Size() != e.Size || info.ModTime().UnixNano() != e.ModTime {
+			delete(x.entries, p)
+			n += 1
+		}
+	}
+	return n
+}
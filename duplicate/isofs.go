@@ -0,0 +1,259 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+const (
+	isoSector   = 2048
+	isoMaxDepth = 64       // 目录的最大层数，防止损坏的镜像造成死循环
+	isoMaxDir   = 64 << 20 // 单个目录的最大长度，防止损坏的镜像造成巨大的内存分配
+)
+
+// isoExtent 文件数据所在的区段，超过 4GB 的文件由多个区段组成
+type isoExtent struct {
+	off, size int64
+}
+
+// isoFS ISO 9660 光盘镜像，优先使用 Rock Ridge 的长文件名，其次为 Joliet，都没有时使用 8.3 格式的文件名
+type isoFS struct {
+	f       *os.File
+	size    int64 // 镜像文件的大小
+	entries []archiveEntry
+	extents map[string][]isoExtent
+	visited map[int64]bool // 已遍历的目录区段，指回上级或重复列出的目录只遍历一次
+}
+
+// isoRecord 目录记录
+type isoRecord struct {
+	extent  int64
+	size    int64
+	modTime time.Time
+	dir     bool
+	more    bool // 文件还有后续的区段
+	name    string
+}
+
+func mountISO(path string) (*isoFS, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	i := &isoFS{f: f, size: info.Size(), extents: map[string][]isoExtent{}, visited: map[int64]bool{}}
+	if err := i.load(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return i, nil
+}
+
+// load 读取卷描述符并遍历目录树
+func (i *isoFS) load() error {
+	var primary, joliet []byte
+	for n := int64(16); ; n++ {
+		vd := make([]byte, isoSector)
+		if _, err := i.f.ReadAt(vd, n*isoSector); err != nil {
+			return errors.New("不是有效的 ISO 9660 镜像")
+		}
+		if string(vd[1:6]) != "CD001" {
+			return errors.New("不是有效的 ISO 9660 镜像")
+		}
+		switch vd[0] {
+		case 1:
+			if primary == nil {
+				primary = vd[156:190]
+			}
+		case 2:
+			// 转义序列 %/@、%/C、%/E 表示 Joliet 的 UCS-2 文件名
+			if esc := vd[88:91]; esc[0] == '%' && esc[1] == '/' && bytes.IndexByte([]byte("@CE"), esc[2]) >= 0 {
+				joliet = vd[156:190]
+			}
+		}
+		if vd[0] == 255 {
+			break
+		}
+	}
+	if primary == nil {
+		return errors.New("ISO 镜像缺少主卷描述符")
+	}
+	root, _ := parseISORecord(primary, false)
+	rockRidge, err := i.walk(root, "", false, 0)
+	if err != nil {
+		return err
+	}
+	if rockRidge || joliet == nil {
+		return nil
+	}
+	// 没有 Rock Ridge 扩展时改用 Joliet 的文件名
+	i.entries, i.extents, i.visited = nil, map[string][]isoExtent{}, map[int64]bool{}
+	root, _ = parseISORecord(joliet, true)
+	_, err = i.walk(root, "", true, 0)
+	return err
+}
+
+// walk 遍历目录，返回是否遇到了 Rock Ridge 的文件名；已遍历过的目录区段直接跳过
+func (i *isoFS) walk(dir isoRecord, prefix string, joliet bool, depth int) (bool, error) {
+	if depth > isoMaxDepth {
+		return false, errors.New("ISO 镜像的目录层数过多，可能已损坏")
+	}
+	if i.visited[dir.extent] {
+		return false, nil
+	}
+	i.visited[dir.extent] = true
+	if dir.size > isoMaxDir || dir.extent*isoSector+dir.size > i.size {
+		return false, errors.New("ISO 镜像的目录记录无效，目录长度超出范围")
+	}
+	data := make([]byte, dir.size)
+	if _, err := i.f.ReadAt(data, dir.extent*isoSector); err != nil {
+		return false, err
+	}
+	rockRidge := false
+	var last string
+	for off := 0; off < len(data); {
+		n := int(data[off])
+		// 目录记录不跨越扇区，扇区末尾的空白跳到下一扇区
+		if n == 0 {
+			off = (off/isoSector + 1) * isoSector
+			continue
+		}
+		if n < 34 || off+n > len(data) {
+			return rockRidge, errors.New("ISO 镜像的目录记录无效")
+		}
+		r, rr := parseISORecord(data[off:off+n], joliet)
+		off += n
+		if r.name == "" {
+			continue
+		}
+		rockRidge = rockRidge || rr
+		name := prefix + r.name
+		if r.dir {
+			sub, err := i.walk(r, name+"/", joliet, depth+1)
+			if err != nil {
+				return rockRidge, err
+			}
+			rockRidge = rockRidge || sub
+			continue
+		}
+		ext := isoExtent{off: r.extent * isoSector, size: r.size}
+		if prev, ok := i.extents[name]; ok && name == last {
+			// 多区段文件的后续区段
+			i.extents[name] = append(prev, ext)
+			i.entries[len(i.entries)-1].Size += r.size
+		} else {
+			i.extents[name] = []isoExtent{ext}
+			i.entries = append(i.entries, archiveEntry{Name: name, Size: r.size, ModTime: r.modTime})
+		}
+		last = ""
+		if r.more {
+			last = name
+		}
+	}
+	return rockRidge, nil
+}
+
+// parseISORecord 解析目录记录，"." 和 ".." 的名称为空；返回的 bool 表示名称是否取自 Rock Ridge
+func parseISORecord(b []byte, joliet bool) (isoRecord, bool) {
+	r := isoRecord{
+		extent: int64(binary.LittleEndian.Uint32(b[2:6])),
+		size:   int64(binary.LittleEndian.Uint32(b[10:14])),
+		dir:    b[25]&0x02 != 0,
+		more:   b[25]&0x80 != 0,
+	}
+	if d := b[18:25]; d[1] > 0 {
+		zone := time.FixedZone("", int(int8(d[6]))*15*60)
+		r.modTime = time.Date(1900+int(d[0]), time.Month(d[1]), int(d[2]), int(d[3]), int(d[4]), int(d[5]), 0, zone)
+	}
+	n := int(b[32])
+	if 33+n > len(b) {
+		return isoRecord{}, false
+	}
+	id := b[33 : 33+n]
+	if n == 1 && id[0] <= 1 {
+		return r, false
+	}
+	// 系统使用区紧随文件名之后，文件名长度为偶数时有一个填充字节
+	su := b[33+n:]
+	if n%2 == 0 && len(su) > 0 {
+		su = su[1:]
+	}
+	if name, ok := rockRidgeName(su); ok && !joliet {
+		r.name = name
+		return r, true
+	}
+	if joliet {
+		u := make([]uint16, len(id)/2)
+		for k := range u {
+			u[k] = binary.BigEndian.Uint16(id[2*k:])
+		}
+		r.name = string(utf16.Decode(u))
+	} else {
+		r.name = string(id)
+	}
+	if !r.dir {
+		r.name, _, _ = strings.Cut(r.name, ";")
+		r.name = strings.TrimSuffix(r.name, ".")
+	}
+	return r, false
+}
+
+// rockRidgeName 从系统使用区的 NM 条目中取出 Rock Ridge 的文件名，不支持存放在延续区中的条目
+func rockRidgeName(su []byte) (string, bool) {
+	name, found := []byte{}, false
+	for len(su) >= 4 {
+		n := int(su[2])
+		if n < 4 || n > len(su) {
+			break
+		}
+		if string(su[:2]) == "NM" && n >= 5 {
+			// 标志位 0x02、0x04 表示 "." 和 ".."
+			if su[4]&0x06 == 0 {
+				name = append(name, su[5:n]...)
+				found = true
+			}
+		}
+		su = su[n:]
+	}
+	return string(name), found && len(name) > 0
+}
+
+func (i *isoFS) Entries() []archiveEntry {
+	return i.entries
+}
+
+func (i *isoFS) Open(name string) (io.ReadCloser, error) {
+	exts, ok := i.extents[name]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	readers := make([]io.Reader, 0, len(exts))
+	for _, e := range exts {
+		readers = append(readers, io.NewSectionReader(i.f, e.off, e.size))
+	}
+	return io.NopCloser(io.MultiReader(readers...)), nil
+}
+
+func (i *isoFS) Close() error {
+	return i.f.Close()
+}
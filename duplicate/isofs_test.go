@@ -0,0 +1,84 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// isoTestRecord 构造目录记录
+func isoTestRecord(id string, extent, size uint32, dir bool) []byte {
+	n := 33 + len(id)
+	if len(id)%2 == 0 {
+		n++
+	}
+	b := make([]byte, n)
+	b[0] = byte(n)
+	binary.LittleEndian.PutUint32(b[2:], extent)
+	binary.LittleEndian.PutUint32(b[10:], size)
+	if dir {
+		b[25] = 0x02
+	}
+	b[32] = byte(len(id))
+	copy(b[33:], id)
+	return b
+}
+
+// writeTestISO 写出只有主卷描述符的镜像，根目录位于第 18 扇区，内容为 records
+func writeTestISO(t *testing.T, rootSize uint32, records ...[]byte) string {
+	img := make([]byte, 20*isoSector)
+	pvd := img[16*isoSector:]
+	pvd[0] = 1
+	copy(pvd[1:], "CD001")
+	copy(pvd[156:], isoTestRecord("\x00", 18, rootSize, true))
+	end := img[17*isoSector:]
+	end[0] = 255
+	copy(end[1:], "CD001")
+	root := img[18*isoSector : 18*isoSector]
+	root = append(root, isoTestRecord("\x00", 18, isoSector, true)...)
+	root = append(root, isoTestRecord("\x01", 18, isoSector, true)...)
+	for _, r := range records {
+		root = append(root, r...)
+	}
+	copy(img[19*isoSector:], "hello")
+	path := filepath.Join(t.TempDir(), "test.iso")
+	if err := os.WriteFile(path, img, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestISOLoop(t *testing.T) {
+	// 子目录指回根目录，且同一目录被列出两次
+	path := writeTestISO(t, isoSector,
+		isoTestRecord("A.TXT;1", 19, 5, false),
+		isoTestRecord("LOOP", 18, isoSector, true),
+		isoTestRecord("LOOP2", 18, isoSector, true))
+	i, err := mountISO(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer i.f.Close()
+	if len(i.entries) != 1 || i.entries[0].Name != "A.TXT" {
+		t.Errorf("指回上级的目录应只遍历一次: %v", i.entries)
+	}
+}
+
+func TestISODirSize(t *testing.T) {
+	path := writeTestISO(t, 0xfffff000)
+	if i, err := mountISO(path); err == nil {
+		i.f.Close()
+		t.Error("目录长度超出镜像时应报错")
+	}
+}
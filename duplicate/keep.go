@@ -0,0 +1,293 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"time"
+	"unicode/utf8"
+)
+
+// 保留策略的名称
+const (
+	KeepFirstName        = "first"
+	KeepPerDirName       = "per-dir"
+	KeepNewestName       = "newest"
+	KeepOldestName       = "oldest"
+	KeepShortestPathName = "shortest-path"
+	KeepFirstDirName     = "first-dir"
+)
+
+// KeepPolicies 支持的保留策略
+var KeepPolicies = []string{KeepFirstName, KeepPerDirName, KeepNewestName, KeepOldestName, KeepShortestPathName, KeepFirstDirName}
+
+// KeepPolicy 保留策略，决定每组重复文件中保留哪些
+type KeepPolicy interface {
+	// Keep 返回组内各文件是否保留，files 按清单中的顺序排列
+	Keep(files FileInfos) []bool
+}
+
+// KeepFirst 每组只保留第一个文件
+type KeepFirst struct{}
+
+func (KeepFirst) Keep(files FileInfos) []bool {
+	keep := make([]bool, len(files))
+	keep[0] = true
+	return keep
+}
+
+// KeepPerDir 每个目录各保留一个文件(目录内的第一个)，只删除同一目录下的多余副本，
+// 适用于希望每个壁纸、样例目录都保留一份的情形
+type KeepPerDir struct{}
+
+func (KeepPerDir) Keep(files FileInfos) []bool {
+	keep := make([]bool, len(files))
+	seen := map[string]bool{}
+	for i, f := range files {
+		dir := filepath.Dir(f.Path)
+		if !seen[dir] {
+			seen[dir] = true
+			keep[i] = true
+		}
+	}
+	return keep
+}
+
+// KeepBest 每组只保留按 Better 排在最前的一个文件，同样好的文件保留清单中靠前的
+type KeepBest struct {
+	// Better 返回 a 是否比 b 更应保留
+	Better func(a, b FileInfo) bool
+	// Skip 不参与比较的文件，如已无法读取修改时间的，可为空
+	Skip func(f FileInfo) bool
+}
+
+func (p KeepBest) Keep(files FileInfos) []bool {
+	keep := make([]bool, len(files))
+	best := -1
+	for i, f := range files {
+		if p.Skip != nil && p.Skip(f) {
+			continue
+		}
+		if best < 0 || p.Better(f, files[best]) {
+			best = i
+		}
+	}
+	if best >= 0 {
+		keep[best] = true
+	}
+	return keep
+}
+
+// KeepNewest 每组保留修改时间最新的文件
+func KeepNewest() KeepBest {
+	return keepByModTime(func(a, b time.Time) bool { return a.After(b) })
+}
+
+// KeepOldest 每组保留修改时间最早的文件，通常是最初的那一份
+func KeepOldest() KeepBest {
+	return keepByModTime(func(a, b time.Time) bool { return a.Before(b) })
+}
+
+// keepByModTime 按修改时间比较，清单中不含修改时间，清理时读取文件当前的修改时间，
+// 无法读取的文件(已不存在、位于归档内等)不会被选为保留的文件
+func keepByModTime(better func(a, b time.Time) bool) KeepBest {
+	mtimes := map[string]time.Time{}
+	mtime := func(f FileInfo) (time.Time, bool) {
+		if !f.ModTime.IsZero() {
+			return f.ModTime, true
+		}
+		t, ok := mtimes[f.Path]
+		if !ok && !IsArchivePath(f.Path) {
+			if info, err := os.Stat(f.Path); err == nil {
+				t, ok = info.ModTime(), true
+				mtimes[f.Path] = t
+			}
+		}
+		return t, ok
+	}
+	return KeepBest{
+		Better: func(a, b FileInfo) bool {
+			ta, _ := mtime(a)
+			tb, _ := mtime(b)
+			return better(ta, tb)
+		},
+		Skip: func(f FileInfo) bool {
+			_, ok := mtime(f)
+			return !ok
+		},
+	}
+}
+
+// KeepShortestPath 每组保留路径最短(按字符数)的文件，通常是整理好的原件而非层层嵌套的备份副本
+func KeepShortestPath() KeepBest {
+	return KeepBest{Better: func(a, b FileInfo) bool {
+		return utf8.RuneCountInString(a.Path) < utf8.RuneCountInString(b.Path)
+	}}
+}
+
+// KeepFirstDir 每组保留所在目录按路径排在最前的文件，同一目录内按文件名，
+// 与清单中的顺序无关，编辑或合并清单后结果不变
+func KeepFirstDir() KeepBest {
+	return KeepBest{Better: func(a, b FileInfo) bool {
+		da, db := filepath.Dir(a.Path), filepath.Dir(b.Path)
+		if da != db {
+			return da < db
+		}
+		return filepath.Base(a.Path) < filepath.Base(b.Path)
+	}}
+}
+
+// KeepProtected 受保护目录(如存放原件的 /archive)中的文件总是保留，组内有这样的文件时其余副本全部删除，
+// 没有时按 Policy 选择保留的文件
+type KeepProtected struct {
+	Policy KeepPolicy
+	Dirs   []string // 受保护的目录，应为绝对路径
+}
+
+func (p KeepProtected) Keep(files FileInfos) []bool {
+	keep := make([]bool, len(files))
+	found := false
+	for i, f := range files {
+		if IsProtected(CanonicalPath(f.Path), p.Dirs) {
+			keep[i] = true
+			found = true
+		}
+	}
+	if found {
+		return keep
+	}
+	return p.Policy.Keep(files)
+}
+
+// KeepRules 按路径的正则表达式规则决定保留或删除的文件，其余文件按 Policy 选择：
+// 匹配 KeepMatch 的文件总是保留，组内有这样的文件时其余副本全部删除；
+// 没有时匹配 DeleteMatch 的文件(如 `/Downloads/`)总是删除，Policy 只在其余文件中选择。
+// 组内文件全部匹配 DeleteMatch 时不会全部删除，而是按 Policy 在整组中选择，并调用 OnAllDeleted
+type KeepRules struct {
+	Policy      KeepPolicy
+	KeepMatch   []*regexp.Regexp // 匹配完整路径，路径分隔符统一为 /
+	DeleteMatch []*regexp.Regexp
+
+	// OnAllDeleted 组内文件全部匹配 DeleteMatch 时调用，可为空
+	OnAllDeleted func(files FileInfos)
+}
+
+func (p KeepRules) Keep(files FileInfos) []bool {
+	keep := make([]bool, len(files))
+	found := false
+	for i, f := range files {
+		if matchAny(p.KeepMatch, f.Path) {
+			keep[i] = true
+			found = true
+		}
+	}
+	if found {
+		return keep
+	}
+	rest := FileInfos{}
+	index := []int{}
+	for i, f := range files {
+		if !matchAny(p.DeleteMatch, f.Path) {
+			rest = append(rest, f)
+			index = append(index, i)
+		}
+	}
+	if len(rest) == 0 {
+		if p.OnAllDeleted != nil {
+			p.OnAllDeleted(files)
+		}
+		return p.Policy.Keep(files)
+	}
+	for i, k := range p.Policy.Keep(rest) {
+		keep[index[i]] = k
+	}
+	// 策略未在其余文件中保留任何文件时保留其中第一个，而不是回退到可能匹配 DeleteMatch 的整组第一个文件
+	if !slices.Contains(keep, true) {
+		keep[index[0]] = true
+	}
+	return keep
+}
+
+// matchAny 路径是否匹配其中任一正则表达式
+func matchAny(res []*regexp.Regexp, path string) bool {
+	path = filepath.ToSlash(path)
+	for _, re := range res {
+		if re.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewKeepPolicy 按名称创建保留策略
+func NewKeepPolicy(name string) (KeepPolicy, error) {
+	switch name {
+	case KeepFirstName:
+		return KeepFirst{}, nil
+	case KeepPerDirName:
+		return KeepPerDir{}, nil
+	case KeepNewestName:
+		return KeepNewest(), nil
+	case KeepOldestName:
+		return KeepOldest(), nil
+	case KeepShortestPathName:
+		return KeepShortestPath(), nil
+	case KeepFirstDirName:
+		return KeepFirstDir(), nil
+	}
+	return nil, fmt.Errorf("不支持的保留策略: %s", name)
+}
+
+// Preference 组内文件的偏好，返回值越小越优先保留
+type Preference func(f FileInfo) int
+
+// PreferName 优先保留文件名(不含目录)匹配 re 的文件，negate 为 true 时优先保留不匹配的，
+// 如用 `^(?i)copy of | \(\d+\)\.` 配合 negate 避开“副本”式的命名
+func PreferName(re *regexp.Regexp, negate bool) Preference {
+	return func(f FileInfo) int {
+		if re.MatchString(filepath.Base(f.Path)) != negate {
+			return 0
+		}
+		return 1
+	}
+}
+
+// Prefer 按偏好对各组内的文件稳定排序，偏好相同的保持原有顺序，之后再应用保留策略
+func (l GroupList) Prefer(p Preference) {
+	for _, g := range l {
+		sort.SliceStable(g.Files, func(i, j int) bool { return p(g.Files[i]) < p(g.Files[j]) })
+	}
+}
+
+// Deletions 按保留策略返回各组中可删除的文件，策略未保留任何文件的组仍保留第一个文件
+func (l GroupList) Deletions(p KeepPolicy) FileInfos {
+	files := FileInfos{}
+	for _, g := range l {
+		if len(g.Files) == 0 {
+			continue
+		}
+		keep := p.Keep(g.Files)
+		if !slices.Contains(keep, true) {
+			keep[0] = true
+		}
+		for i, f := range g.Files {
+			if !keep[i] {
+				files = append(files, f)
+			}
+		}
+	}
+	return files
+}
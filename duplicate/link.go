@@ -0,0 +1,189 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Hardlink 将组内除 keeper 外的文件替换为指向 keeper 的硬链接，返回替换的文件数。
+// 目录结构和文件名保持不变，同时释放重复占用的空间
+func Hardlink(group Group, keeper string) (int, error) {
+	return NewCleaner(CleanOptions{Progress: true}).Hardlink(group, keeper)
+}
+
+// Symlink 将组内除 keeper 外的文件替换为指向 keeper 的符号链接，返回替换的文件数。
+// relative 为 true 时链接使用相对于所在目录的路径，整个目录树移动后链接仍然有效
+func Symlink(group Group, keeper string, relative bool) (int, error) {
+	return NewCleaner(CleanOptions{Progress: true, RelativeLinks: relative}).Symlink(group, keeper)
+}
+
+// Reflink 同 Cleaner.Reflink，使用默认选项
+func Reflink(group Group, keeper string) (int, error) {
+	return NewCleaner(CleanOptions{Progress: true}).Reflink(group, keeper)
+}
+
+// Hardlink 同 duplicate.Hardlink，受保护的路径同样不会被替换，每替换一个文件调用一次 OnCleaned。
+// 各文件须与 keeper 位于同一文件系统且内容与组记录的Hash值一致，已经是 keeper 的硬链接的文件直接跳过
+func (c *Cleaner) Hardlink(group Group, keeper string) (int, error) {
+	return c.replaceAll("创建硬链接", group, keeper, func(tmp, path string) error {
+		if err := os.Link(keeper, tmp); err != nil {
+			return fmt.Errorf("无法创建硬链接，文件须与保留的文件位于同一文件系统: %w", err)
+		}
+		return nil
+	})
+}
+
+// Symlink 同 duplicate.Symlink，CleanOptions.RelativeLinks 决定是否使用相对路径。
+// 各文件的内容须与组记录的Hash值一致，已经是指向 keeper 的符号链接的文件直接跳过
+func (c *Cleaner) Symlink(group Group, keeper string) (int, error) {
+	target, err := filepath.Abs(keeper)
+	if err != nil {
+		return 0, newPathError("创建符号链接", keeper, err)
+	}
+	return c.replaceAll("创建符号链接", group, keeper, func(tmp, path string) error {
+		link := target
+		if c.opts.RelativeLinks {
+			abs, err := filepath.Abs(path)
+			if err != nil {
+				return err
+			}
+			if link, err = filepath.Rel(filepath.Dir(abs), target); err != nil {
+				return err
+			}
+		}
+		if err := os.Symlink(link, tmp); err != nil {
+			return fmt.Errorf("无法创建符号链接: %w", err)
+		}
+		return nil
+	})
+}
+
+// Reflink 将组内除 keeper 外的文件替换为 keeper 的写时复制克隆，返回替换的文件数。
+// 克隆与 keeper 共享存储但内容互相独立，之后修改任一文件都不影响其他文件；
+// 替换后的文件保留原来的权限和修改时间，内容与组记录的Hash值不符的文件不会被覆盖。需要 Btrfs、XFS、APFS 等支持克隆的文件系统，
+// 不支持时返回包装了 ErrCloneUnsupported 的错误
+func (c *Cleaner) Reflink(group Group, keeper string) (int, error) {
+	return c.replaceAll("克隆文件", group, keeper, func(tmp, path string) error {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if err := cloneFile(keeper, tmp); err != nil {
+			return err
+		}
+		if err := os.Chmod(tmp, info.Mode().Perm()); err != nil {
+			os.Remove(tmp)
+			return err
+		}
+		if err := os.Chtimes(tmp, info.ModTime(), info.ModTime()); err != nil {
+			os.Remove(tmp)
+			return err
+		}
+		return nil
+	})
+}
+
+// replaceAll 将组内除 keeper 外的文件逐个替换为 create 在临时路径上创建的链接。
+// keeper 和各文件替换前都按组记录的Hash值核对内容，记录中没有Hash值时以 keeper 当前的内容为准，
+// 清单过期或被改动时内容不同的文件不会被替换，否则其中的数据将无法找回
+func (c *Cleaner) replaceAll(op string, group Group, keeper string, create func(tmp, path string) error) (int, error) {
+	if !isOSFS(c.opts.FS) {
+		return 0, errors.New("只能在本机文件系统上创建链接")
+	}
+	if IsArchivePath(keeper) {
+		return 0, newPathError(op, keeper, errArchiveReadOnly)
+	}
+	kinfo, err := os.Lstat(keeper)
+	if err != nil {
+		return 0, newPathError(op, keeper, err)
+	}
+	if !kinfo.Mode().IsRegular() || (group.Size > 0 && kinfo.Size() != group.Size) {
+		return 0, newPathError(op, keeper, fmt.Errorf("%w: 保留的文件已变化", ErrHashMismatch))
+	}
+	want := group.Hash
+	if want == "" {
+		sums, err := hashFileMulti(c.opts.FS, keeper, []string{"sha256"})
+		if err != nil {
+			return 0, newPathError(op, keeper, err)
+		}
+		want = sums[0]
+	} else if err := c.verifyContent(FileInfo{Path: keeper, Size: group.Size, Hash: want}, kinfo); err != nil {
+		return 0, newPathError(op, keeper, err)
+	}
+	n := 0
+	errs := []error{}
+	bar := newTracker(c.opts.Progress, c.opts.OnProgress, StageLink, "替换为链接", int64(len(group.Files)), group.Size*int64(len(group.Files)))
+	defer bar.Close()
+	for _, f := range group.Files {
+		bar.Add(1, group.Size)
+		if f.Path == keeper {
+			continue
+		}
+		linked, err := c.replace(keeper, kinfo, want, f.Path, create)
+		if err != nil {
+			errs = append(errs, newPathError(op, f.Path, err))
+			continue
+		}
+		if !linked {
+			continue
+		}
+		n += 1
+		if c.opts.OnCleaned != nil {
+			c.opts.OnCleaned(f.Path)
+		}
+	}
+	return n, errors.Join(errs...)
+}
+
+// replace 核对 path 的内容与 keeper 的Hash值 want 一致后，先在同一目录下用 create 创建指向 keeper 的临时链接，
+// 再原子地改名覆盖 path，中途失败时 path 保持原样。path 已是 keeper 的硬链接或指向 keeper 的符号链接时返回 false
+func (c *Cleaner) replace(keeper string, kinfo os.FileInfo, want string, path string, create func(tmp, path string) error) (bool, error) {
+	if IsArchivePath(path) {
+		return false, errArchiveReadOnly
+	}
+	if err := c.protected(path); err != nil {
+		return false, err
+	}
+	info, err := os.Lstat(path)
+	if err != nil {
+		return false, err
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		if target, err := os.Stat(path); err == nil && os.SameFile(target, kinfo) {
+			return false, nil
+		}
+	}
+	if !info.Mode().IsRegular() {
+		return false, errors.New("不是普通文件")
+	}
+	if os.SameFile(info, kinfo) {
+		return false, nil
+	}
+	if info.Size() != kinfo.Size() {
+		return false, fmt.Errorf("%w: 大小为 %d 字节，保留的文件为 %d 字节", ErrHashMismatch, info.Size(), kinfo.Size())
+	}
+	if err := c.verifyContent(FileInfo{Path: path, Size: kinfo.Size(), Hash: want}, info); err != nil {
+		return false, err
+	}
+	tmp := filepath.Join(filepath.Dir(path), fmt.Sprintf(".%s.%d.dclink", filepath.Base(path), os.Getpid()))
+	if err := create(tmp, path); err != nil {
+		return false, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,75 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate_test
+
+import (
+	"duplicate-cleaner/duplicate"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// linkMethods 将组内文件替换为链接的各种方式
+var linkMethods = map[string]func(c *duplicate.Cleaner, g duplicate.Group, keeper string) (int, error){
+	"hardlink": (*duplicate.Cleaner).Hardlink,
+	"symlink":  (*duplicate.Cleaner).Symlink,
+	"reflink":  (*duplicate.Cleaner).Reflink,
+}
+
+// newLinkGroup 在临时目录中创建 a、b 内容相同，c 大小相同但内容不同的一组文件，
+// 模拟清单生成后 c 被改动的情形
+func newLinkGroup(t *testing.T, hash string) (duplicate.Group, string) {
+	dir := t.TempDir()
+	files := duplicate.FileInfos{}
+	for name, content := range map[string]string{"a": "hello", "b": "hello", "c": "world"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, duplicate.FileInfo{Path: path, Size: 5, Hash: hash})
+	}
+	return duplicate.Group{Hash: hash, Size: 5, Files: files}, dir
+}
+
+func TestLinkMismatch(t *testing.T) {
+	// hello 的 md5，以及清单中没有Hash值的情形
+	for _, hash := range []string{"5d41402abc4b2a76b9719d911017c592", ""} {
+		for name, link := range linkMethods {
+			g, dir := newLinkGroup(t, hash)
+			keeper := filepath.Join(dir, "a")
+			n, err := link(duplicate.NewCleaner(duplicate.CleanOptions{}), g, keeper)
+			if n != 1 && !errors.Is(err, duplicate.ErrCloneUnsupported) {
+				t.Errorf("%s: 应只替换内容相同的 b，实际为 %d", name, n)
+			}
+			if !errors.Is(err, duplicate.ErrHashMismatch) {
+				t.Errorf("%s: 内容不同的文件应报告 ErrHashMismatch: %v", name, err)
+			}
+			if data, _ := os.ReadFile(filepath.Join(dir, "c")); string(data) != "world" {
+				t.Errorf("%s: 内容不同的文件不应被替换，现为 %q", name, data)
+			}
+			if data, _ := os.ReadFile(filepath.Join(dir, "b")); string(data) != "hello" {
+				t.Errorf("%s: 内容相同的文件内容不应改变，现为 %q", name, data)
+			}
+		}
+	}
+}
+
+func TestHardlinkKeeperChanged(t *testing.T) {
+	g, dir := newLinkGroup(t, "5d41402abc4b2a76b9719d911017c592")
+	n, err := duplicate.NewCleaner(duplicate.CleanOptions{}).Hardlink(g, filepath.Join(dir, "c"))
+	if n != 0 || !errors.Is(err, duplicate.ErrHashMismatch) {
+		t.Errorf("保留的文件与记录不符时不应替换任何文件，实际为 %d: %v", n, err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "a")); string(data) != "hello" {
+		t.Errorf("a 不应被替换，现为 %q", data)
+	}
+}
@@ -0,0 +1,47 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import "io/fs"
+
+// fsBoundary 限制遍历不跨越文件系统：设备号与所在扫描路径不同的目录不进入；
+// 同一设备上的绑定挂载设备号相同，再按挂载信息(仅 Linux)识别挂载点
+type fsBoundary struct {
+	points map[string]bool // 挂载点
+	dev    uint64          // 当前扫描路径所在的设备
+	known  bool            // 是否获取到了当前扫描路径的设备号
+}
+
+func newFSBoundary() *fsBoundary {
+	b := &fsBoundary{points: map[string]bool{}}
+	for _, m := range readMounts() {
+		b.points[m.point] = true
+	}
+	return b
+}
+
+// setRoot 开始遍历一个扫描路径
+func (b *fsBoundary) setRoot(root string, info fs.FileInfo) {
+	key, ok := fileKeyOf(root, info)
+	b.dev, b.known = key.dev, ok
+}
+
+// crosses 进入该目录是否会跨越到其他文件系统
+func (b *fsBoundary) crosses(path string, info fs.FileInfo) bool {
+	if b.points[path] {
+		return true
+	}
+	if !b.known {
+		return false
+	}
+	key, ok := fileKeyOf(path, info)
+	return ok && key.dev != b.dev
+}
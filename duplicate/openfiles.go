@@ -0,0 +1,90 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import "sort"
+
+// deferOpen 检测候选文件中正被其他进程写入的文件(如正在增长的日志、正在下载的文件)，
+// 将含有这些文件的组推迟到最后计算，返回可立即计算的组和推迟的组。未启用 SkipOpen 或非本机文件系统时不检测
+func (s *Scanner) deferOpen(groups [][]*FileInfo) ([][]*FileInfo, [][]*FileInfo) {
+	if !s.opts.SkipOpen || !isOSFS(s.opts.FS) {
+		return groups, nil
+	}
+	busy := openForWrite(candidatePaths(groups))
+	if len(busy) == 0 {
+		return groups, nil
+	}
+	ready, later := [][]*FileInfo{}, [][]*FileInfo{}
+	for _, g := range groups {
+		if containsOpen(g, busy) {
+			later = append(later, g)
+		} else {
+			ready = append(ready, g)
+		}
+	}
+	return ready, later
+}
+
+// dropOpen 再次检测推迟的组，仍被写入的文件不计算Hash值，记为正在写入的文件(见 Scanner.OpenFiles)，
+// 剩余文件不足 MinCopies 的组整组去掉。返回仍需计算的组和不再计算的文件数
+func (s *Scanner) dropOpen(groups [][]*FileInfo) ([][]*FileInfo, int) {
+	if len(groups) == 0 {
+		return nil, 0
+	}
+	busy := openForWrite(candidatePaths(groups))
+	kept, skipped := [][]*FileInfo{}, 0
+	for _, g := range groups {
+		rest := []*FileInfo{}
+		for _, f := range g {
+			if busy[f.Path] {
+				s.openFiles = append(s.openFiles, f.Path)
+			} else {
+				rest = append(rest, f)
+			}
+		}
+		if len(rest) < s.opts.MinCopies {
+			skipped += len(g)
+			continue
+		}
+		skipped += len(g) - len(rest)
+		kept = append(kept, rest)
+	}
+	return kept, skipped
+}
+
+// candidatePaths 候选组中本机文件的路径，归档中的文件不会被写入
+func candidatePaths(groups [][]*FileInfo) map[string]bool {
+	paths := map[string]bool{}
+	for _, g := range groups {
+		for _, f := range g {
+			if !IsArchivePath(f.Path) {
+				paths[f.Path] = true
+			}
+		}
+	}
+	return paths
+}
+
+// containsOpen 组中是否有正被写入的文件
+func containsOpen(g []*FileInfo, busy map[string]bool) bool {
+	for _, f := range g {
+		if busy[f.Path] {
+			return true
+		}
+	}
+	return false
+}
+
+// OpenFiles 返回启用 SkipOpen 时因一直被其他进程写入而未计算Hash值、未计入结果的文件，按路径排列，须在扫描结束后调用
+func (s *Scanner) OpenFiles() []string {
+	sort.Strings(s.openFiles)
+	return s.openFiles
+}
@@ -0,0 +1,68 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// openForWrite 遍历 /proc 中各进程打开的文件，返回 paths 中被以写方式(O_WRONLY 或 O_RDWR)打开的文件。
+// 只能看到有权限访问的进程，其他用户的进程需要 root 权限
+func openForWrite(paths map[string]bool) map[string]bool {
+	busy := map[string]bool{}
+	procs, err := os.ReadDir("/proc")
+	if err != nil {
+		return busy
+	}
+	self := strconv.Itoa(os.Getpid())
+	for _, p := range procs {
+		pid := p.Name()
+		if pid == self || pid[0] < '0' || pid[0] > '9' {
+			continue
+		}
+		dir := filepath.Join("/proc", pid, "fd")
+		fds, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, fd := range fds {
+			target, err := os.Readlink(filepath.Join(dir, fd.Name()))
+			if err != nil || !paths[target] || busy[target] {
+				continue
+			}
+			if fdWritable(filepath.Join("/proc", pid, "fdinfo", fd.Name())) {
+				busy[target] = true
+			}
+		}
+	}
+	return busy
+}
+
+// fdWritable 根据 fdinfo 中的 flags(八进制)判断文件描述符是否可写
+func fdWritable(fdinfo string) bool {
+	f, err := os.Open(fdinfo)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if v, ok := strings.CutPrefix(scanner.Text(), "flags:"); ok {
+			flags, err := strconv.ParseUint(strings.TrimSpace(v), 8, 32)
+			return err == nil && flags&uint64(os.O_WRONLY|os.O_RDWR) != 0
+		}
+	}
+	return false
+}
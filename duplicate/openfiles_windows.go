@@ -0,0 +1,41 @@
+//go:build windows
+
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import (
+	"errors"
+
+	"golang.org/x/sys/windows"
+)
+
+// openForWrite 以不允许他人写入的共享方式逐个打开文件，返回 paths 中因共享冲突而无法打开的文件，
+// 即正被其他进程以写方式打开的文件
+func openForWrite(paths map[string]bool) map[string]bool {
+	busy := map[string]bool{}
+	for path := range paths {
+		p, err := windows.UTF16PtrFromString(path)
+		if err != nil {
+			continue
+		}
+		h, err := windows.CreateFile(p, windows.GENERIC_READ, windows.FILE_SHARE_READ|windows.FILE_SHARE_DELETE,
+			nil, windows.OPEN_EXISTING, windows.FILE_ATTRIBUTE_NORMAL, 0)
+		if errors.Is(err, windows.ERROR_SHARING_VIOLATION) {
+			busy[path] = true
+			continue
+		}
+		if err == nil {
+			windows.CloseHandle(h)
+		}
+	}
+	return busy
+}
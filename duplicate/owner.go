@@ -0,0 +1,68 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import (
+	"sort"
+	"sync"
+)
+
+// OwnerUsage 某个所有者名下的重复文件占用
+type OwnerUsage struct {
+	Owner string `json:"owner"`
+	Files int    `json:"files"` // 重复的副本数
+	Bytes int64  `json:"bytes"` // 重复副本占用的空间
+}
+
+// ownerCache 缓存 uid、SID 到用户名的解析结果
+var ownerCache sync.Map
+
+// UsageByOwner 按所有者汇总重复文件占用的空间，每组第一个文件视为保留的原件，
+// 其余副本计入各自所有者名下，结果按占用从大到小排列
+func UsageByOwner(l GroupList) []OwnerUsage {
+	usage := map[string]*OwnerUsage{}
+	for _, g := range l {
+		for _, f := range g.Files[1:] {
+			u, ok := usage[f.Owner]
+			if !ok {
+				u = &OwnerUsage{Owner: f.Owner}
+				usage[f.Owner] = u
+			}
+			u.Files += 1
+			u.Bytes += f.Size
+		}
+	}
+	lst := []OwnerUsage{}
+	for _, u := range usage {
+		lst = append(lst, *u)
+	}
+	sort.Slice(lst, func(i, j int) bool {
+		if lst[i].Bytes != lst[j].Bytes {
+			return lst[i].Bytes > lst[j].Bytes
+		}
+		return lst[i].Owner < lst[j].Owner
+	})
+	return lst
+}
+
+// ByOwner 返回包含指定所有者文件的组
+func (l GroupList) ByOwner(owner string) GroupList {
+	lst := GroupList{}
+	for _, g := range l {
+		for _, f := range g.Files {
+			if f.Owner == owner {
+				lst = append(lst, g)
+				break
+			}
+		}
+	}
+	return lst
+}
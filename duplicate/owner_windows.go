@@ -0,0 +1,42 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import (
+	"io/fs"
+
+	"golang.org/x/sys/windows"
+)
+
+// fileOwner 返回文件所有者的账户名(域\用户)，无法解析时返回 SID
+func fileOwner(path string, info fs.FileInfo) string {
+	sd, err := windows.GetNamedSecurityInfo(path, windows.SE_FILE_OBJECT, windows.OWNER_SECURITY_INFORMATION)
+	if err != nil {
+		return ""
+	}
+	sid, _, err := sd.Owner()
+	if err != nil || sid == nil {
+		return ""
+	}
+	key := sid.String()
+	if name, ok := ownerCache.Load(key); ok {
+		return name.(string)
+	}
+	name := key
+	if account, domain, _, err := sid.LookupAccount(""); err == nil {
+		name = account
+		if domain != "" {
+			name = domain + `\` + account
+		}
+	}
+	ownerCache.Store(key, name)
+	return name
+}
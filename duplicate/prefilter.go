@@ -0,0 +1,161 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/zeebo/xxh3"
+)
+
+// prefilterSize 预筛时读取的文件开头长度，不超过该大小的组直接计算完整Hash值
+const prefilterSize = 64 << 10
+
+// prefilter 只读取各文件开头 prefilterSize 字节计算Hash值，按结果拆分大小相同的组，
+// 并丢弃文件数不足 MinCopies 的子组，只有开头相同的文件才需要读完整个文件。
+// 预筛只用于排除，开头的Hash值即使碰撞也只是多计算一次完整Hash值，因此使用最快的非加密Hash。
+// 全部文件都能从索引中取得Hash值的组不需要读取，原样保留
+func (s *Scanner) prefilter(ctx context.Context, groups [][]*FileInfo) ([][]*FileInfo, []error) {
+	type job struct {
+		group []*FileInfo
+		heads []uint64
+		oks   []bool
+	}
+	jobs := []*job{}
+	total := 0
+	for _, g := range groups {
+		if g[0].Size <= prefilterSize || s.allIndexed(g) {
+			continue
+		}
+		jobs = append(jobs, &job{group: g, heads: make([]uint64, len(g)), oks: make([]bool, len(g))})
+		total += len(g)
+	}
+	if total == 0 {
+		return groups, nil
+	}
+	bar := newTracker(s.opts.Progress, s.opts.OnProgress, StagePrefilter, "预筛文件", int64(total), 0)
+	defer bar.Close()
+	wg := sync.WaitGroup{}
+	c := make(chan struct{}, s.opts.Count)
+	m := sync.Mutex{}
+	errs := []error{}
+	for _, j := range jobs {
+		for i, f := range j.group {
+			run := func() {
+				defer wg.Done()
+				c <- struct{}{}
+				defer func() { <-c }()
+				if ctx.Err() != nil {
+					return
+				}
+				h, err := s.headHash(f.Path)
+				if err != nil {
+					err = s.checkVolatile(f, err)
+				}
+				m.Lock()
+				defer m.Unlock()
+				switch {
+				case s.addVolatile(err):
+				case err != nil:
+					err = newPathError("预筛", f.Path, err)
+					errs = append(errs, err)
+					s.opts.onError(err)
+				default:
+					j.heads[i], j.oks[i] = h, true
+				}
+				bar.Add(1, 0)
+			}
+			wg.Add(1)
+			if s.opts.Count == 1 {
+				run()
+			} else {
+				go run()
+			}
+		}
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		return groups, errs
+	}
+	split := map[*FileInfo]*job{}
+	for _, j := range jobs {
+		split[j.group[0]] = j
+	}
+	result := [][]*FileInfo{}
+	for _, g := range groups {
+		j, ok := split[g[0]]
+		if !ok {
+			result = append(result, g)
+			continue
+		}
+		// 按首次出现的顺序输出子组，扫描结果与不预筛时一致
+		order := []uint64{}
+		subs := map[uint64][]*FileInfo{}
+		for i, f := range j.group {
+			if !j.oks[i] {
+				continue
+			}
+			if _, ok := subs[j.heads[i]]; !ok {
+				order = append(order, j.heads[i])
+			}
+			subs[j.heads[i]] = append(subs[j.heads[i]], f)
+		}
+		for _, h := range order {
+			if len(subs[h]) >= s.opts.MinCopies {
+				result = append(result, subs[h])
+			}
+		}
+	}
+	return result, errs
+}
+
+// headHash 计算文件开头 prefilterSize 字节的Hash值
+func (s *Scanner) headHash(path string) (uint64, error) {
+	r, err := s.open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+	buf := make([]byte, prefilterSize)
+	n, err := io.ReadFull(r, buf)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return 0, err
+	}
+	return xxh3.Hash(buf[:n]), nil
+}
+
+// allIndexed 组内的文件是否都能从索引或缓存中取得Hash值
+func (s *Scanner) allIndexed(group []*FileInfo) bool {
+	index := s.opts.Index
+	if index != nil && index.Hash() != HashName(s.opts.Hash) {
+		index = nil
+	}
+	if index == nil && s.opts.Cache == nil {
+		return false
+	}
+	for _, f := range group {
+		if index != nil {
+			if _, ok := index.Lookup(*f); ok {
+				continue
+			}
+		}
+		if s.opts.Cache != nil {
+			if _, ok := s.opts.Cache.Lookup(*f); ok {
+				continue
+			}
+		}
+		return false
+	}
+	return true
+}
@@ -0,0 +1,60 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import "sort"
+
+// Priority 目录的优先级，Pattern 的写法见 Presets，Level 越大越先遍历和计算，未匹配的目录为 0，可为负数
+type Priority struct {
+	Pattern string
+	Level   int
+}
+
+// priorityLevel 路径的优先级，取匹配的最深一级目录的规则，同一级匹配多条时取靠前的
+func priorityLevel(path string, prios []Priority) int {
+	elems := splitPath(path)
+	for k := len(elems); k > 0; k-- {
+		for _, p := range prios {
+			if matchPattern(elems[:k], p.Pattern) {
+				return p.Level
+			}
+		}
+	}
+	return 0
+}
+
+// sortRootsByPriority 按优先级从高到低排列扫描路径，优先级相同的保持原顺序
+func sortRootsByPriority(roots []string, prios []Priority) {
+	if len(prios) == 0 {
+		return
+	}
+	levels := map[string]int{}
+	for _, r := range roots {
+		levels[r] = priorityLevel(r, prios)
+	}
+	sort.SliceStable(roots, func(i, j int) bool { return levels[roots[i]] > levels[roots[j]] })
+}
+
+// sortGroupsByPriority 按组内文件的最高优先级从高到低排列候选组，使重要目录中的重复先被确认
+func sortGroupsByPriority(groups [][]*FileInfo, prios []Priority) {
+	if len(prios) == 0 {
+		return
+	}
+	levels := make(map[*FileInfo]int, len(groups))
+	for _, g := range groups {
+		level := priorityLevel(g[0].Path, prios)
+		for _, f := range g[1:] {
+			level = max(level, priorityLevel(f.Path, prios))
+		}
+		levels[g[0]] = level
+	}
+	sort.SliceStable(groups, func(i, j int) bool { return levels[groups[i][0]] > levels[groups[j][0]] })
+}
@@ -0,0 +1,113 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import (
+	"sync"
+	"time"
+
+	"github.com/schollz/progressbar/v3"
+)
+
+// 进度事件的阶段
+const (
+	StageWalk        = "walk"         // 遍历文件，总数未知
+	StagePrefilter   = "prefilter"    // 预筛文件开头
+	StageHash        = "hash"         // 计算Hash值
+	StageCheck       = "check"        // 预检文件
+	StageClean       = "clean"        // 清理文件
+	StageLink        = "link"         // 替换为链接
+	StageVerifyIndex = "verify-index" // 校验索引
+)
+
+// progressInterval 同一阶段两次进度事件之间的最短间隔，阶段开始和结束时总会报告
+const progressInterval = time.Second
+
+// Progress 进度事件，供包装程序、CI 和图形界面自行显示进度，不必解析进度条
+type Progress struct {
+	Stage      string        // 阶段，见 StageWalk 等
+	Done       int64         // 已处理的文件数，遍历时为已访问的目录和文件数
+	Total      int64         // 文件总数，-1 表示未知
+	Bytes      int64         // 已处理的字节数，遍历时为已找到的候选文件的总大小
+	TotalBytes int64         // 总字节数，0 表示未知
+	Elapsed    time.Duration // 该阶段已用的时间
+	ETA        time.Duration // 预计剩余时间，无法估计时为 -1
+	Final      bool          // 该阶段已结束
+}
+
+// tracker 同时驱动进度条和进度事件，两者都可以不启用；可被并发调用
+type tracker struct {
+	m     sync.Mutex
+	bar   *progressbar.ProgressBar
+	on    func(Progress)
+	p     Progress
+	start time.Time
+	last  time.Time
+}
+
+// newTracker 开始一个阶段，show 为 false 时不显示进度条，on 为空时不报告进度事件
+func newTracker(show bool, on func(Progress), stage, description string, total, totalBytes int64) *tracker {
+	t := &tracker{on: on, start: time.Now(), p: Progress{Stage: stage, Total: total, TotalBytes: totalBytes}}
+	if show {
+		t.bar = progressbar.Default(total, description)
+	} else {
+		t.bar = progressbar.DefaultSilent(total, description)
+	}
+	t.m.Lock()
+	t.report(false)
+	t.m.Unlock()
+	return t
+}
+
+// Add 记录处理完成的文件数和字节数
+func (t *tracker) Add(n int, bytes int64) {
+	if n > 0 {
+		t.bar.Add(n)
+	}
+	t.m.Lock()
+	defer t.m.Unlock()
+	t.p.Done += int64(n)
+	t.p.Bytes += bytes
+	if time.Since(t.last) >= progressInterval {
+		t.report(false)
+	}
+}
+
+// Close 结束该阶段
+func (t *tracker) Close() {
+	t.bar.Close()
+	t.m.Lock()
+	defer t.m.Unlock()
+	t.report(true)
+}
+
+// report 报告当前进度，调用时需持有锁
+func (t *tracker) report(final bool) {
+	if t.on == nil {
+		return
+	}
+	t.last = time.Now()
+	p := t.p
+	p.Elapsed = t.last.Sub(t.start)
+	p.Final = final
+	// 优先按字节数估计，文件大小相差很大时比按文件数准确
+	switch {
+	case final:
+		p.ETA = 0
+	case p.TotalBytes > 0 && p.Bytes > 0:
+		p.ETA = time.Duration(float64(p.Elapsed) * float64(p.TotalBytes-p.Bytes) / float64(p.Bytes))
+	case p.Total > 0 && p.Done > 0:
+		p.ETA = time.Duration(float64(p.Elapsed) * float64(p.Total-p.Done) / float64(p.Done))
+	default:
+		p.ETA = -1
+	}
+	t.on(p)
+}
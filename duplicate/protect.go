@@ -0,0 +1,45 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import (
+	"os"
+	"runtime"
+)
+
+// DefaultProtected 默认受保护的系统目录，其中的文件不会被清理
+var DefaultProtected = defaultProtected()
+
+func defaultProtected() []string {
+	switch runtime.GOOS {
+	case "windows":
+		dirs := []string{}
+		for _, env := range []string{"SystemRoot", "ProgramFiles", "ProgramFiles(x86)", "ProgramData"} {
+			if d := os.Getenv(env); d != "" {
+				dirs = append(dirs, d)
+			}
+		}
+		return dirs
+	case "darwin":
+		return []string{"/System", "/Library", "/bin", "/sbin", "/usr", "/private/etc"}
+	}
+	return []string{"/bin", "/boot", "/etc", "/lib", "/lib64", "/sbin", "/usr", "/proc", "/sys", "/dev"}
+}
+
+// IsProtected 判断路径是否位于受保护的目录中，路径和目录都应为绝对路径
+func IsProtected(path string, protected []string) bool {
+	for _, p := range protected {
+		if IsWithin(path, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,175 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// PurgeResult 清空隔离目录的结果
+type PurgeResult struct {
+	Files     int   // 永久删除的文件数
+	Bytes     int64 // 释放的空间
+	KeptFiles int   // 仍留在隔离目录中的文件数
+	KeptBytes int64 // 仍留在隔离目录中的文件大小
+}
+
+// purgeItem 隔离清单中的一行，unparsed 为无法解析或指向隔离目录之外的行，原样保留
+type purgeItem struct {
+	QuarantineEntry
+	path     string
+	line     []byte
+	unparsed bool
+	purge    bool
+}
+
+// PurgeQuarantine 永久删除隔离目录中隔离时间超过 maxAge 的文件，之后总大小仍超过 maxSize 时
+// 从最早隔离的文件开始删除，直到不超过 maxSize，两者为 0 时不按该条件删除。
+// 已被还原或手动删除的文件同时从清单中去掉。调用方需保证期间没有向该目录隔离文件
+func PurgeQuarantine(dir string, maxAge time.Duration, maxSize int64) (PurgeResult, error) {
+	res := PurgeResult{}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return res, err
+	}
+	mpath := filepath.Join(abs, QuarantineManifest)
+	items, err := readQuarantine(abs, mpath)
+	if err != nil {
+		return res, err
+	}
+	now := time.Now()
+	total := int64(0)
+	for i := range items {
+		if items[i].unparsed {
+			continue
+		}
+		if maxAge > 0 && now.Sub(items[i].Time) > maxAge {
+			items[i].purge = true
+		} else {
+			total += items[i].Size
+		}
+	}
+	// 清单按隔离的先后追加，从前往后即从最早的开始
+	for i := 0; maxSize > 0 && total > maxSize && i < len(items); i++ {
+		if !items[i].unparsed && !items[i].purge {
+			items[i].purge = true
+			total -= items[i].Size
+		}
+	}
+	errs := []error{}
+	kept := [][]byte{}
+	for _, it := range items {
+		if it.unparsed {
+			kept = append(kept, it.line)
+			continue
+		}
+		if it.purge {
+			if err := os.Remove(it.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				errs = append(errs, newPathError("删除", it.path, err))
+				it.purge = false
+			} else {
+				res.Files += 1
+				res.Bytes += it.Size
+				removeEmptyDirs(filepath.Dir(it.path), abs)
+			}
+		}
+		if !it.purge {
+			kept = append(kept, it.line)
+			res.KeptFiles += 1
+			res.KeptBytes += it.Size
+		}
+	}
+	if err := writeQuarantine(mpath, kept); err != nil {
+		errs = append(errs, fmt.Errorf("更新隔离清单失败: %w", err))
+	}
+	return res, errors.Join(errs...)
+}
+
+// readQuarantine 读取隔离清单中仍在隔离目录中的文件，大小以实际文件为准；
+// 无法解析的行也一并返回，以便改写清单时原样保留，不因版本差异或手动编辑而丢失记录
+func readQuarantine(dir, mpath string) ([]purgeItem, error) {
+	f, err := os.Open(mpath)
+	if err != nil {
+		return nil, fmt.Errorf("%s 不是隔离目录: %w", dir, err)
+	}
+	defer f.Close()
+	items := []purgeItem{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := bytes.Clone(scanner.Bytes())
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		e := QuarantineEntry{}
+		if json.Unmarshal(line, &e) != nil || e.Stored == "" {
+			items = append(items, purgeItem{line: line, unparsed: true})
+			continue
+		}
+		path := filepath.Join(dir, filepath.FromSlash(e.Stored))
+		if !IsWithin(path, dir) {
+			items = append(items, purgeItem{line: line, unparsed: true})
+			continue
+		}
+		info, err := os.Lstat(path)
+		if err != nil {
+			continue
+		}
+		e.Size = info.Size()
+		items = append(items, purgeItem{QuarantineEntry: e, path: path, line: line})
+	}
+	return items, scanner.Err()
+}
+
+// writeQuarantine 用保留的行替换隔离清单，各行按读取时的原文写入，先写入临时文件再改名，中途失败时原清单不变
+func writeQuarantine(mpath string, lines [][]byte) error {
+	tmp := mpath + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	for _, line := range lines {
+		w.Write(line)
+		w.WriteByte('\n')
+	}
+	err = w.Flush()
+	if err == nil {
+		err = f.Sync()
+	}
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	if err == nil {
+		err = os.Rename(tmp, mpath)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
+
+// removeEmptyDirs 从 dir 开始向上删除空目录，直到 root(不含)
+func removeEmptyDirs(dir, root string) {
+	for dir != root && IsWithin(dir, root) {
+		if os.Remove(dir) != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
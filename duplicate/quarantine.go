@@ -0,0 +1,172 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// QuarantineManifest 隔离目录中记录原位置的清单文件名，每行一条 JSON 格式的 QuarantineEntry
+const QuarantineManifest = "manifest.jsonl"
+
+// QuarantineEntry 一个被移入隔离目录的文件
+type QuarantineEntry struct {
+	Time   time.Time `json:"time"`
+	Path   string    `json:"path"`   // 原路径
+	Stored string    `json:"stored"` // 在隔离目录中的相对路径
+	Size   int64     `json:"size"`
+}
+
+// quarantine 隔离目录，文件按原绝对路径的目录结构存放
+type quarantine struct {
+	dir      string
+	manifest *os.File
+}
+
+// openQuarantine 创建隔离目录并以追加方式打开清单
+func openQuarantine(dir string) (*quarantine, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(abs, 0700); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(filepath.Join(abs, QuarantineManifest), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &quarantine{dir: abs, manifest: f}, nil
+}
+
+// Close 关闭清单
+func (q *quarantine) Close() error {
+	return q.manifest.Close()
+}
+
+// move 将文件移入隔离目录并在清单中记录，同一路径再次隔离时加 .2、.3 等后缀，不覆盖已有文件，
+// 返回文件在隔离目录中的路径
+func (q *quarantine) move(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	if IsWithin(abs, q.dir) {
+		return "", errors.New("文件位于隔离目录中")
+	}
+	info, err := os.Lstat(abs)
+	if err != nil {
+		return "", err
+	}
+	rel := quarantinePath(abs)
+	if err := os.MkdirAll(filepath.Join(q.dir, filepath.Dir(rel)), 0700); err != nil {
+		return "", err
+	}
+	for i := 1; ; i++ {
+		stored := rel
+		if i > 1 {
+			stored = fmt.Sprintf("%s.%d", rel, i)
+		}
+		dst := filepath.Join(q.dir, stored)
+		ok, err := reserve(dst)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			continue
+		}
+		if err := moveFile(abs, dst, info); err != nil {
+			os.Remove(dst)
+			return "", err
+		}
+		line, _ := json.Marshal(QuarantineEntry{Time: time.Now(), Path: abs, Stored: filepath.ToSlash(stored), Size: info.Size()})
+		if _, err := q.manifest.Write(append(line, '\n')); err != nil {
+			return dst, fmt.Errorf("文件已移到 %s，但写入隔离清单失败: %w", dst, err)
+		}
+		return dst, nil
+	}
+}
+
+// reserve 独占创建空文件占用名称，之后再移动文件覆盖它，名称已被占用时返回 false
+func reserve(path string) (bool, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if errors.Is(err, fs.ErrExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, f.Close()
+}
+
+// quarantinePath 返回绝对路径在隔离目录中的相对路径，Windows 的盘符转为同名目录，如 C:\a 存为 C/a
+func quarantinePath(abs string) string {
+	vol := filepath.VolumeName(abs)
+	rest := strings.TrimLeft(abs[len(vol):], `/\`)
+	vol = strings.Trim(strings.NewReplacer(":", "", `\\`, "", `\`, string(filepath.Separator), "/", string(filepath.Separator)).Replace(vol), `/\`)
+	return filepath.Join(vol, rest)
+}
+
+// moveFile 移动文件，目标位于其他文件系统时复制内容、权限和修改时间后删除原文件
+func moveFile(src, dst string, info fs.FileInfo) error {
+	err := os.Rename(src, dst)
+	if err == nil {
+		return nil
+	}
+	var le *os.LinkError
+	if !errors.As(err, &le) || !info.Mode().IsRegular() {
+		return err
+	}
+	if _, e := os.Lstat(src); e != nil {
+		return err
+	}
+	if err := copyFile(src, dst, info); err != nil {
+		return err
+	}
+	if err := os.Remove(src); err != nil {
+		os.Remove(dst)
+		return err
+	}
+	return nil
+}
+
+// copyFile 复制文件内容并同步到磁盘，保留权限和修改时间
+func copyFile(src, dst string, info fs.FileInfo) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if err == nil {
+		err = out.Sync()
+	}
+	if e := out.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		return err
+	}
+	os.Chmod(dst, info.Mode().Perm())
+	return os.Chtimes(dst, info.ModTime(), info.ModTime())
+}
@@ -0,0 +1,41 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import "time"
+
+// rateLimiter 限制每秒的文件系统请求数，为空时不限制
+type rateLimiter struct {
+	clock    Clock
+	interval time.Duration
+	next     time.Time
+}
+
+// newRateLimiter 创建每秒最多 qps 次请求的限速器，qps 不大于0时返回 nil
+func newRateLimiter(qps float64, clock Clock) *rateLimiter {
+	if qps <= 0 {
+		return nil
+	}
+	return &rateLimiter{clock: clock, interval: time.Duration(float64(time.Second) / qps)}
+}
+
+// wait 等到允许发出下一次请求
+func (l *rateLimiter) wait() {
+	if l == nil {
+		return
+	}
+	now := l.clock.Now()
+	if l.next.After(now) {
+		l.clock.Sleep(l.next.Sub(now))
+		now = l.next
+	}
+	l.next = now.Add(l.interval)
+}
@@ -0,0 +1,29 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+// cloneFile 通过 clonefile(2) 克隆文件，支持 APFS
+func cloneFile(src, dst string) error {
+	if err := unix.Clonefile(src, dst, unix.CLONE_NOFOLLOW); err != nil {
+		if errors.Is(err, unix.ENOTSUP) || errors.Is(err, unix.EXDEV) {
+			return fmt.Errorf("%w: %v", ErrCloneUnsupported, err)
+		}
+		return err
+	}
+	return nil
+}
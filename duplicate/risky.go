@@ -0,0 +1,54 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import "path/filepath"
+
+// RiskyLocation 由应用程序管理的目录，其中的文件被应用的数据库引用，直接删除会损坏应用数据
+type RiskyLocation struct {
+	Pattern string // 目录模式，写法同 Presets
+	App     string // 管理该目录的应用程序
+}
+
+// RiskyLocations 已知的应用程序管理的目录
+var RiskyLocations = []RiskyLocation{
+	{"*.photoslibrary", "Photos"},
+	{"*.musiclibrary", "Music"},
+	{"*.tvlibrary", "TV"},
+	{"Music/iTunes", "iTunes"},
+	{"iTunes/iTunes Media", "iTunes"},
+	{"*.aplibrary", "Aperture"},
+	{"*.lrdata", "Lightroom"},
+	{"*.lrlibrary", "Lightroom"},
+	{"*.fcpbundle", "Final Cut Pro"},
+	{".thunderbird", "Thunderbird"},
+	{"Thunderbird/Profiles", "Thunderbird"},
+	{"/var/lib/docker", "Docker"},
+	{".local/share/docker", "Docker"},
+	{"AppData/Local/Docker", "Docker"},
+	{"Library/Containers/com.docker.docker", "Docker"},
+	{"/var/lib/containers", "Podman"},
+	{".local/share/containers", "Podman"},
+	{"Plex Media Server", "Plex"},
+}
+
+// RiskyApp 返回管理该文件所在目录的应用程序，不在已知的应用目录中时返回空
+func RiskyApp(path string) string {
+	elems := splitPath(filepath.Dir(path))
+	for i := len(elems); i > 0; i-- {
+		for _, loc := range RiskyLocations {
+			if matchPattern(elems[:i], loc.Pattern) {
+				return loc.App
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,101 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import (
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// RootOverlap 被合并的扫描路径及包含它的上级路径
+type RootOverlap struct {
+	Root   string
+	Parent string
+}
+
+// MergeRoots 将扫描路径转为解析符号链接后的真实路径，去掉与其他路径相同或位于其他路径之下的路径，
+// 保证每个文件只被扫描一次；返回保留的路径(保持原有顺序)和被合并的路径
+func MergeRoots(dirs []string) ([]string, []RootOverlap) {
+	return mergeRoots(dirs, CanonicalPath)
+}
+
+// mergeRoots 同 MergeRoots，canonical 将扫描路径转为用于比较的规范路径
+func mergeRoots(dirs []string, canonical func(string) string) ([]string, []RootOverlap) {
+	abs := make([]string, len(dirs))
+	for i, d := range dirs {
+		abs[i] = canonical(d)
+	}
+	// 短的路径排在前面，先确定上级路径
+	order := make([]int, len(dirs))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return len(abs[order[i]]) < len(abs[order[j]]) })
+	kept := map[int]bool{}
+	overlaps := []RootOverlap{}
+	for _, i := range order {
+		parent := ""
+		for k := range kept {
+			if IsWithin(abs[i], abs[k]) {
+				parent = abs[k]
+				break
+			}
+		}
+		if parent != "" {
+			overlaps = append(overlaps, RootOverlap{Root: abs[i], Parent: parent})
+			continue
+		}
+		kept[i] = true
+	}
+	roots := []string{}
+	for i := range dirs {
+		if kept[i] {
+			roots = append(roots, abs[i])
+		}
+	}
+	return roots, overlaps
+}
+
+// CanonicalPath 返回解析所有符号链接后的绝对路径，路径不存在等无法解析时返回绝对路径
+func CanonicalPath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	if real, err := filepath.EvalSymlinks(abs); err == nil {
+		return real
+	}
+	return abs
+}
+
+// FileURI 将绝对路径转为百分号编码的 file:// URI，如 /a b/c#1 转为 file:///a%20b/c%231，
+// Windows 的 C:\a 转为 file:///C:/a，UNC 路径 \\server\share\a 转为 file://server/share/a
+func FileURI(path string) string {
+	p := filepath.ToSlash(path)
+	u := &url.URL{Scheme: "file", Path: p}
+	if host, rest, ok := strings.Cut(strings.TrimPrefix(p, "//"), "/"); ok && strings.HasPrefix(p, "//") {
+		u.Host, u.Path = host, "/"+rest
+	} else if !strings.HasPrefix(p, "/") {
+		u.Path = "/" + p
+	}
+	return u.String()
+}
+
+// IsWithin 判断 path 是否为 root 本身或位于 root 之下
+func IsWithin(path, root string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
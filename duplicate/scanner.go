@@ -0,0 +1,525 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Options 扫描选项
+type Options struct {
+	Hash     string   // Hash算法: md5 | sha1 | sha256 | sha512 | blake3 | xxh64 | xxh128
+	Count    int      // 同时计算数量，小于1时按1处理，为1时按固定顺序依次计算
+	Progress bool     // 是否显示进度条
+	MaxFiles int      // 候选文件数上限，超出时中止扫描，0为不限制
+	MaxBytes int64    // 候选文件总字节数上限，超出时中止扫描，0为不限制
+	MinSize  int64    // 小于该大小的文件不作为候选，空文件总是不作为候选
+	MaxSize  int64    // 大于该大小的文件不作为候选，0为不限制
+	MaxDepth int      // 最多进入的目录层数，1为只扫描扫描路径下直接包含的文件，0为不限制
+	WalkQPS  float64  // 遍历时每秒最多的目录读取和文件信息请求数，用于云存储挂载，0为不限制
+	Exclude  []string // 不进入的目录，模式的写法见 Presets
+	Skip     []string // 跳过的文件(如 *.tmp)，模式的写法同 Exclude
+	Include  []string // 不为空时只有匹配其中任一模式的文件(如 *.jpg)才作为候选，模式的写法同 Exclude
+	Exts     []string // 不为空时只扫描这些扩展名(如 jpg、mp4，可带 .，不区分大小写)的文件
+
+	SkipHidden  bool     // 跳过名称以 . 开头的文件和目录，Windows 上还跳过带有隐藏属性的
+	IgnoreFiles []string // 遍历时读取各目录中这些名称的忽略文件(如 DcIgnore、GitIgnores，可含相对路径)，按 .gitignore 的写法跳过其中列出的路径
+
+	// 进入指向目录的符号链接，同一目录(包括链接成环时)只进入一次；指向文件的符号链接仍被跳过，
+	// 以免同一文件经链接和原路径出现两次。只支持本机文件系统，无法获取目标目录的标识(设备号和 inode 号)时不跟随
+	FollowSymlinks bool
+
+	// 不进入其他文件系统：跳过设备号与扫描路径不同的目录(如网络共享、外接磁盘的挂载点)，
+	// 在 Linux 上还跳过同一设备上的绑定挂载，只支持本机文件系统
+	OneFileSystem bool
+
+	ModifiedBefore time.Time // 只扫描修改时间早于该时间的文件，零值为不限制
+	ModifiedAfter  time.Time // 只扫描修改时间晚于该时间的文件，零值为不限制
+
+	// 目录的优先级，优先级高的扫描路径先遍历，含有其中文件的组先计算和输出，
+	// 适合配合 Groups 或超时停止时让重要目录的结果先出来
+	Priorities []Priority
+
+	Archives  []string // 以只读方式挂载并参与比较的归档文件(zip、tar)和光盘镜像(iso)
+	ByExt     bool     // 按扩展名预先分桶，扩展名不同的文件不视为重复
+	Adaptive  bool     // 按各组文件的数量和大小自动选择比较策略，以减少读取的字节数
+	Prefilter bool     // 先比较各文件开头64KB的Hash值，只对开头相同的文件计算完整Hash值
+	Verify    bool     // 输出前逐字节比较Hash值相同的文件，排除Hash碰撞
+	Tolerant  bool     // 计算前后重新获取文件信息，遍历后消失或改变的文件从组中去掉并记为易变文件(见 Scanner.Volatile)，不作为错误
+	SkipOpen  bool     // 推迟计算正被其他进程写入的文件(仅 Linux 和 Windows)，到最后仍在写入的不计算，记入 Scanner.OpenFiles
+	Scope     string   // 重复范围(见 Scopes)，为空时不限
+
+	MinCopies int // 只输出至少有这么多个文件的组，大小相同的文件不足该数时不计算Hash值，小于2时按2处理
+
+	Types       []string // 只扫描这些类别的文件(见 FileTypes)，按文件内容而非扩展名判断
+	DetectTypes bool     // 即使不过滤也检测并记录文件类别
+	Owners      bool     // 记录文件所有者
+
+	Index      *HashIndex // 大小和修改时间未变的文件直接使用索引中的Hash值，新算出的Hash值也会加入索引，可为空
+	Signatures bool       // 同时计算快速签名并记录到索引中，供 HashIndex.Candidates 预筛新文件
+	Cache      *HashCache // 索引中没有的文件再从缓存中查找Hash值，新算出的Hash值也会写入缓存，可为空
+
+	FS    FileSystem // 访问的文件系统，为空时使用 OSFS
+	Clock Clock      // 限速等待使用的时钟，为空时使用 SystemClock
+
+	// 以下回调均可为空，同一次扫描中不会被并发调用
+
+	OnFileScanned  func(f FileInfo) // 遍历到一个候选文件
+	OnHashComputed func(f FileInfo) // 算出一个文件的Hash值
+	OnGroupFound   func(g Group)    // 确认一组重复文件
+	OnError        func(err error)  // 扫描中出现错误，出错的文件或目录会被跳过
+	OnProgress     func(Progress)   // 各阶段开始、结束时和进行中每秒最多一次报告进度
+}
+
+// Scanner 重复文件扫描器
+type Scanner struct {
+	dirs   []string
+	opts   Options
+	err    error
+	mounts map[string]archiveFS
+	stats  Stats
+
+	volatile  []VolatileFile
+	openFiles []string
+}
+
+// Stats 遍历统计
+type Stats struct {
+	Dirs     int // 遍历的目录数
+	MaxDepth int // 遇到的最大目录深度，扫描路径本身为0
+
+	Mounts    []string // 设置了 OneFileSystem 时跳过的挂载点
+	Hardlinks int      // 与候选文件是同一文件的硬链接，因而不参与比较的文件数
+}
+
+// NewScanner 创建扫描器
+func NewScanner(dirs []string, opts Options) *Scanner {
+	if opts.Count < 1 {
+		opts.Count = 1
+	}
+	if opts.MinCopies < 2 {
+		opts.MinCopies = 2
+	}
+	if opts.FS == nil {
+		opts.FS = OSFS{}
+	}
+	if opts.Clock == nil {
+		opts.Clock = SystemClock{}
+	}
+	return &Scanner{dirs: dirs, opts: opts, mounts: map[string]archiveFS{}}
+}
+
+// List 扫描并返回完整的重复文件列表，按文件大小从大到小排列
+func (s *Scanner) List() (GroupList, error) {
+	return s.ListContext(context.Background())
+}
+
+// ListContext 同 List，ctx 取消或超时时停止扫描，返回已确认的组和包含 ctx 错误的 error
+func (s *Scanner) ListContext(ctx context.Context) (GroupList, error) {
+	lst := GroupList{}
+	err := s.scan(ctx, func(g Group) bool {
+		lst = append(lst, g)
+		return true
+	})
+	sortList(lst)
+	return lst, err
+}
+
+// sortList 按文件大小从大到小、Hash值从小到大排列
+func sortList(lst GroupList) {
+	sort.SliceStable(lst, func(i, j int) bool {
+		if lst[i].Size != lst[j].Size {
+			return lst[i].Size > lst[j].Size
+		}
+		return lst[i].Hash < lst[j].Hash
+	})
+}
+
+// Groups 扫描并在每组重复文件的Hash值全部算出后立即输出该组，
+// 扫描结束或 ctx 取消后关闭通道，之后可通过 Err 获取扫描中的错误
+func (s *Scanner) Groups(ctx context.Context) <-chan Group {
+	ch := make(chan Group)
+	go func() {
+		defer close(ch)
+		s.err = s.scan(ctx, func(g Group) bool {
+			select {
+			case ch <- g:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+	}()
+	return ch
+}
+
+// Stats 返回遍历统计，须在扫描结束后调用
+func (s *Scanner) Stats() Stats {
+	return s.stats
+}
+
+// Err 返回 Groups 扫描过程中的错误，须在通道关闭后调用
+func (s *Scanner) Err() error {
+	return s.err
+}
+
+// Estimate 仅按大小分组、不计算Hash值得到的重复情况上限
+type Estimate struct {
+	Files  int   // 候选文件数
+	Groups int   // 大小相同的组数
+	Dups   int   // 可能重复的文件数上限，每组保留一个
+	Bytes  int64 // 可释放空间的上限
+}
+
+// Estimate 遍历并按大小分组后立即返回估计结果，不读取文件内容
+func (s *Scanner) Estimate() (Estimate, error) {
+	defer s.unmountArchives()
+	est := Estimate{}
+	groups, files, err := s.candidates(context.Background())
+	if err != nil {
+		return est, err
+	}
+	est.Files = len(files)
+	est.Groups = len(groups)
+	for _, g := range groups {
+		est.Dups += len(g) - 1
+		est.Bytes += int64(len(g)-1) * g[0].Size
+	}
+	return est, nil
+}
+
+// candidates 遍历目录和归档，返回按大小分组后的候选文件及遍历到的全部文件
+func (s *Scanner) candidates(ctx context.Context) ([][]*FileInfo, []*FileInfo, error) {
+	var files []*FileInfo
+	if len(s.dirs) > 0 || len(s.opts.Archives) == 0 {
+		walked, err := s.walkDirs(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		files = walked
+	}
+	archived, err := s.mountArchives()
+	if err != nil {
+		return nil, nil, err
+	}
+	files = append(files, archived...)
+	groups := s.mergeHardlinks(groupBySize(files, s.opts.ByExt, s.opts.MinCopies))
+	return scopeCandidates(groups, s.opts.Scope, s.opts.MinCopies), files, nil
+}
+
+// scan 执行扫描，每得到一组重复文件就调用 emit，emit 返回 false 时停止输出
+func (s *Scanner) scan(ctx context.Context, emit func(Group) bool) error {
+	defer s.unmountArchives()
+	groups, files, err := s.candidates(ctx)
+	if err != nil {
+		return err
+	}
+	errs := []error{}
+	if s.opts.Prefilter {
+		groups, errs = s.prefilter(ctx, groups)
+	}
+	if s.opts.Signatures && s.opts.Index != nil {
+		s.indexSignatures(ctx, groups, files)
+	}
+	sortGroupsByPriority(groups, s.opts.Priorities)
+	if len(groups) == 0 {
+		return errors.Join(append(errs, ctx.Err())...)
+	}
+	total, totalBytes := 0, int64(0)
+	for _, g := range groups {
+		total += len(g)
+		totalBytes += groupBytes(g)
+	}
+	wg := sync.WaitGroup{}
+	c := make(chan struct{}, s.opts.Count)
+	m := sync.Mutex{}
+	stopped := false
+	bar := newTracker(s.opts.Progress, s.opts.OnProgress, StageHash, "计算Hash值", int64(total), totalBytes)
+	defer bar.Close()
+	// report 记录单个文件的计算结果，hashValue 为空表示已确认该文件不重复
+	report := func(f *FileInfo, hashValue string, err error) {
+		m.Lock()
+		defer m.Unlock()
+		switch {
+		case s.addVolatile(err):
+			// 已改变的文件不计入结果，也不作为错误
+		case err != nil:
+			err = newPathError("计算Hash值", f.Path, err)
+			errs = append(errs, err)
+			s.opts.onError(err)
+		case hashValue != "":
+			f.Hash = hashValue
+			if s.opts.Index != nil {
+				s.opts.Index.Add(*f)
+			}
+			if s.opts.Cache != nil {
+				if err := s.opts.Cache.Add(*f); err != nil {
+					s.opts.onError(newPathError("写入缓存", f.Path, err))
+				}
+			}
+			if s.opts.OnHashComputed != nil {
+				s.opts.OnHashComputed(*f)
+			}
+		}
+		bar.Add(1, f.Size)
+	}
+	// 签名在锁外计算，失败时只是不记录签名
+	record := report
+	if s.opts.Signatures && s.opts.Index != nil {
+		record = func(f *FileInfo, hashValue string, err error) {
+			if err == nil && hashValue != "" && !IsArchivePath(f.Path) {
+				f.Signature, _ = signature(s.opts.FS, f.Path)
+			}
+			report(f, hashValue, err)
+		}
+	}
+	// 计算期间文件可能被修改，算出Hash值或出错后再检查一次，未参与比较的文件无需检查
+	if s.opts.Tolerant {
+		hashed := record
+		record = func(f *FileInfo, hashValue string, err error) {
+			if hashValue != "" || err != nil {
+				if err = s.checkVolatile(f, err); err != nil {
+					hashValue = ""
+				}
+			}
+			hashed(f, hashValue, err)
+		}
+	}
+	// dispatch 按组内文件的计算任务依次占用计算名额
+	dispatch := func(group []*FileInfo) {
+		cached, rest, unique := s.lookupIndex(group)
+		strategy := StrategyFull
+		// 部分文件已有Hash值时，同步比较无法判断与这些文件是否相同，只能计算完整Hash值
+		if s.opts.Adaptive && len(cached) == 0 {
+			strategy = s.chooseStrategy(rest)
+		}
+		jobs := s.hashJobs(rest, strategy, record)
+		if len(cached) > 0 || len(unique) > 0 {
+			jobs = append(jobs, func() {
+				for _, f := range cached {
+					report(f, f.Hash, nil)
+				}
+				for _, f := range unique {
+					report(f, "", nil)
+				}
+			})
+		}
+		// 同组最后一个完成的任务负责按Hash值分组并输出
+		remain := len(jobs)
+		for _, job := range jobs {
+			wg.Add(1)
+			run := func(job func()) {
+				defer wg.Done()
+				if ctx.Err() == nil {
+					job()
+				}
+				<-c
+				m.Lock()
+				remain -= 1
+				last := remain == 0 && !stopped && ctx.Err() == nil
+				m.Unlock()
+				if !last {
+					return
+				}
+				found := scopeGroups(groupByHash(group, s.opts.MinCopies), s.opts.Scope)
+				// 逐字节比较在锁外进行，同步比较的组已逐块比较过内容，无需再比较
+				var verrs []error
+				if s.opts.Verify && strategy != StrategyLockstep {
+					c <- struct{}{}
+					found, verrs = s.verifyGroups(found)
+					<-c
+				}
+				m.Lock()
+				defer m.Unlock()
+				for _, err := range verrs {
+					if s.addVolatile(err) {
+						continue
+					}
+					// Hash碰撞不影响扫描结果，只通过回调告知
+					if !errors.Is(err, errCollision) {
+						errs = append(errs, err)
+					}
+					s.opts.onError(err)
+				}
+				if stopped || ctx.Err() != nil {
+					return
+				}
+				for _, g := range found {
+					if s.opts.Adaptive {
+						g.Strategy = strategy
+					}
+					if s.opts.OnGroupFound != nil {
+						s.opts.OnGroupFound(g)
+					}
+					if !emit(g) {
+						stopped = true
+						return
+					}
+				}
+			}
+			// 按组的顺序占用计算名额，排在前面的组先开始计算；
+			// 只允许一个并发时在当前协程中依次执行，各回调的调用顺序固定
+			c <- struct{}{}
+			if s.opts.Count == 1 {
+				run(job)
+			} else {
+				go run(job)
+			}
+		}
+	}
+	ready, later := s.deferOpen(groups)
+	for _, group := range ready {
+		dispatch(group)
+	}
+	// 推迟的组在其他组都开始计算后再检测一次，期间写完的文件照常计算
+	deferred := groupsBytes(later)
+	later, skipped := s.dropOpen(later)
+	bar.Add(skipped, deferred-groupsBytes(later))
+	for _, group := range later {
+		dispatch(group)
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		errs = append(errs, ctx.Err())
+	}
+	return errors.Join(errs...)
+}
+
+// hashJobs 按策略将同一大小组的计算拆分为可并行的任务
+func (s *Scanner) hashJobs(group []*FileInfo, strategy string, report func(*FileInfo, string, error)) []func() {
+	switch strategy {
+	case StrategyLockstep:
+		return []func(){func() { s.compareLockstep(group, report) }}
+	case StrategyQuick:
+		return []func(){func() { s.compareQuick(group, report) }}
+	}
+	jobs := make([]func(), 0, len(group))
+	for _, file := range group {
+		f := file
+		jobs = append(jobs, func() {
+			// 遍历后已改变的文件不再读取
+			if err := s.checkVolatile(f, nil); err != nil {
+				report(f, "", err)
+				return
+			}
+			// hash.Hash接口不是并发安全的，要在协程内实例化
+			h := newHash(s.opts.Hash)
+			hashValue, err := s.calcHash(f.Path, h)
+			report(f, hashValue, err)
+		})
+	}
+	return jobs
+}
+
+// indexSignatures 大小唯一、不需要计算Hash值的文件只记录快速签名，
+// 这样索引覆盖所有扫描到的文件，之后的新文件与任何一个相同都能被预筛出来
+func (s *Scanner) indexSignatures(ctx context.Context, groups [][]*FileInfo, files []*FileInfo) {
+	grouped := map[*FileInfo]bool{}
+	for _, g := range groups {
+		for _, f := range g {
+			grouped[f] = true
+		}
+	}
+	for _, f := range files {
+		if ctx.Err() != nil {
+			return
+		}
+		if grouped[f] || IsArchivePath(f.Path) {
+			continue
+		}
+		if sig, err := Signature(f.Path); err == nil {
+			f.Signature = sig
+			s.opts.Index.Add(*f)
+		}
+	}
+}
+
+// lookupIndex 从索引中取出大小和修改时间未变的文件的Hash值，返回已命中、需要计算和已确认不重复的文件。
+// 其他算法算出的Hash值不能与本次的直接比较，只用于排除：组内其他文件都有同一算法的Hash值且都与之不同时，
+// 该文件一定不重复，无需计算；其余的文件再到缓存中查找，仍未命中的按本次的算法重新计算，与已命中的文件一起分组
+func (s *Scanner) lookupIndex(group []*FileInfo) ([]*FileInfo, []*FileInfo, []*FileInfo) {
+	if s.opts.Index == nil || s.opts.Index.Hash() != HashName(s.opts.Hash) {
+		return s.lookupCache(nil, group, nil)
+	}
+	type foreign struct{ algo, hash string }
+	cached, rest, unique := []*FileInfo{}, []*FileInfo{}, []*FileInfo{}
+	others := map[*FileInfo]foreign{}
+	sameHash := map[foreign]int{}
+	sameAlgo := map[string]int{}
+	for _, f := range group {
+		if h, ok := s.opts.Index.Lookup(*f); ok {
+			f.Hash = h
+			cached = append(cached, f)
+		} else if algo, h, ok := s.opts.Index.LookupAny(*f); ok {
+			k := foreign{algo, h}
+			others[f] = k
+			sameHash[k] += 1
+			sameAlgo[algo] += 1
+		}
+	}
+	for _, f := range group {
+		k, ok := others[f]
+		switch {
+		case f.Hash != "":
+		case ok && sameAlgo[k.algo] == len(group) && sameHash[k] == 1:
+			unique = append(unique, f)
+		default:
+			rest = append(rest, f)
+		}
+	}
+	return s.lookupCache(cached, rest, unique)
+}
+
+// lookupCache 在缓存中查找索引未命中的文件，命中的移到 cached 中
+func (s *Scanner) lookupCache(cached, rest, unique []*FileInfo) ([]*FileInfo, []*FileInfo, []*FileInfo) {
+	if s.opts.Cache == nil {
+		return cached, rest, unique
+	}
+	missed := []*FileInfo{}
+	for _, f := range rest {
+		if h, ok := s.opts.Cache.Lookup(*f); ok {
+			f.Hash = h
+			cached = append(cached, f)
+		} else {
+			missed = append(missed, f)
+		}
+	}
+	return cached, missed, unique
+}
+
+// onError 调用错误回调
+func (o *Options) onError(err error) {
+	if o.OnError != nil {
+		o.OnError(err)
+	}
+}
+
+// groupBytes 一组文件的总大小
+func groupBytes(g []*FileInfo) int64 {
+	n := int64(0)
+	for _, f := range g {
+		n += f.Size
+	}
+	return n
+}
+
+// groupsBytes 各组文件的总大小
+func groupsBytes(groups [][]*FileInfo) int64 {
+	n := int64(0)
+	for _, g := range groups {
+		n += groupBytes(g)
+	}
+	return n
+}
@@ -0,0 +1,86 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import "path/filepath"
+
+// 重复范围
+const (
+	ScopeAll      = "all"       // 不限
+	ScopeCrossDir = "cross-dir" // 只列出出现在不同目录中的重复，同一目录下的副本常是有意保留的
+	ScopeSameDir  = "same-dir"  // 只列出同一目录下的重复，每组的文件都在同一目录中
+)
+
+// Scopes 支持的重复范围
+var Scopes = []string{ScopeAll, ScopeCrossDir, ScopeSameDir}
+
+// scopeCandidates 按重复范围预先处理大小相同的组，减少需要计算Hash值的文件：
+// same-dir 按目录拆分各组，cross-dir 去掉全部位于同一目录的组
+func scopeCandidates(groups [][]*FileInfo, scope string, minCopies int) [][]*FileInfo {
+	switch scope {
+	case ScopeSameDir:
+		result := [][]*FileInfo{}
+		for _, g := range groups {
+			order := []string{}
+			dirs := map[string][]*FileInfo{}
+			for _, f := range g {
+				dir := filepath.Dir(f.Path)
+				if _, ok := dirs[dir]; !ok {
+					order = append(order, dir)
+				}
+				dirs[dir] = append(dirs[dir], f)
+			}
+			for _, dir := range order {
+				if len(dirs[dir]) >= minCopies {
+					result = append(result, dirs[dir])
+				}
+			}
+		}
+		return result
+	case ScopeCrossDir:
+		result := groups[:0]
+		for _, g := range groups {
+			if !sameDir(g) {
+				result = append(result, g)
+			}
+		}
+		return result
+	}
+	return groups
+}
+
+// scopeGroups 按重复范围过滤确认的组，cross-dir 时去掉全部位于同一目录的组
+func scopeGroups(l GroupList, scope string) GroupList {
+	if scope != ScopeCrossDir {
+		return l
+	}
+	lst := GroupList{}
+	for _, g := range l {
+		files := make([]*FileInfo, len(g.Files))
+		for i := range g.Files {
+			files[i] = &g.Files[i]
+		}
+		if !sameDir(files) {
+			lst = append(lst, g)
+		}
+	}
+	return lst
+}
+
+// sameDir 文件是否都位于同一目录
+func sameDir(files []*FileInfo) bool {
+	for _, f := range files[1:] {
+		if filepath.Dir(f.Path) != filepath.Dir(files[0].Path) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,93 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// sigSize 签名读取的开头和结尾长度
+const sigSize = 4 << 10
+
+// Signature 计算文件的快速签名: 大小 + 开头4KB的Hash值 + 结尾4KB的Hash值。
+// 签名不同的文件内容一定不同，签名相同的文件仍需计算完整Hash值确认
+func Signature(path string) (string, error) {
+	return signature(OSFS{}, path)
+}
+
+func signature(fsys FileSystem, path string) (string, error) {
+	f, err := fsys.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	ra, ok := f.(io.ReaderAt)
+	if !ok {
+		return "", errors.New("文件不支持随机读取")
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	size := info.Size()
+	buf := make([]byte, sigSize)
+	head, err := io.ReadFull(f, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	h := md5.Sum(buf[:head])
+	tail := h
+	if size > sigSize {
+		n, err := ra.ReadAt(buf, size-sigSize)
+		if err != nil && err != io.EOF {
+			return "", err
+		}
+		tail = md5.Sum(buf[:n])
+	}
+	return fmt.Sprintf("%d:%s:%s", size, hex.EncodeToString(h[:]), hex.EncodeToString(tail[:])), nil
+}
+
+// Candidates 用大小和快速签名预筛文件，返回索引中可能与其内容相同的文件。
+// 没有同样大小的文件时不读取内容；结果为空说明一定不重复，否则仍需比较完整Hash值
+func (x *HashIndex) Candidates(path string) ([]IndexEntry, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	x.m.Lock()
+	same := []IndexEntry{}
+	for _, e := range x.entries {
+		if e.Size == info.Size() && e.Path != path {
+			same = append(same, e)
+		}
+	}
+	x.m.Unlock()
+	if len(same) == 0 {
+		return nil, nil
+	}
+	sig, err := Signature(path)
+	if err != nil {
+		return nil, err
+	}
+	res := []IndexEntry{}
+	for _, e := range same {
+		// 未记录签名的文件无法排除
+		if e.Sig == "" || e.Sig == sig {
+			res = append(res, e)
+		}
+	}
+	return res, nil
+}
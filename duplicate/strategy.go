@@ -0,0 +1,159 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+)
+
+// 比较策略
+const (
+	StrategyFull     = "full"     // 并行计算每个文件的完整Hash值
+	StrategyQuick    = "quick"    // 先只读开头计算部分Hash值，开头相同的文件才计算完整Hash值
+	StrategyLockstep = "lockstep" // 同步逐块比较，内容不同的文件提前淘汰
+)
+
+const (
+	lockstepMax  = 4       // 同步比较的最大文件数，超过时打开文件过多且淘汰效果变差
+	lockstepSize = 1 << 20 // 同步比较时每次读取的块大小
+)
+
+// chooseStrategy 根据同一大小组的文件数和文件大小选择比较策略：不超过 prefilterSize 的小文件
+// 读开头就等于读完整个文件，直接计算完整Hash值；文件少的组同步逐块比较；文件多的大文件组先比较开头，
+// 已启用 Options.Prefilter 时开头已经比较过，直接计算完整Hash值
+func (s *Scanner) chooseStrategy(group []*FileInfo) string {
+	switch {
+	case group[0].Size <= prefilterSize:
+		return StrategyFull
+	case len(group) <= lockstepMax:
+		return StrategyLockstep
+	case !s.opts.Prefilter:
+		return StrategyQuick
+	}
+	return StrategyFull
+}
+
+// compareQuick 先计算各文件开头 prefilterSize 字节的Hash值，开头与其他文件都不同的文件一定不重复，
+// 不再读取；其余文件计算完整Hash值，结果与直接计算完整Hash值相同
+func (s *Scanner) compareQuick(group []*FileInfo, report func(*FileInfo, string, error)) {
+	order := []uint64{}
+	subs := map[uint64][]*FileInfo{}
+	for _, f := range group {
+		// 遍历后已改变的文件不再读取
+		if err := s.checkVolatile(f, nil); err != nil {
+			report(f, "", err)
+			continue
+		}
+		h, err := s.headHash(f.Path)
+		if err != nil {
+			report(f, "", err)
+			continue
+		}
+		if _, ok := subs[h]; !ok {
+			order = append(order, h)
+		}
+		subs[h] = append(subs[h], f)
+	}
+	for _, h := range order {
+		for _, f := range subs[h] {
+			if len(subs[h]) < s.opts.MinCopies {
+				report(f, "", nil)
+				continue
+			}
+			hashValue, err := s.calcHash(f.Path, newHash(s.opts.Hash))
+			report(f, hashValue, err)
+		}
+	}
+}
+
+// lockstepMember 参与同步比较的文件
+type lockstepMember struct {
+	f   *FileInfo
+	r   io.ReadCloser
+	h   hash.Hash
+	buf []byte
+	n   int
+}
+
+// compareLockstep 逐块同步读取同组文件，内容出现差异且不再与其他文件相同的文件立即淘汰，
+// 读到末尾仍然相同的文件顺带得到完整的Hash值
+func (s *Scanner) compareLockstep(group []*FileInfo, report func(*FileInfo, string, error)) {
+	members := []*lockstepMember{}
+	for _, f := range group {
+		r, err := s.open(f.Path)
+		if err != nil {
+			report(f, "", err)
+			continue
+		}
+		defer r.Close()
+		members = append(members, &lockstepMember{f: f, r: r, h: newHash(s.opts.Hash), buf: make([]byte, lockstepSize)})
+	}
+	parts := [][]*lockstepMember{members}
+	for len(parts) > 0 {
+		next := [][]*lockstepMember{}
+		for _, part := range parts {
+			if len(part) < 2 {
+				for _, mb := range part {
+					report(mb.f, "", nil)
+				}
+				continue
+			}
+			eof := true
+			alive := part[:0]
+			for _, mb := range part {
+				n, err := io.ReadFull(mb.r, mb.buf)
+				if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+					report(mb.f, "", err)
+					continue
+				}
+				mb.n = n
+				mb.h.Write(mb.buf[:n])
+				if n == len(mb.buf) {
+					eof = false
+				}
+				alive = append(alive, mb)
+			}
+			for _, sub := range splitByChunk(alive) {
+				if eof && len(sub) > 1 {
+					for _, mb := range sub {
+						report(mb.f, hex.EncodeToString(mb.h.Sum(nil)), nil)
+					}
+					continue
+				}
+				next = append(next, sub)
+			}
+		}
+		parts = next
+	}
+}
+
+// splitByChunk 按本次读到的内容将文件划分为若干子组
+func splitByChunk(members []*lockstepMember) [][]*lockstepMember {
+	subs := [][]*lockstepMember{}
+	for _, mb := range members {
+		placed := false
+		for i, sub := range subs {
+			if bytes.Equal(sub[0].buf[:sub[0].n], mb.buf[:mb.n]) {
+				subs[i] = append(sub, mb)
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			subs = append(subs, []*lockstepMember{mb})
+		}
+	}
+	return subs
+}
@@ -0,0 +1,150 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// traceVersion 轨迹文件的版本
+const traceVersion = 1
+
+// 轨迹事件类型
+const (
+	traceFile  = "file"  // 遍历到候选文件
+	traceHash  = "hash"  // 算出Hash值
+	traceError = "error" // 计算Hash值失败
+)
+
+// TraceHeader 轨迹文件头，记录扫描时的参数
+type TraceHeader struct {
+	Version int      `json:"trace"`
+	Hash    string   `json:"hash"`
+	ByExt   bool     `json:"by_ext,omitempty"`
+	Roots   []string `json:"roots,omitempty"`
+}
+
+// traceEvent 轨迹中的一条事件
+type traceEvent struct {
+	Event string `json:"event"`
+	Path  string `json:"path"`
+	Size  int64  `json:"size,omitempty"`
+	Hash  string `json:"hash,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// TraceRecorder 将遍历结果和Hash值计算结果记录为轨迹，便于脱离文件系统复现分组过程
+type TraceRecorder struct {
+	m   sync.Mutex
+	enc *json.Encoder
+	err error
+}
+
+// NewTraceRecorder 创建轨迹记录器并写入文件头
+func NewTraceRecorder(w io.Writer, dirs []string, opts Options) *TraceRecorder {
+	t := &TraceRecorder{enc: json.NewEncoder(w)}
+	t.err = t.enc.Encode(TraceHeader{Version: traceVersion, Hash: opts.Hash, ByExt: opts.ByExt, Roots: dirs})
+	return t
+}
+
+// Attach 将记录器挂接到扫描选项的回调上，原有回调仍会被调用
+func (t *TraceRecorder) Attach(opts *Options) {
+	onScanned, onHash, onError := opts.OnFileScanned, opts.OnHashComputed, opts.OnError
+	opts.OnFileScanned = func(f FileInfo) {
+		t.write(traceEvent{Event: traceFile, Path: f.Path, Size: f.Size})
+		if onScanned != nil {
+			onScanned(f)
+		}
+	}
+	opts.OnHashComputed = func(f FileInfo) {
+		t.write(traceEvent{Event: traceHash, Path: f.Path, Hash: f.Hash})
+		if onHash != nil {
+			onHash(f)
+		}
+	}
+	opts.OnError = func(err error) {
+		if pe := (*PathError)(nil); errors.As(err, &pe) {
+			t.write(traceEvent{Event: traceError, Path: pe.Path, Error: pe.Err.Error()})
+		}
+		if onError != nil {
+			onError(err)
+		}
+	}
+}
+
+// Err 返回记录过程中的第一个写入错误
+func (t *TraceRecorder) Err() error {
+	t.m.Lock()
+	defer t.m.Unlock()
+	return t.err
+}
+
+func (t *TraceRecorder) write(e traceEvent) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	if t.err == nil {
+		t.err = t.enc.Encode(e)
+	}
+}
+
+// Replay 根据轨迹重新分组，不访问文件系统
+func Replay(r io.Reader) (GroupList, *TraceHeader, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, errors.New("轨迹文件为空")
+	}
+	header := &TraceHeader{}
+	if err := json.Unmarshal(scanner.Bytes(), header); err != nil {
+		return nil, nil, fmt.Errorf("无效的轨迹文件头: %v", err)
+	}
+	if err := CheckVersion("轨迹文件", header.Version, traceVersion, traceVersion); err != nil {
+		return nil, nil, err
+	}
+	files := []*FileInfo{}
+	index := map[string]*FileInfo{}
+	errs := []error{}
+	for line := 2; scanner.Scan(); line++ {
+		e := traceEvent{}
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, header, fmt.Errorf("轨迹第 %d 行无效: %v", line, err)
+		}
+		switch e.Event {
+		case traceFile:
+			f := &FileInfo{Path: e.Path, Size: e.Size}
+			files = append(files, f)
+			index[e.Path] = f
+		case traceHash:
+			if f, ok := index[e.Path]; ok {
+				f.Hash = e.Hash
+			}
+		case traceError:
+			errs = append(errs, newPathError("计算Hash值", e.Path, errors.New(e.Error)))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, header, err
+	}
+	lst := GroupList{}
+	for _, group := range groupBySize(files, header.ByExt, 2) {
+		lst = append(lst, groupByHash(group, 2)...)
+	}
+	sortList(lst)
+	return lst, header, errors.Join(errs...)
+}
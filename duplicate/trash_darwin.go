@@ -0,0 +1,69 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// moveToTrash 将文件移到 ~/.Trash，其他卷上的文件移到该卷的 .Trashes/$uid，重名时在名称后加序号，
+// 返回文件在回收站中的路径
+func moveToTrash(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	trash := filepath.Join(home, ".Trash")
+	if vol, ok := strings.CutPrefix(abs, "/Volumes/"); ok {
+		name, _, _ := strings.Cut(vol, "/")
+		trash = filepath.Join("/Volumes", name, ".Trashes", fmt.Sprint(os.Getuid()))
+	}
+	if err := os.MkdirAll(trash, 0700); err != nil {
+		return "", err
+	}
+	ext := filepath.Ext(abs)
+	base := strings.TrimSuffix(filepath.Base(abs), ext)
+	for i := 1; ; i++ {
+		name := base + ext
+		if i > 1 {
+			name = fmt.Sprintf("%s %d%s", base, i, ext)
+		}
+		dst := filepath.Join(trash, name)
+		if _, err := os.Lstat(dst); err == nil {
+			continue
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+		// renamex_np 的 RENAME_EXCL 保证不会覆盖同时出现的同名文件
+		err := unix.RenamexNp(abs, dst, unix.RENAME_EXCL)
+		if errors.Is(err, unix.EEXIST) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		return dst, nil
+	}
+}
+
+// untrash 访达不记录额外信息，无需清理
+func untrash(dest string) {}
@@ -0,0 +1,86 @@
+//go:build windows
+
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var procSHFileOperationW = windows.NewLazySystemDLL("shell32.dll").NewProc("SHFileOperationW")
+
+const (
+	foDelete          = 0x0003
+	fofSilent         = 0x0004
+	fofNoConfirmation = 0x0010
+	fofAllowUndo      = 0x0040
+	fofNoErrorUI      = 0x0400
+)
+
+// shFileOpStruct SHFILEOPSTRUCTW，64 位系统上按自然对齐，与 shellapi.h 一致
+type shFileOpStruct struct {
+	hwnd                  uintptr
+	wFunc                 uint32
+	pFrom                 *uint16
+	pTo                   *uint16
+	fFlags                uint16
+	fAnyOperationsAborted int32
+	hNameMappings         uintptr
+	lpszProgressTitle     *uint16
+}
+
+// moveToTrash 通过 SHFileOperation 将文件移到回收站，回收站中的位置由系统决定，返回空字符串。
+// 网络驱动器没有回收站，此时 Windows 会直接删除，因此拒绝处理
+func moveToTrash(path string) (string, error) {
+	// 32 位系统上该结构按 1 字节对齐，与上面的定义不一致
+	if unsafe.Sizeof(uintptr(0)) != 8 {
+		return "", errTrashUnsupported
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	root, err := windows.UTF16PtrFromString(filepath.VolumeName(abs) + `\`)
+	if err != nil {
+		return "", err
+	}
+	if windows.GetDriveType(root) == windows.DRIVE_REMOTE {
+		return "", fmt.Errorf("%w: 网络驱动器没有回收站", errTrashUnsupported)
+	}
+	// pFrom 为以两个 NUL 结尾的路径列表
+	from, err := windows.UTF16FromString(abs)
+	if err != nil {
+		return "", err
+	}
+	from = append(from, 0)
+	op := shFileOpStruct{
+		wFunc:  foDelete,
+		pFrom:  &from[0],
+		fFlags: fofAllowUndo | fofNoConfirmation | fofSilent | fofNoErrorUI,
+	}
+	r, _, _ := procSHFileOperationW.Call(uintptr(unsafe.Pointer(&op)))
+	if r != 0 {
+		return "", fmt.Errorf("移到回收站失败，错误码 0x%x", r)
+	}
+	if op.fAnyOperationsAborted != 0 {
+		return "", errors.New("移到回收站被取消")
+	}
+	return "", nil
+}
+
+// untrash 回收站由系统管理，无需清理
+func untrash(dest string) {}
@@ -0,0 +1,126 @@
+//go:build unix && !darwin
+
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// moveToTrash 按 freedesktop.org 回收站规范移动文件：与主目录在同一文件系统时放入
+// $XDG_DATA_HOME/Trash，否则放入该文件系统顶层的 .Trash-$uid，并写入记录原路径的 .trashinfo，
+// 文件管理器可以据此还原；返回文件在回收站中的路径
+func moveToTrash(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	trash, err := trashDir(abs)
+	if err != nil {
+		return "", err
+	}
+	files, info := filepath.Join(trash, "files"), filepath.Join(trash, "info")
+	for _, d := range []string{files, info} {
+		if err := os.MkdirAll(d, 0700); err != nil {
+			return "", err
+		}
+	}
+	name := filepath.Base(abs)
+	for i := 1; ; i++ {
+		entry := name
+		if i > 1 {
+			entry = fmt.Sprintf("%s.%d", name, i)
+		}
+		// 先独占创建 .trashinfo 占用名称，再移动文件
+		infoPath := filepath.Join(info, entry+".trashinfo")
+		f, err := os.OpenFile(infoPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		_, err = fmt.Fprintf(f, "[Trash Info]\nPath=%s\nDeletionDate=%s\n",
+			(&url.URL{Path: abs}).EscapedPath(), time.Now().Format("2006-01-02T15:04:05"))
+		if e := f.Close(); err == nil {
+			err = e
+		}
+		dst := filepath.Join(files, entry)
+		if err == nil {
+			err = os.Rename(abs, dst)
+		}
+		if err != nil {
+			os.Remove(infoPath)
+			return "", err
+		}
+		return dst, nil
+	}
+}
+
+// untrash 文件从回收站移回原处后删除对应的 .trashinfo
+func untrash(dest string) {
+	trash := filepath.Dir(filepath.Dir(dest))
+	os.Remove(filepath.Join(trash, "info", filepath.Base(dest)+".trashinfo"))
+}
+
+// trashDir 返回文件所在文件系统使用的回收站目录
+func trashDir(abs string) (string, error) {
+	data := os.Getenv("XDG_DATA_HOME")
+	if data == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		data = filepath.Join(home, ".local", "share")
+	}
+	home := filepath.Join(data, "Trash")
+	if err := os.MkdirAll(home, 0700); err != nil {
+		return "", err
+	}
+	dev, err := deviceOf(filepath.Dir(abs))
+	if err != nil {
+		return "", err
+	}
+	if hd, err := deviceOf(home); err == nil && hd == dev {
+		return home, nil
+	}
+	// 向上找到该文件系统的顶层目录
+	top := filepath.Dir(abs)
+	for {
+		parent := filepath.Dir(top)
+		if parent == top {
+			break
+		}
+		if d, err := deviceOf(parent); err != nil || d != dev {
+			break
+		}
+		top = parent
+	}
+	return filepath.Join(top, fmt.Sprintf(".Trash-%d", os.Getuid())), nil
+}
+
+// deviceOf 返回路径所在的设备号
+func deviceOf(path string) (uint64, error) {
+	var st unix.Stat_t
+	if err := unix.Stat(path, &st); err != nil {
+		return 0, err
+	}
+	return uint64(st.Dev), nil
+}
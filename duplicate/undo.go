@@ -0,0 +1,204 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// undoVersion 撤销日志的版本
+const undoVersion = 1
+
+// 清理方式
+const (
+	ActionDelete     = "delete"     // 直接删除，无法还原
+	ActionTrash      = "trash"      // 移到回收站
+	ActionQuarantine = "quarantine" // 移到隔离目录
+)
+
+// UndoEntry 撤销日志中的一条记录
+type UndoEntry struct {
+	Time   time.Time `json:"time"`
+	Path   string    `json:"path"` // 原绝对路径
+	Size   int64     `json:"size"`
+	Hash   string    `json:"hash,omitempty"` // 清单中记录的Hash值，未提供时为空
+	Action string    `json:"action"`
+	Dest   string    `json:"dest,omitempty"` // 移到的位置，直接删除或由系统决定位置(如 Windows 回收站)时为空
+}
+
+// undoHeader 撤销日志的第一行
+type undoHeader struct {
+	Version int `json:"version"`
+}
+
+// UndoLog 撤销日志，每清理一个文件追加一行 JSON 格式的 UndoEntry，可用 Restore 还原
+type UndoLog struct {
+	f          *os.File
+	hashes     map[string]string
+	restorable int // 本次写入的记录中有移到的位置、可以还原的条数
+}
+
+// OpenUndoLog 以追加方式打开撤销日志，文件不存在时创建并写入版本信息
+func OpenUndoLog(path string) (*UndoLog, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err == nil && info.Size() == 0 {
+		line, _ := json.Marshal(undoHeader{Version: undoVersion})
+		_, err = f.Write(append(line, '\n'))
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &UndoLog{f: f, hashes: map[string]string{}}, nil
+}
+
+// Expect 提供待清理文件在清单中记录的Hash值，写入日志时一并记录，便于事后核对文件内容
+func (u *UndoLog) Expect(files []FileInfo) {
+	for _, f := range files {
+		if abs, err := filepath.Abs(f.Path); err == nil && f.Hash != "" {
+			u.hashes[abs] = f.Hash
+		}
+	}
+}
+
+// add 记录一个已清理的文件，每条记录单独写入，不经过缓冲
+func (u *UndoLog) add(path string, size int64, action, dest string) error {
+	e := UndoEntry{Time: time.Now(), Path: path, Size: size, Hash: u.hashes[path], Action: action, Dest: dest}
+	line, _ := json.Marshal(e)
+	_, err := u.f.Write(append(line, '\n'))
+	if err == nil && dest != "" {
+		u.restorable += 1
+	}
+	return err
+}
+
+// Restorable 本次打开后写入的记录中可以用 Restore 还原的条数，
+// 直接删除或移到由系统决定位置的回收站(如 Windows)的文件不能还原
+func (u *UndoLog) Restorable() int {
+	return u.restorable
+}
+
+// Close 关闭日志
+func (u *UndoLog) Close() error {
+	return u.f.Close()
+}
+
+// ReadUndoLog 读取撤销日志中的全部记录，崩溃时写了一半的最后一行被忽略
+func ReadUndoLog(path string) ([]UndoEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("撤销日志为空")
+	}
+	head := undoHeader{}
+	if err := json.Unmarshal(scanner.Bytes(), &head); err != nil {
+		return nil, fmt.Errorf("无效的撤销日志: %v", err)
+	}
+	if err := CheckVersion("撤销日志", head.Version, undoVersion, undoVersion); err != nil {
+		return nil, err
+	}
+	entries := []UndoEntry{}
+	for scanner.Scan() {
+		e := UndoEntry{}
+		if json.Unmarshal(scanner.Bytes(), &e) == nil && e.Path != "" {
+			entries = append(entries, e)
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// Restore 按撤销日志将移到回收站或隔离目录的文件放回原位置，同一路径被多次清理时只还原最后一次，
+// 返回还原的数量。原位置已有文件时不覆盖；已还原过的记录直接跳过，可以安全地重复执行；
+// 直接删除的文件和 Windows 回收站中的文件无法还原，以错误返回。onRestored 可为空
+func Restore(entries []UndoEntry, onRestored func(e UndoEntry)) (int, error) {
+	n := 0
+	errs := []error{}
+	seen := map[string]bool{}
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		if seen[e.Path] {
+			continue
+		}
+		seen[e.Path] = true
+		restored, err := restore(e)
+		if err != nil {
+			errs = append(errs, newPathError("还原", e.Path, err))
+			continue
+		}
+		if restored {
+			n += 1
+			if onRestored != nil {
+				onRestored(e)
+			}
+		}
+	}
+	return n, errors.Join(errs...)
+}
+
+// restore 还原单个文件，已经还原过时返回 false
+func restore(e UndoEntry) (bool, error) {
+	if e.Dest == "" {
+		if e.Action == ActionTrash {
+			return false, errors.New("回收站中的位置由系统决定，请从回收站中手动还原")
+		}
+		return false, errors.New("文件已被直接删除，无法还原")
+	}
+	info, err := os.Lstat(e.Dest)
+	if errors.Is(err, fs.ErrNotExist) {
+		if _, err := os.Lstat(e.Path); err == nil {
+			return false, nil
+		}
+		return false, fmt.Errorf("%s 已不存在，可能已被清空", e.Dest)
+	}
+	if err != nil {
+		return false, err
+	}
+	if info.Size() != e.Size {
+		return false, fmt.Errorf("%s 的大小为 %d，与记录的 %d 不符", e.Dest, info.Size(), e.Size)
+	}
+	if err := os.MkdirAll(filepath.Dir(e.Path), 0755); err != nil {
+		return false, err
+	}
+	ok, err := reserve(e.Path)
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		return false, errors.New("原位置已有文件，未覆盖")
+	}
+	if err := moveFile(e.Dest, e.Path, info); err != nil {
+		os.Remove(e.Path)
+		return false, err
+	}
+	if e.Action == ActionTrash {
+		untrash(e.Dest)
+	}
+	return true, nil
+}
@@ -0,0 +1,118 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import (
+	"bytes"
+	"errors"
+	"io"
+)
+
+// verifySize 逐字节比较时每次读取的块大小
+const verifySize = 1 << 20
+
+// errCollision Hash值相同但内容不同
+var errCollision = errors.New("Hash值相同但内容不同")
+
+// verifyGroups 逐字节比较每组Hash值相同的文件，只有内容确实相同的文件才留在同一组，
+// 无法读取的文件从组中移除，返回确认后的组和出现的错误，发现的Hash碰撞以 errCollision 一并返回，
+// 容忍模式下因文件改变而失败或不同的文件以 volatileError 一并返回
+func (s *Scanner) verifyGroups(found GroupList) (GroupList, []error) {
+	lst := GroupList{}
+	errs := []error{}
+	for _, g := range found {
+		rest := g.Files
+		for len(rest) >= s.opts.MinCopies {
+			// 以剩余的第一个文件为基准，与之不同的文件再互相比较
+			ref := rest[0]
+			same, diff := FileInfos{ref}, FileInfos{}
+			refFailed := false
+			for _, f := range rest[1:] {
+				if refFailed {
+					diff = append(diff, f)
+					continue
+				}
+				equal, failed, err := s.compareFiles(ref.Path, f.Path)
+				switch {
+				case err != nil && failed == ref.Path:
+					refFailed = true
+					diff = append(diff, f)
+					errs = append(errs, s.compareError(&ref, err))
+				case err != nil:
+					errs = append(errs, s.compareError(&f, err))
+				case equal:
+					same = append(same, f)
+				default:
+					// 内容不同也可能是比较期间文件被修改，而不是Hash碰撞
+					if verr := s.checkVolatile(&ref, nil); verr != nil {
+						refFailed = true
+						diff = append(diff, f)
+						errs = append(errs, verr)
+					} else if verr := s.checkVolatile(&f, nil); verr != nil {
+						errs = append(errs, verr)
+					} else {
+						diff = append(diff, f)
+						errs = append(errs, newPathError("逐字节比较", f.Path, errCollision))
+					}
+				}
+			}
+			if refFailed {
+				same = same[1:]
+				diff = append(same, diff...)
+			} else if len(same) >= s.opts.MinCopies {
+				v := g
+				v.Files = same
+				lst = append(lst, v)
+			}
+			rest = diff
+		}
+	}
+	return lst, errs
+}
+
+// compareError 包装逐字节比较的错误，文件已改变时返回 volatileError
+func (s *Scanner) compareError(f *FileInfo, err error) error {
+	if verr := s.checkVolatile(f, err); verr != err {
+		return verr
+	}
+	return newPathError("逐字节比较", f.Path, err)
+}
+
+// compareFiles 流式比较两个文件的内容，出错时同时返回出错的文件
+func (s *Scanner) compareFiles(a, b string) (bool, string, error) {
+	ra, err := s.open(a)
+	if err != nil {
+		return false, a, err
+	}
+	defer ra.Close()
+	rb, err := s.open(b)
+	if err != nil {
+		return false, b, err
+	}
+	defer rb.Close()
+	bufA, bufB := make([]byte, verifySize), make([]byte, verifySize)
+	for {
+		na, errA := io.ReadFull(ra, bufA)
+		if errA != nil && !errors.Is(errA, io.EOF) && !errors.Is(errA, io.ErrUnexpectedEOF) {
+			return false, a, errA
+		}
+		nb, errB := io.ReadFull(rb, bufB)
+		if errB != nil && !errors.Is(errB, io.EOF) && !errors.Is(errB, io.ErrUnexpectedEOF) {
+			return false, b, errB
+		}
+		if !bytes.Equal(bufA[:na], bufB[:nb]) {
+			return false, "", nil
+		}
+		if na < verifySize {
+			return true, "", nil
+		}
+	}
+}
@@ -0,0 +1,74 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+)
+
+// VolatileFile 遍历之后消失或被修改的文件，见 Options.Tolerant
+type VolatileFile struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// volatileError 文件在遍历之后发生了变化，不作为扫描错误，只记入易变文件
+type volatileError struct {
+	VolatileFile
+}
+
+func (e *volatileError) Error() string {
+	return fmt.Sprintf("%s %s", e.Path, e.Reason)
+}
+
+// checkVolatile 容忍模式下重新获取文件信息，与遍历时相比已消失或大小、修改时间改变时
+// 返回 volatileError 代替原错误，否则原样返回 err。归档中的文件不会改变，不检查
+func (s *Scanner) checkVolatile(f *FileInfo, err error) error {
+	if !s.opts.Tolerant || IsArchivePath(f.Path) {
+		return err
+	}
+	reason := ""
+	info, e := s.opts.FS.Lstat(f.Path)
+	switch {
+	case errors.Is(e, fs.ErrNotExist):
+		reason = "已删除"
+	case e != nil:
+		return err
+	case !info.Mode().IsRegular():
+		reason = "已不是普通文件"
+	case info.Size() != f.Size:
+		reason = fmt.Sprintf("大小由 %d 变为 %d 字节", f.Size, info.Size())
+	case !f.ModTime.IsZero() && !info.ModTime().Equal(f.ModTime):
+		reason = "修改时间已改变"
+	default:
+		return err
+	}
+	return &volatileError{VolatileFile{Path: f.Path, Reason: reason}}
+}
+
+// addVolatile err 为 volatileError 时记入易变文件并返回 true，调用方须持有锁
+func (s *Scanner) addVolatile(err error) bool {
+	var v *volatileError
+	if !errors.As(err, &v) {
+		return false
+	}
+	s.volatile = append(s.volatile, v.VolatileFile)
+	return true
+}
+
+// Volatile 返回容忍模式下扫描期间消失或被修改、未计入结果的文件，按路径排列，须在扫描结束后调用
+func (s *Scanner) Volatile() []VolatileFile {
+	sort.Slice(s.volatile, func(i, j int) bool { return s.volatile[i].Path < s.volatile[j].Path })
+	return s.volatile
+}
@@ -0,0 +1,114 @@
+/*
+Copyright (c) 2025 Jesse Jin Authors. All rights reserved.
+
+Use of this source code is governed by a MIT-style
+license that can be found in the LICENSE file.
+
+版权由作者 Jesse Jin <afrusrsc@126.com> 所有。
+此源码的使用受 MIT 开源协议约束，详见 LICENSE 文件。
+*/
+
+package duplicate
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"sort"
+)
+
+// walkBatch 每次读取的目录项数，大目录分批读取，每批计为一次请求
+const walkBatch = 1000
+
+// walkFunc 遍历回调，depth 为相对根目录的深度(根目录为0)，
+// 返回 filepath.SkipDir 时不进入该目录，返回其他错误时中止遍历
+type walkFunc func(path string, info fs.FileInfo, depth int, err error) error
+
+// walkTree 以显式栈代替递归遍历目录树，目录层级再深也不会耗尽调用栈。
+// limit 不为空时，每次读取目录和获取文件信息前都会等待限速器，避免云存储挂载触发接口限流；
+// follow 不为空时进入指向目录的符号链接，fn 收到的是目标目录的信息，已进入过的目录不再交给 fn；
+// 目录在 fn 放行后才记为已进入，被 fn 跳过的目录经其他路径遇到时仍可进入
+func walkTree(fsys FileSystem, root string, limit *rateLimiter, follow *linkFollower, fn walkFunc) error {
+	limit.wait()
+	info, err := fsys.Lstat(root)
+	if follow != nil && info != nil && info.IsDir() && follow.entered(root, info) {
+		return nil
+	}
+	if err = fn(root, info, 0, err); err != nil || info == nil || !info.IsDir() || (follow != nil && !follow.enter(root, info)) {
+		if errors.Is(err, filepath.SkipDir) {
+			return nil
+		}
+		return err
+	}
+	type dirEntry struct {
+		path  string
+		depth int
+	}
+	stack := []dirEntry{{root, 0}}
+	for len(stack) > 0 {
+		dir := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		entries, err := readDir(fsys, dir.path, limit)
+		if err != nil {
+			if err = fn(dir.path, nil, dir.depth, err); err != nil && !errors.Is(err, filepath.SkipDir) {
+				return err
+			}
+			continue
+		}
+		subDirs := []dirEntry{}
+		for _, e := range entries {
+			path := filepath.Join(dir.path, e.Name())
+			limit.wait()
+			info, err := e.Info()
+			if follow != nil && info != nil {
+				if info = follow.resolve(path, info, limit); info.IsDir() && follow.entered(path, info) {
+					continue
+				}
+			}
+			err = fn(path, info, dir.depth+1, err)
+			if errors.Is(err, filepath.SkipDir) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if info != nil && info.IsDir() && (follow == nil || follow.enter(path, info)) {
+				subDirs = append(subDirs, dirEntry{path, dir.depth + 1})
+			}
+		}
+		// 逆序入栈，保证按名称顺序遍历
+		for i := len(subDirs) - 1; i >= 0; i-- {
+			stack = append(stack, subDirs[i])
+		}
+	}
+	return nil
+}
+
+// readDir 分批读取目录项并按名称排序
+func readDir(fsys FileSystem, dir string, limit *rateLimiter) ([]fs.DirEntry, error) {
+	limit.wait()
+	f, err := fsys.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	d, ok := f.(fs.ReadDirFile)
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: dir, Err: errors.New("不是目录")}
+	}
+	entries := []fs.DirEntry{}
+	for {
+		batch, err := d.ReadDir(walkBatch)
+		entries = append(entries, batch...)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		limit.wait()
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}